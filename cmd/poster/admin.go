@@ -0,0 +1,256 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kostromin59/poster/internal/apps/poster"
+	"github.com/kostromin59/poster/internal/configs"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+func migrate(args []string) {
+	if len(args) != 1 || (args[0] != "up" && args[0] != "down" && args[0] != "status") {
+		fmt.Fprintln(os.Stderr, "Usage: poster migrate up|down|status")
+		os.Exit(2)
+	}
+
+	if err := poster.Migrate(loadConfig[configs.Migrate](), args[0]); err != nil {
+		fail(err)
+	}
+}
+
+func post(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: poster post list|create|delete [flags]")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "list":
+		postList(args[1:])
+	case "create":
+		postCreate(args[1:])
+	case "delete":
+		postDelete(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: poster post list|create|delete [flags]")
+		os.Exit(2)
+	}
+}
+
+func postList(args []string) {
+	fs := flag.NewFlagSet("post list", flag.ExitOnError)
+	filterFlags := newPostFilterFlags(fs)
+	limit := fs.Uint64("limit", 20, "max number of posts")
+	offset := fs.Uint64("offset", 0, "number of skipped posts")
+	_ = fs.Parse(args)
+
+	cfg := loadConfig[configs.Posts]()
+
+	if err := poster.ListPosts(cfg, filterFlags.filters(cfg.Location), *offset, *limit); err != nil {
+		fail(err)
+	}
+}
+
+func postCreate(args []string) {
+	fs := flag.NewFlagSet("post create", flag.ExitOnError)
+	title := fs.String("title", "", "post title")
+	content := fs.String("content", "", "post content, HTML supported by Telegram")
+	publishDate := fs.String("publish-date", "", "publish date (YYYY-MM-DD HH:MM), now by default")
+	tags := fs.String("tags", "", "comma separated tags")
+	sources := fs.String("sources", "", "comma separated sources")
+	_ = fs.Parse(args)
+
+	if *title == "" || *content == "" || *sources == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cfg := loadConfig[configs.Posts]()
+
+	dto := models.CreatePostDTO{
+		Title:       *title,
+		Content:     *content,
+		PublishDate: time.Now(),
+	}
+
+	if *publishDate != "" {
+		loc, err := time.LoadLocation(cfg.Location)
+		if err != nil {
+			fail(err)
+		}
+
+		dto.PublishDate, err = time.ParseInLocation(dateTimeLayout, *publishDate, loc)
+		if err != nil {
+			fail(fmt.Errorf("invalid -publish-date, expected YYYY-MM-DD HH:MM: %w", err))
+		}
+	}
+
+	for _, t := range splitTags(*tags) {
+		dto.Tags = append(dto.Tags, models.Tag(t))
+	}
+
+	for _, s := range splitList(*sources) {
+		dto.Sources = append(dto.Sources, models.Source(s))
+	}
+
+	if err := poster.CreatePost(cfg, dto); err != nil {
+		fail(err)
+	}
+}
+
+func postDelete(args []string) {
+	fs := flag.NewFlagSet("post delete", flag.ExitOnError)
+	id := fs.String("id", "", "post id")
+	_ = fs.Parse(args)
+
+	if *id == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := poster.DeletePost(loadConfig[configs.Posts](), models.PostID(*id)); err != nil {
+		fail(err)
+	}
+}
+
+func category(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: poster category list|create [flags]")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "list":
+		categoryList(args[1:])
+	case "create":
+		categoryCreate(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: poster category list|create [flags]")
+		os.Exit(2)
+	}
+}
+
+func categoryList(args []string) {
+	fs := flag.NewFlagSet("category list", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	if err := poster.ListCategories(loadConfig[configs.Posts]()); err != nil {
+		fail(err)
+	}
+}
+
+func categoryCreate(args []string) {
+	fs := flag.NewFlagSet("category create", flag.ExitOnError)
+	slug := fs.String("slug", "", "category slug used in filters")
+	title := fs.String("title", "", "category title")
+	parentID := fs.String("parent-id", "", "id of the parent category, top level by default")
+	order := fs.Int("order", 0, "sort order among categories of the same parent")
+	_ = fs.Parse(args)
+
+	if *slug == "" || *title == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	dto := models.CreateCategoryDTO{
+		Slug:  *slug,
+		Title: *title,
+		Order: *order,
+	}
+
+	if *parentID != "" {
+		id := models.CategoryID(*parentID)
+		dto.ParentID = &id
+	}
+
+	if err := poster.CreateCategory(loadConfig[configs.Posts](), dto); err != nil {
+		fail(err)
+	}
+}
+
+func channel(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: poster channel list|create [flags]")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "list":
+		channelList(args[1:])
+	case "create":
+		channelCreate(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: poster channel list|create [flags]")
+		os.Exit(2)
+	}
+}
+
+func channelList(args []string) {
+	fs := flag.NewFlagSet("channel list", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	if err := poster.ListChannels(loadConfig[configs.Posts]()); err != nil {
+		fail(err)
+	}
+}
+
+func channelCreate(args []string) {
+	fs := flag.NewFlagSet("channel create", flag.ExitOnError)
+	chatID := fs.Int64("chat-id", 0, "telegram chat id of the channel")
+	title := fs.String("title", "", "channel title shown in the bot")
+	footer := fs.String("footer", "", "footer of posts, the default footer is used when it is empty")
+	templateFile := fs.String("template-file", "", "file with the text/template of posts, the default template is used when it is empty")
+	defaultTags := fs.String("default-tags", "", "comma separated tags added to every post of the channel")
+	silent := fs.Bool("silent", false, "publish posts without notification")
+	_ = fs.Parse(args)
+
+	if *chatID == 0 || *title == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	dto := models.CreateTelegramChannelDTO{
+		ChatID: *chatID,
+		Title:  *title,
+		Footer: *footer,
+		Silent: *silent,
+	}
+
+	if *templateFile != "" {
+		tmpl, err := os.ReadFile(*templateFile)
+		if err != nil {
+			fail(err)
+		}
+
+		dto.Template = string(tmpl)
+	}
+
+	for _, t := range splitTags(*defaultTags) {
+		dto.DefaultTags = append(dto.DefaultTags, models.Tag(t))
+	}
+
+	if err := poster.CreateChannel(loadConfig[configs.Posts](), dto); err != nil {
+		fail(err)
+	}
+}
+
+func events(args []string) {
+	if len(args) == 0 || args[0] != "replay" {
+		fmt.Fprintln(os.Stderr, "Usage: poster events replay [flags]")
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("events replay", flag.ExitOnError)
+	filterFlags := newPostFilterFlags(fs)
+	_ = fs.Parse(args[1:])
+
+	cfg := loadConfig[configs.Events]()
+
+	if err := poster.ReplayEvents(cfg, filterFlags.filters(cfg.Location)); err != nil {
+		fail(err)
+	}
+}
@@ -1,25 +1,279 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/kostromin59/poster/internal/apps/poster"
 	"github.com/kostromin59/poster/internal/configs"
+	"github.com/kostromin59/poster/internal/models"
 )
 
+type command struct {
+	usage string
+	run   func(args []string)
+}
+
+var commands = map[string]command{
+	"serve":           {usage: "run the bot, the worker and the API in one process", run: serve(poster.AllRoles...)},
+	"bot":             {usage: "run the Telegram bot, only one instance may be run", run: serve(poster.RoleBot)},
+	"worker":          {usage: "run the event listener and cron jobs", run: serve(poster.RoleWorker)},
+	"api":             {usage: "run the HTTP API", run: serve(poster.RoleAPI)},
+	"migrate":         {usage: "apply (up), roll back (down) or show (status) migrations", run: migrate},
+	"post":            {usage: "list, create or delete posts", run: post},
+	"category":        {usage: "list or create categories", run: category},
+	"channel":         {usage: "list or create Telegram channels", run: channel},
+	"events":          {usage: "replay published post events", run: events},
+	"export-site":     {usage: "generate the static site", run: exportSite},
+	"import-telegram": {usage: "import posts from the Telegram Desktop export", run: importTelegram},
+	"export-markdown": {usage: "export posts as Markdown files", run: exportMarkdown},
+	"import-markdown": {usage: "import posts from Markdown files", run: importMarkdown},
+	"backup":          {usage: "write the database and media files into the archive", run: backup},
+	"restore":         {usage: "restore the archive into the empty database", run: restore},
+}
+
 func main() {
 	if err := godotenv.Load(".env"); err != nil {
 		slog.Warn(".env not found", slog.String("err", err.Error()))
 	}
 
-	var cfg configs.Poster
+	name, args := "serve", []string{}
+	if len(os.Args) > 1 {
+		name, args = os.Args[1], os.Args[2:]
+	}
+
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd.run(args)
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	fmt.Fprintln(os.Stderr, "Usage: poster [command] [flags], serve is run by default")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, commands[name].usage)
+	}
+}
+
+// dateTimeLayout is the layout of dates with time in flags and in the output, the bot uses it too.
+const dateTimeLayout = "2006-01-02 15:04"
+
+// fail prints the error of the command and exits, commands don't panic on expected errors.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	os.Exit(1)
+}
+
+// loadConfig reads the config of the command from the environment.
+func loadConfig[T any]() *T {
+	var cfg T
 	if err := envconfig.Process("", &cfg); err != nil {
-		panic(err)
+		fail(err)
+	}
+
+	return &cfg
+}
+
+func serve(roles ...poster.Role) func(args []string) {
+	return func(args []string) {
+		fs := flag.NewFlagSet(string(roles[0]), flag.ExitOnError)
+		migrate := fs.Bool("migrate", false, "apply new migrations before the start, same as MIGRATE_ON_START")
+		_ = fs.Parse(args)
+
+		cfg := loadConfig[configs.Poster]()
+		cfg.MigrateOnStart = cfg.MigrateOnStart || *migrate
+
+		if err := poster.Run(cfg, roles...); err != nil {
+			fail(err)
+		}
+	}
+}
+
+func exportSite(args []string) {
+	fs := flag.NewFlagSet("export-site", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	if err := poster.ExportSite(loadConfig[configs.ExportSite]()); err != nil {
+		fail(err)
+	}
+}
+
+func importTelegram(args []string) {
+	fs := flag.NewFlagSet("import-telegram", flag.ExitOnError)
+	dir := fs.String("dir", "", "directory of the Telegram Desktop export with result.json")
+	chatID := fs.Int64("chat-id", 0, "chat id of the channel, by default it is taken from the export")
+	sources := fs.String("sources", "", "comma separated sources of imported posts in addition to tg")
+	_ = fs.Parse(args)
+
+	if *dir == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cfg := loadConfig[configs.ImportTelegram]()
+
+	var postSources []models.Source
+	for _, s := range splitList(*sources) {
+		postSources = append(postSources, models.Source(s))
+	}
+
+	if err := poster.ImportTelegram(cfg, *dir, *chatID, postSources); err != nil {
+		fail(err)
+	}
+}
+
+func exportMarkdown(args []string) {
+	fs := flag.NewFlagSet("export-markdown", flag.ExitOnError)
+	dir := fs.String("dir", "", "output directory")
+	filterFlags := newPostFilterFlags(fs)
+	_ = fs.Parse(args)
+
+	if *dir == "" {
+		fs.Usage()
+		os.Exit(2)
 	}
 
-	if err := poster.Run(&cfg); err != nil {
-		panic(err)
+	cfg := loadConfig[configs.Markdown]()
+
+	if err := poster.ExportMarkdown(cfg, *dir, filterFlags.filters(cfg.Location)); err != nil {
+		fail(err)
+	}
+}
+
+func importMarkdown(args []string) {
+	fs := flag.NewFlagSet("import-markdown", flag.ExitOnError)
+	dir := fs.String("dir", "", "directory with Markdown files written by export-markdown")
+	_ = fs.Parse(args)
+
+	if *dir == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := poster.ImportMarkdown(loadConfig[configs.Markdown](), *dir); err != nil {
+		fail(err)
+	}
+}
+
+func backup(args []string) {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	file := fs.String("file", "poster-backup-"+time.Now().Format("20060102T150405")+".tar.gz", "archive file")
+	_ = fs.Parse(args)
+
+	if err := poster.Backup(loadConfig[configs.Backup](), *file); err != nil {
+		fail(err)
+	}
+}
+
+func restore(args []string) {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	file := fs.String("file", "", "archive file written by the backup command")
+	_ = fs.Parse(args)
+
+	if *file == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := poster.Restore(loadConfig[configs.Backup](), *file); err != nil {
+		fail(err)
+	}
+}
+
+// postFilterFlags are flags of commands which select posts.
+type postFilterFlags struct {
+	title    *string
+	tags     *string
+	sources  *string
+	category *string
+	from     *string
+}
+
+func newPostFilterFlags(fs *flag.FlagSet) *postFilterFlags {
+	return &postFilterFlags{
+		title:    fs.String("title", "", "part of the post title"),
+		tags:     fs.String("tags", "", "comma separated tags"),
+		sources:  fs.String("sources", "", "comma separated sources"),
+		category: fs.String("category", "", "category slug, posts of subcategories are included"),
+		from:     fs.String("from", "", "posts published since the date (YYYY-MM-DD)"),
 	}
 }
+
+func (f *postFilterFlags) filters(location string) models.PostSearchFilters {
+	filters := models.PostSearchFilters{
+		Sources: splitList(*f.sources),
+		Tags:    splitTags(*f.tags),
+	}
+
+	if *f.title != "" {
+		filters.Title = f.title
+	}
+
+	if *f.category != "" {
+		filters.Category = f.category
+	}
+
+	if *f.from != "" {
+		loc, err := time.LoadLocation(location)
+		if err != nil {
+			fail(err)
+		}
+
+		publishedFrom, err := time.ParseInLocation(time.DateOnly, *f.from, loc)
+		if err != nil {
+			fail(fmt.Errorf("invalid -from, expected YYYY-MM-DD: %w", err))
+		}
+
+		filters.PublishedFrom = &publishedFrom
+	}
+
+	return filters
+}
+
+// splitList splits the comma separated flag value.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
+
+// splitTags splits the comma separated tags and adds the leading # to them.
+func splitTags(s string) []string {
+	var tags []string
+	for _, t := range splitList(s) {
+		if !strings.HasPrefix(t, "#") {
+			t = "#" + t
+		}
+
+		tags = append(tags, t)
+	}
+
+	return tags
+}
@@ -0,0 +1,70 @@
+package poster
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/configs"
+	"github.com/kostromin59/poster/internal/infrastructure/backup"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/pkg/storage"
+)
+
+// Backup writes the content of the database and local media files into the archive.
+func Backup(cfg *configs.Backup, filename string) error {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	archiver := backup.NewArchiver(pgxrepository.NewSnapshot(pool), storage.NewLocal(cfg.Media.Dir, cfg.Media.URL))
+
+	manifest, err := archiver.Backup(ctx, filename)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("backup has been written", slog.String("file", filename), slog.Int64("schema_version", manifest.SchemaVersion), slog.Int("files", len(manifest.Files)))
+
+	return nil
+}
+
+// Restore loads the archive written by Backup into the empty database.
+func Restore(cfg *configs.Backup, filename string) error {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	archiver := backup.NewArchiver(pgxrepository.NewSnapshot(pool), storage.NewLocal(cfg.Media.Dir, cfg.Media.URL))
+
+	manifest, err := archiver.Restore(ctx, filename)
+	if err != nil {
+		return err
+	}
+
+	invalidateCache(ctx, cfg.Cache, cfg.Redis)
+
+	slog.Info("backup has been restored", slog.String("file", filename), slog.Time("created_at", manifest.CreatedAt))
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package poster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/configs"
+	"github.com/kostromin59/poster/internal/infrastructure/cachedrepository"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+// ListCategories prints categories in tree order, the ID is needed for the parent of a new category.
+func ListCategories(cfg *configs.Posts) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	// No categories is not an error of the command, only the header is printed.
+	categories, err := pgxrepository.NewCategory(pool).FindAll(ctx)
+	if err != nil && !errors.Is(err, models.ErrCategoryNotFound) {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPARENT ID\tSLUG\tTITLE\tORDER")
+	for _, c := range categories {
+		parentID := "-"
+		if c.ParentID != nil {
+			parentID = string(*c.ParentID)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n", c.ID, parentID, c.Slug, c.Title, c.Order)
+	}
+
+	return w.Flush()
+}
+
+// CreateCategory creates the category. Posts cached by the running app are invalidated,
+// because an empty result for the slug of the new category may be cached.
+func CreateCategory(cfg *configs.Posts, dto models.CreateCategoryDTO) error {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	var categoryRepo cachedrepository.CategoryRepository = pgxrepository.NewCategory(pool)
+	if cfg.Cache.Driver == configs.DriverRedis {
+		redisCache, err := newRedisCache(cfg.Redis)
+		if err != nil {
+			return err
+		}
+
+		categoryRepo = cachedrepository.NewCategory(categoryRepo, redisCache, 0)
+	}
+
+	category, err := categoryRepo.Create(ctx, dto)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("category has been created", slog.String("id", string(category.ID)), slog.String("slug", category.Slug))
+
+	return nil
+}
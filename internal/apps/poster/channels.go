@@ -0,0 +1,81 @@
+package poster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/configs"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/infrastructure/tgbot"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+// ListChannels prints Telegram channels which posts can be published to.
+func ListChannels(cfg *configs.Posts) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	// No channels is not an error of the command, only the header is printed.
+	channels, err := pgxrepository.NewTelegramChannel(pool).FindAll(ctx)
+	if err != nil && !errors.Is(err, models.ErrTelegramChannelNotFound) {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCHAT ID\tTITLE\tDEFAULT TAGS\tSILENT")
+	for _, c := range channels {
+		tags := make([]string, len(c.DefaultTags))
+		for i, t := range c.DefaultTags {
+			tags[i] = string(t)
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%t\n", c.ID, c.ChatID, c.Title, strings.Join(tags, " "), c.Silent)
+	}
+
+	return w.Flush()
+}
+
+// CreateChannel creates the Telegram channel, the bot shows it in the channel step of the post wizard.
+// The template is checked before, otherwise every publication to the channel would fail.
+func CreateChannel(cfg *configs.Posts, dto models.CreateTelegramChannelDTO) error {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})))
+
+	if dto.Template != "" {
+		if _, err := tgbot.ParsePublisherTemplate(dto.Template); err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	channel, err := pgxrepository.NewTelegramChannel(pool).Create(ctx, dto)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("telegram channel has been created", slog.String("id", string(channel.ID)), slog.Int64("chat_id", channel.ChatID))
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package poster
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/configs"
+	"github.com/kostromin59/poster/internal/infrastructure/cronjob"
+	"github.com/kostromin59/poster/internal/infrastructure/dispatchers"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/internal/tracing"
+	"github.com/kostromin59/poster/pkg/kafka"
+)
+
+// ReplayEvents dispatches the published post event again for published posts matching the filters,
+// e.g. to deliver posts to a new source. Publishers skip posts they have already delivered.
+func ReplayEvents(cfg *configs.Events, filters models.PostSearchFilters) error {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("unable to shutdown tracing", slog.String("err", err.Error()))
+		}
+	}()
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	asyncProducer, err := kafka.NewAsyncProducer(cfg.KafkaHosts)
+	if err != nil {
+		return err
+	}
+
+	dispatcher := dispatchers.NewAsyncKakfa(asyncProducer, cfg.PublishedPostTopic)
+
+	dispatched, err := cronjob.DispatchPublishedPosts(ctx, dispatcher, pgxrepository.NewPost(pool), filters)
+
+	// The producer flushes buffered messages on close and reports failed ones into the errors channel.
+	asyncProducer.AsyncClose()
+	failed := dispatcher.HandleErrors()
+
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d events have not been produced", failed, dispatched)
+	}
+
+	slog.Info("events have been replayed", slog.Int("count", dispatched))
+
+	return nil
+}
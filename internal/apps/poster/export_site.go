@@ -0,0 +1,58 @@
+package poster
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/configs"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/infrastructure/staticsite"
+)
+
+// ExportSite renders all published posts of the website source into the static site directory.
+func ExportSite(cfg *configs.ExportSite) error {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	loc, err := time.LoadLocation(cfg.Location)
+	if err != nil {
+		return err
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	generator, err := newStaticSiteGenerator(pgxrepository.NewPost(pool), cfg.StaticSite, loc)
+	if err != nil {
+		return err
+	}
+
+	if err := generator.Build(ctx); err != nil {
+		return err
+	}
+
+	slog.Info("static site has been exported", slog.String("dir", cfg.StaticSite.Dir))
+
+	return nil
+}
+
+func newStaticSiteGenerator(repo staticsite.PostRepository, cfg configs.StaticSite, loc *time.Location) (*staticsite.Generator, error) {
+	return staticsite.NewGenerator(repo, staticsite.Config{
+		Dir:      cfg.Dir,
+		Theme:    cfg.Theme,
+		BaseURL:  cfg.URL,
+		Title:    cfg.Title,
+		PageSize: cfg.PageSize,
+		Location: loc,
+	})
+}
@@ -0,0 +1,58 @@
+package poster
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/configs"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/infrastructure/tgimport"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/storage"
+)
+
+// ImportTelegram imports the channel history from the Telegram Desktop export directory.
+// Imported posts are marked as published to the channel, so the command can be run again with a newer export.
+func ImportTelegram(cfg *configs.ImportTelegram, dir string, chatID int64, sources []models.Source) error {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	loc, err := time.LoadLocation(cfg.Location)
+	if err != nil {
+		return err
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	importer := tgimport.NewImporter(
+		pgxrepository.NewPost(pool),
+		pgxrepository.NewPublication(pool),
+		storage.NewLocal(cfg.Media.Dir, cfg.Media.URL),
+	)
+
+	stats, err := importer.Import(ctx, dir, tgimport.Config{
+		Sources:  sources,
+		ChatID:   chatID,
+		Location: loc,
+	})
+	// Posts imported before a failure are in the database too.
+	invalidateCache(ctx, cfg.Cache, cfg.Redis)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("telegram export has been imported", slog.Int("imported", stats.Imported), slog.Int("skipped", stats.Skipped))
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package poster
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/configs"
+	"github.com/kostromin59/poster/internal/infrastructure/markdown"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+// ExportMarkdown writes published posts matching the filters into the directory as Markdown files with front matter.
+func ExportMarkdown(cfg *configs.Markdown, dir string, filters models.PostSearchFilters) error {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	loc, err := time.LoadLocation(cfg.Location)
+	if err != nil {
+		return err
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	exporter := markdown.NewExporter(pgxrepository.NewPost(pool), &http.Client{Timeout: time.Minute}, loc)
+
+	exported, err := exporter.Export(ctx, dir, filters)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("posts have been exported", slog.Int("count", exported), slog.String("dir", dir))
+
+	return nil
+}
+
+// ImportMarkdown creates posts from Markdown files of the directory written by ExportMarkdown.
+func ImportMarkdown(cfg *configs.Markdown, dir string) error {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	importer := markdown.NewImporter(pgxrepository.NewPost(pool), pgxrepository.NewMedia(pool))
+
+	stats, err := importer.Import(ctx, dir)
+	// Posts imported before a failure are in the database too.
+	invalidateCache(ctx, cfg.Cache, cfg.Redis)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("posts have been imported", slog.Int("imported", stats.Imported), slog.Int("skipped", stats.Skipped))
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package poster
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/kostromin59/poster/internal/configs"
+	"github.com/kostromin59/poster/migrations"
+)
+
+// migrateUp applies new embedded migrations.
+func migrateUp(ctx context.Context, dsn string) error {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	provider, err := migrations.NewProvider(db)
+	if err != nil {
+		return err
+	}
+
+	results, err := provider.Up(ctx)
+	for _, r := range results {
+		slog.Info("migration has been applied", slog.String("migration", r.Source.Path), slog.Duration("duration", r.Duration))
+	}
+
+	return err
+}
+
+// Migrate runs the goose command (up, down or status) with the embedded migrations.
+func Migrate(cfg *configs.Migrate, command string) error {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if command == "up" {
+		return migrateUp(ctx, cfg.Database.DSN())
+	}
+
+	db, err := sql.Open("pgx", cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	provider, err := migrations.NewProvider(db)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "down":
+		r, err := provider.Down(ctx)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("migration has been rolled back", slog.String("migration", r.Source.Path), slog.Duration("duration", r.Duration))
+
+		return nil
+	case "status":
+		statuses, err := provider.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MIGRATION\tSTATE\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "-"
+			if !s.AppliedAt.IsZero() {
+				appliedAt = s.AppliedAt.Format(time.DateTime)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.Source.Path, s.State, appliedAt)
+		}
+
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+}
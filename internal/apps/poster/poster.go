@@ -2,147 +2,499 @@ package poster
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kostromin59/poster/internal/configs"
 	"github.com/kostromin59/poster/internal/events"
 	"github.com/kostromin59/poster/internal/handlers"
+	"github.com/kostromin59/poster/internal/infrastructure/cachedrepository"
 	"github.com/kostromin59/poster/internal/infrastructure/cronjob"
 	"github.com/kostromin59/poster/internal/infrastructure/dispatchers"
 	"github.com/kostromin59/poster/internal/infrastructure/listeners"
+	"github.com/kostromin59/poster/internal/infrastructure/mastodon"
+	"github.com/kostromin59/poster/internal/infrastructure/newsletter"
 	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/infrastructure/restapi"
 	"github.com/kostromin59/poster/internal/infrastructure/tgbot"
+	"github.com/kostromin59/poster/internal/infrastructure/webhook"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/internal/publishers"
+	"github.com/kostromin59/poster/internal/tracing"
 	"github.com/kostromin59/poster/pkg/cache"
 	"github.com/kostromin59/poster/pkg/kafka"
+	"github.com/kostromin59/poster/pkg/lock"
+	"github.com/kostromin59/poster/pkg/storage"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 	"github.com/robfig/cron/v3"
 	"gopkg.in/telebot.v4"
 )
 
-func Run(cfg *configs.Poster) error {
-	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelDebug,
-	})))
+// Role is a part of the app which can be run in a separate process.
+type Role string
 
-	appCtx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+const (
+	// RoleBot polls the Telegram bot. Only one instance of the bot may be run.
+	RoleBot Role = "bot"
+	// RoleWorker listens to events, publishes posts and runs cron jobs.
+	RoleWorker Role = "worker"
+	// RoleAPI serves the HTTP API: newsletter, feeds and media.
+	RoleAPI Role = "api"
+)
+
+var AllRoles = []Role{RoleBot, RoleWorker, RoleAPI}
+
+// telegramReadinessInterval limits calls of getMe by readiness probes.
+const telegramReadinessInterval = 30 * time.Second
+
+// appCache is implemented by cache.Redis and cache.Memory.
+type appCache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	SetWithExpiration(ctx context.Context, key string, data []byte, exp time.Duration) error
+}
+
+type app struct {
+	cfg *configs.Poster
+	loc *time.Location
+
+	postRepo            *pgxrepository.Post
+	cachedPostRepo      *cachedrepository.Post
+	tagRepo             *cachedrepository.Tag
+	sourceRepo          *cachedrepository.Source
+	categoryRepo        *cachedrepository.Category
+	telegramChannelRepo *pgxrepository.TelegramChannel
+	publicationRepo     *pgxrepository.Publication
+	webhookDeliveryRepo *pgxrepository.WebhookDelivery
+	subscriberRepo      *pgxrepository.Subscriber
+	mediaRepo           *pgxrepository.Media
+
+	redisClient *redis.Client
+	cache       appCache
+	locker      cronjob.Locker
+	mux         *http.ServeMux
+	health      *restapi.Health
 
-	setupCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	telegramBot         *telebot.Bot
+	tgPublisher         *tgbot.Publisher
+	webhookPublisher    *webhook.Publisher
+	newsletterPublisher *newsletter.Publisher
+
+	// closers are called in the reverse order on shutdown.
+	closers []func()
+}
+
+// Run runs the roles in one process until SIGINT or SIGTERM.
+func Run(cfg *configs.Poster, roles ...Role) error {
+	logger, err := logging.New(os.Stdout, cfg.Log, cfg.TGBotToken, cfg.Database.Password, cfg.Redis.Password, cfg.SMTP.Password)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
+	appCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	setupCtx, cancel := context.WithTimeout(appCtx, 30*time.Second)
 	defer cancel()
 
-	// Redis
-	// Redis
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     cfg.Redis.Conn,
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
-	})
+	a := &app{cfg: cfg}
+	defer a.close()
 
-	if status := redisClient.Ping(setupCtx); status.Err() != nil {
-		return status.Err()
+	if err := a.setup(setupCtx, roles); err != nil {
+		return err
+	}
+
+	starters := map[Role]func(ctx, setupCtx context.Context) error{
+		RoleWorker: a.startWorker,
+		RoleAPI:    a.startAPI,
+		RoleBot:    a.startBot,
 	}
 
-	// Redis Cache
-	cache, err := cache.NewRedis(redisClient)
+	for _, r := range AllRoles {
+		if !slices.Contains(roles, r) {
+			continue
+		}
+
+		if err := starters[r](appCtx, setupCtx); err != nil {
+			return fmt.Errorf("%s: %w", r, err)
+		}
+	}
+
+	a.health.SetReady()
+
+	slog.Info("app has been started", slog.Any("roles", roles))
+	<-appCtx.Done()
+	slog.Info("app is shutting down")
+
+	// Balancers need time to notice that the app is not ready.
+	a.health.SetShuttingDown()
+	time.Sleep(cfg.ShutdownDelay)
+
+	return nil
+}
+
+func (a *app) close() {
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		a.closers[i]()
+	}
+}
+
+// setup creates dependencies shared by roles.
+func (a *app) setup(ctx context.Context, roles []Role) error {
+	loc, err := time.LoadLocation(a.cfg.Location)
 	if err != nil {
 		return err
 	}
 
-	// Repositories
-	pool, err := pgxpool.New(setupCtx, cfg.Database.DSN())
+	a.loc = loc
+
+	if a.cfg.MigrateOnStart {
+		if err := migrateUp(ctx, a.cfg.Database.DSN()); err != nil {
+			return err
+		}
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, a.cfg.Tracing)
 	if err != nil {
 		return err
 	}
+	a.closers = append(a.closers, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
 
-	postRepo := pgxrepository.NewPost(pool)
-	tagRepo := pgxrepository.NewTag(pool)
-	sourceRepo := pgxrepository.NewSource(pool)
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("unable to shutdown tracing", slog.String("err", err.Error()))
+		}
+	})
 
-	// Kafka
-	consumer, err := kafka.NewConsumer(cfg.KafkaHosts)
+	// Repositories
+	poolCfg, err := pgxpool.ParseConfig(a.cfg.Database.DSN())
 	if err != nil {
 		return err
 	}
+	poolCfg.ConnConfig.Tracer = tracing.NewPgxTracer()
 
-	asyncProducer, err := kafka.NewAsyncProducer(cfg.KafkaHosts)
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		return err
 	}
+	a.closers = append(a.closers, pool.Close)
 
-	// Dispatchers
-	publishedPostDispatcher := dispatchers.NewAsyncKakfa(asyncProducer, cfg.PublishedPostTopic)
+	// Redis is used by the cache, the job locks and the bot state unless they are kept in memory.
+	if a.cfg.Cache.Driver == configs.DriverRedis || a.cfg.TGState.Driver == configs.DriverRedis {
+		a.redisClient = redis.NewClient(&redis.Options{
+			Addr:     a.cfg.Redis.Conn,
+			Password: a.cfg.Redis.Password,
+			DB:       a.cfg.Redis.DB,
+		})
+		a.closers = append(a.closers, func() { _ = a.redisClient.Close() })
 
-	// Cron
-	c := cron.New()
-	defer c.Stop()
-	if err := cronjob.PublishedPost(appCtx, c, publishedPostDispatcher, postRepo); err != nil {
-		return err
+		if err := redisotel.InstrumentTracing(a.redisClient); err != nil {
+			return err
+		}
+
+		if status := a.redisClient.Ping(ctx); status.Err() != nil {
+			return status.Err()
+		}
 	}
-	c.Start()
 
-	// Telegram bot
-	telegramBot, err := telebot.NewBot(telebot.Settings{
-		Token:     cfg.TGBotToken,
-		ParseMode: telebot.ModeHTML,
-		OnError: func(err error, c telebot.Context) {
-			slog.Error("telegram bot error", slog.String("err", err.Error()))
-			_ = c.Send("Что-то пошло не так! Попробуйте ещё раз!")
-		},
+	// Cache
+	switch a.cfg.Cache.Driver {
+	case configs.DriverRedis:
+		a.cache, err = cache.NewRedis(a.redisClient)
+		if err != nil {
+			return err
+		}
+
+		a.locker = lock.NewRedis(a.redisClient)
+	case configs.DriverMemory:
+		memoryCache := cache.NewMemory(a.cfg.Cache.MaxEntries, a.cfg.Cache.MaxBytes, time.Minute)
+		a.closers = append(a.closers, func() { _ = memoryCache.Close() })
+
+		a.cache = memoryCache
+		a.locker = lock.NewLocal()
+	default:
+		return fmt.Errorf("unknown cache driver %q", a.cfg.Cache.Driver)
+	}
+
+	if a.cfg.TGState.Driver != configs.DriverRedis && a.cfg.TGState.Driver != configs.DriverMemory {
+		return fmt.Errorf("unknown telegram state driver %q", a.cfg.TGState.Driver)
+	}
+
+	// Read-heavy repositories are cached, writes through them invalidate the cache of every role.
+	// The cron and the static site read posts bypassing the cache, they must see new posts at once.
+	a.postRepo = pgxrepository.NewPost(pool)
+	a.cachedPostRepo = cachedrepository.NewPost(a.postRepo, a.cache, a.cfg.Cache.TTL)
+	a.tagRepo = cachedrepository.NewTag(pgxrepository.NewTag(pool), a.cache, a.cfg.Cache.TTL)
+	a.sourceRepo = cachedrepository.NewSource(pgxrepository.NewSource(pool), a.cache, a.cfg.Cache.TTL)
+	a.categoryRepo = cachedrepository.NewCategory(pgxrepository.NewCategory(pool), a.cache, a.cfg.Cache.TTL)
+	a.telegramChannelRepo = pgxrepository.NewTelegramChannel(pool)
+	a.publicationRepo = pgxrepository.NewPublication(pool)
+	a.webhookDeliveryRepo = pgxrepository.NewWebhookDelivery(pool)
+	a.subscriberRepo = pgxrepository.NewSubscriber(pool)
+	a.mediaRepo = pgxrepository.NewMedia(pool)
+
+	// HTTP server serves health probes of every role and the API of the api role.
+	a.mux = http.NewServeMux()
+	a.health = restapi.NewHealth()
+	a.health.Register(a.mux)
+	a.mux.Handle("GET /metrics", promhttp.Handler())
+	a.health.AddReadiness("postgres", pool.Ping)
+	if a.redisClient != nil {
+		a.health.AddReadiness("redis", func(ctx context.Context) error {
+			return a.redisClient.Ping(ctx).Err()
+		})
+	}
+
+	server := &http.Server{
+		Addr:              a.cfg.HTTPAddr,
+		Handler:           a.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("http server error", slog.String("err", err.Error()))
+		}
+	}()
+	a.closers = append(a.closers, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Error("unable to shutdown http server", slog.String("err", err.Error()))
+		}
 	})
+
+	// Telegram bot is used by the bot and by the telegram publisher of the worker.
+	if slices.Contains(roles, RoleBot) || slices.Contains(roles, RoleWorker) {
+		a.telegramBot, err = telebot.NewBot(telebot.Settings{
+			Token:     a.cfg.TGBotToken,
+			ParseMode: telebot.ModeHTML,
+			Client:    &http.Client{Timeout: time.Minute, Transport: tgbot.NewMetricsTransport(nil)},
+			OnError: func(err error, c telebot.Context) {
+				// Context is nil for errors of the poller.
+				if c == nil {
+					slog.Error("telegram bot error", slog.String("err", err.Error()))
+					return
+				}
+
+				ctx, ok := c.Get(tgbot.ContextKey).(context.Context)
+				if !ok {
+					ctx = context.Background()
+				}
+
+				logging.FromContext(ctx).Error("telegram bot error", slog.String("err", err.Error()))
+				_ = c.Send("Что-то пошло не так! Попробуйте ещё раз!")
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		// The bot renders previews of posts with the publisher.
+		a.tgPublisher = tgbot.NewPublisher(a.telegramBot, a.cfg.TGPublishChatID, "my footer", a.cache, a.telegramChannelRepo, a.publicationRepo)
+	}
+
+	// Publishers
+	a.webhookPublisher = webhook.NewPublisher(&http.Client{Timeout: 10 * time.Second}, a.webhookDeliveryRepo, a.publicationRepo)
+
+	a.newsletterPublisher = newsletter.NewPublisher(
+		newsletter.NewSMTP(a.cfg.SMTP.Addr, a.cfg.SMTP.User, a.cfg.SMTP.Password),
+		a.subscriberRepo,
+		a.publicationRepo,
+		a.cfg.PublicURL,
+		a.cfg.SMTP.From,
+	)
+
+	return nil
+}
+
+func (a *app) startWorker(ctx, setupCtx context.Context) error {
+	// Kafka
+	consumerClient, err := kafka.NewConsumerClient(a.cfg.KafkaHosts)
 	if err != nil {
 		return err
 	}
+	a.closers = append(a.closers, func() { _ = consumerClient.Close() })
 
-	loc, err := time.LoadLocation(cfg.Location)
+	consumerGroup, err := kafka.NewConsumerGroup(consumerClient, a.cfg.KafkaConsumerGroup)
 	if err != nil {
 		return err
 	}
+	a.closers = append(a.closers, func() { _ = consumerGroup.Close() })
 
-	stepTG := tgbot.NewLocalState[string]()
-	createPostState := tgbot.NewLocalState[tgbot.CreatePostState]()
-	createPostTGHandlers := tgbot.NewCreatePost(telegramBot, stepTG, createPostState, postRepo, tagRepo, sourceRepo, loc)
+	asyncProducer, err := kafka.NewAsyncProducer(a.cfg.KafkaHosts)
+	if err != nil {
+		return err
+	}
+	a.closers = append(a.closers, func() { _ = asyncProducer.Close() })
 
-	telegramBot.Use(tgbot.AllowedUsersMiddleware(cfg.TGAllowedUsers), tgbot.ContextMiddleware(), tgbot.CancelMiddleware(stepTG))
-	telegramBot.Handle("/create_post", createPostTGHandlers.Handler())
+	kafkaClient, err := kafka.NewClient(a.cfg.KafkaHosts)
+	if err != nil {
+		return err
+	}
+	a.closers = append(a.closers, func() { _ = kafkaClient.Close() })
 
-	textHandlers := []telebot.HandlerFunc{
-		createPostTGHandlers.TextAwaitingPublishDateHandler(),
-		createPostTGHandlers.TextSubmitSourcesHandler(),
-		createPostTGHandlers.TextAwaitingTagsHandler(),
-		createPostTGHandlers.TextAwaitingContentHandler(),
-		createPostTGHandlers.TextAwaitingTitleHandler(),
+	a.health.AddReadiness("kafka", func(context.Context) error {
+		return kafkaClient.RefreshMetadata(a.cfg.PublishedPostTopic)
+	})
+
+	// Dispatchers
+	publishedPostDispatcher := dispatchers.NewAsyncKakfa(asyncProducer, a.cfg.PublishedPostTopic)
+	go publishedPostDispatcher.HandleErrors()
+
+	// Cron
+	c := cron.New()
+	a.closers = append(a.closers, func() { <-c.Stop().Done() })
+	if err := cronjob.PublishedPost(ctx, c, a.locker, publishedPostDispatcher, a.postRepo); err != nil {
+		return err
 	}
+	c.Start()
 
-	telegramBot.Handle(telebot.OnText, func(c telebot.Context) error {
-		for _, h := range textHandlers {
-			if err := h(c); err != nil {
-				return err
-			}
-		}
+	// Publishers
+	mastodonPublisher := mastodon.NewPublisher(&http.Client{Timeout: 30 * time.Second}, a.publicationRepo, a.cfg.PostURL)
 
-		return nil
+	publisherRegistry := publishers.NewRegistry()
+	publisherRegistry.Register(models.SourceTypeTelegram, func(source models.SourceConfig) (publishers.Publisher, error) {
+		return a.tgPublisher.WithSource(source)
+	})
+	publisherRegistry.Register(models.SourceTypeWebhook, func(source models.SourceConfig) (publishers.Publisher, error) {
+		return a.webhookPublisher.WithSource(source)
 	})
+	publisherRegistry.Register(models.SourceTypeEmail, func(source models.SourceConfig) (publishers.Publisher, error) {
+		return a.newsletterPublisher.WithSource(source)
+	})
+	if a.cfg.StaticSite.Dir != "" {
+		generator, err := newStaticSiteGenerator(a.postRepo, a.cfg.StaticSite, a.loc)
+		if err != nil {
+			return err
+		}
 
-	tgPublisher := tgbot.NewPublisher(telegramBot, cfg.TGPublishChatID, "my footer", cache)
+		publisherRegistry.Register(models.SourceTypeWebsite, func(source models.SourceConfig) (publishers.Publisher, error) {
+			return generator.WithSource(source)
+		})
+	}
+	publisherRegistry.Register(models.SourceTypeMastodon, func(source models.SourceConfig) (publishers.Publisher, error) {
+		return mastodonPublisher.WithSource(source)
+	})
 
 	// Handlers
-	publishedPostTGHandler := handlers.NewPublishedPostTG(tgPublisher)
+	publishedPostHandler := handlers.NewPublishedPost(a.sourceRepo, publisherRegistry)
+	postsCacheHandler := handlers.NewPostsCache(a.cachedPostRepo)
 
 	// Event listeners
-	kafkaPublishedPostListener := listeners.NewKafka(consumer, cfg.PublishedPostTopic)
-	publisedPostCh, err := kafkaPublishedPostListener.Start(appCtx)
+	kafkaPublishedPostListener := listeners.NewKafka(consumerGroup, consumerClient, a.cfg.PublishedPostTopic)
+	publisedPostCh, err := kafkaPublishedPostListener.Start(ctx)
 	if err != nil {
 		return err
 	}
 
-	publishedPostListener := events.NewListener(publisedPostCh, publishedPostTGHandler)
-	publishedPostListener.Start(appCtx)
+	publishedPostListener := events.NewListener(a.cfg.PublishedPostTopic, publisedPostCh, publishedPostHandler, postsCacheHandler)
+	publishedPostListener.Start(ctx)
 
-	slog.Info("app has been started")
-	telegramBot.Start()
+	a.health.AddLiveness("kafka_consumer", func(context.Context) error {
+		return kafkaPublishedPostListener.Err()
+	})
+	a.health.AddReadiness("kafka_consumer_lag", func(context.Context) error {
+		if lag := kafkaPublishedPostListener.Lag(); lag > a.cfg.MaxConsumerLag {
+			return fmt.Errorf("lag %d is greater than %d", lag, a.cfg.MaxConsumerLag)
+		}
+
+		return nil
+	})
+
+	return nil
+}
+
+func (a *app) startAPI(_, _ context.Context) error {
+	restapi.NewNewsletter(a.subscriberRepo, a.newsletterPublisher).Register(a.mux)
+	restapi.NewFeeds(a.cachedPostRepo, a.cfg.FeedTitle, a.cfg.PublicURL, a.cfg.PostURL).Register(a.mux)
+	a.mux.Handle("GET /media/", http.StripPrefix("/media/", http.FileServer(http.Dir(a.cfg.Media.Dir))))
+
+	return nil
+}
+
+func (a *app) startBot(_, _ context.Context) error {
+	telegramBot := a.telegramBot
+
+	stepTG := newBotState[string](a.cfg.TGState, a.redisClient, "step")
+	newPreviewer := func(source models.SourceConfig) (tgbot.CreatePostPreviewer, error) {
+		return a.tgPublisher.WithSource(source)
+	}
+
+	// Files sent to the bot are stored in the media directory served by the api role.
+	mediaStorage := storage.NewLocal(a.cfg.Media.Dir, a.cfg.Media.URL)
+
+	createPostState := newBotState[tgbot.WizardSession[tgbot.CreatePostState]](a.cfg.TGState, a.redisClient, "create_post")
+	createPostTGHandlers := tgbot.NewCreatePost(telegramBot, stepTG, createPostState, a.cachedPostRepo, a.tagRepo, a.sourceRepo, a.telegramChannelRepo, a.categoryRepo, a.mediaRepo, mediaStorage, newPreviewer, a.loc)
+
+	editPostState := newBotState[tgbot.WizardSession[tgbot.CreatePostState]](a.cfg.TGState, a.redisClient, "edit_post")
+	postsTGHandlers := tgbot.NewPosts(telegramBot, stepTG, editPostState, a.cachedPostRepo, a.tagRepo, a.sourceRepo, a.telegramChannelRepo, a.categoryRepo, a.mediaRepo, mediaStorage, newPreviewer, a.loc)
+
+	tagsState := newBotState[tgbot.TagsState](a.cfg.TGState, a.redisClient, "tags")
+	tagsTGHandlers := tgbot.NewTags(telegramBot, stepTG, tagsState, a.tagRepo)
+
+	telegramBot.Use(tgbot.AllowedUsersMiddleware(a.cfg.TGAllowedUsers), tgbot.ContextMiddleware(), tgbot.CancelMiddleware(stepTG))
+	telegramBot.Handle("/create_post", createPostTGHandlers.Handler())
+	telegramBot.Handle("/posts", postsTGHandlers.Handler())
+	telegramBot.Handle("/tags", tagsTGHandlers.Handler())
+
+	conversations := tgbot.NewConversations(stepTG)
+	conversations.Add(createPostTGHandlers)
+	conversations.Add(postsTGHandlers)
+	conversations.Handle(tgbot.StepAwaitingTagName, tagsTGHandlers.TextAwaitingTagNameHandler())
+	telegramBot.Handle(telebot.OnText, conversations.Handler())
+	for _, endpoint := range []string{telebot.OnPhoto, telebot.OnVideo, telebot.OnDocument} {
+		telegramBot.Handle(endpoint, conversations.FileHandler())
+	}
+
+	webhooksTGHandlers := tgbot.NewWebhooks(telegramBot, a.sourceRepo, a.webhookDeliveryRepo, func(source models.SourceConfig) (tgbot.WebhookTester, error) {
+		return a.webhookPublisher.WithSource(source)
+	})
+	telegramBot.Handle("/webhooks", webhooksTGHandlers.Handler())
+
+	var polling atomic.Bool
+	polling.Store(true)
+	go func() {
+		telegramBot.Start()
+		polling.Store(false)
+	}()
+	a.closers = append(a.closers, telegramBot.Stop)
+
+	a.health.AddLiveness("telegram_poller", func(context.Context) error {
+		if !polling.Load() {
+			return errors.New("poller has been stopped")
+		}
+
+		return nil
+	})
+	a.health.AddReadiness("telegram", restapi.CachedHealthCheck(func(context.Context) error {
+		// getMe doesn't take the context, the cached check keeps only one call in flight.
+		_, err := telegramBot.Raw("getMe", nil)
+		return err
+	}, telegramReadinessInterval))
 
 	return nil
 }
+
+// newBotState returns the state of the bot conversations, the name separates states in Redis.
+func newBotState[T any](cfg configs.TGState, redisClient *redis.Client, name string) tgbot.State[T] {
+	if cfg.Driver == configs.DriverRedis {
+		return tgbot.NewRedisState[T](redisClient, name, cfg.IdleTTL)
+	}
+
+	return tgbot.NewLocalState[T](cfg.IdleTTL)
+}
@@ -0,0 +1,168 @@
+package poster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/configs"
+	"github.com/kostromin59/poster/internal/infrastructure/cachedrepository"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// ListPosts prints posts matching the filters, including scheduled ones, the latest first.
+func ListPosts(cfg *configs.Posts, filters models.PostSearchFilters, offset, limit uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	loc, err := time.LoadLocation(cfg.Location)
+	if err != nil {
+		return err
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	// No posts is not an error of the command, only the header is printed.
+	posts, err := pgxrepository.NewPost(pool).Find(ctx, filters, offset, limit)
+	if err != nil && !errors.Is(err, models.ErrPostNotFound) {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPUBLISH DATE\tTITLE\tTAGS\tSOURCES")
+	for _, p := range posts {
+		tags := make([]string, len(p.Tags))
+		for i, t := range p.Tags {
+			tags[i] = string(t)
+		}
+
+		sources := make([]string, len(p.Sources))
+		for i, s := range p.Sources {
+			sources[i] = string(s)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", p.ID, p.PublishDate.In(loc).Format("2006-01-02 15:04"), p.Title, strings.Join(tags, " "), strings.Join(sources, ","))
+	}
+
+	return w.Flush()
+}
+
+// CreatePost creates the post. The post is published by the worker when the publish date comes.
+func CreatePost(cfg *configs.Posts, dto models.CreatePostDTO) error {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	postRepo, err := newInvalidatingPostRepo(pool, cfg.Cache, cfg.Redis)
+	if err != nil {
+		return err
+	}
+
+	post, err := postRepo.Create(ctx, dto)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("post has been created", slog.String("id", string(post.ID)), slog.Time("publish_date", post.PublishDate))
+
+	return nil
+}
+
+// DeletePost deletes the post with its tags, sources and media links.
+// A post which has already been published is not deleted.
+func DeletePost(cfg *configs.Posts, id models.PostID) error {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	postRepo, err := newInvalidatingPostRepo(pool, cfg.Cache, cfg.Redis)
+	if err != nil {
+		return err
+	}
+
+	if err := postRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	slog.Info("post has been deleted", slog.String("id", string(id)))
+
+	return nil
+}
+
+type postWriter interface {
+	Create(ctx context.Context, dto models.CreatePostDTO) (models.Post, error)
+	Delete(ctx context.Context, id models.PostID) error
+}
+
+// newInvalidatingPostRepo returns the post repository which invalidates the cache of the running app.
+// Commands only write posts, so nothing is cached by them. The memory cache of the app
+// can't be reached from the command, its entries expire by the ttl.
+func newInvalidatingPostRepo(pool *pgxpool.Pool, cacheCfg configs.Cache, redisCfg configs.Redis) (postWriter, error) {
+	postRepo := pgxrepository.NewPost(pool)
+	if cacheCfg.Driver != configs.DriverRedis {
+		return postRepo, nil
+	}
+
+	redisCache, err := newRedisCache(redisCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cachedrepository.NewPost(postRepo, redisCache, 0), nil
+}
+
+// invalidateCache invalidates the cache of the running app after commands which write
+// to the database directly. The data is already written, so errors are only logged.
+func invalidateCache(ctx context.Context, cacheCfg configs.Cache, redisCfg configs.Redis) {
+	if cacheCfg.Driver != configs.DriverRedis {
+		return
+	}
+
+	redisCache, err := newRedisCache(redisCfg)
+	if err == nil {
+		err = cachedrepository.Invalidate(ctx, redisCache)
+	}
+
+	if err != nil {
+		slog.Warn("unable to invalidate cache, cached entries expire by the ttl", slog.String("err", err.Error()))
+	}
+}
+
+func newRedisCache(cfg configs.Redis) (*cache.Redis, error) {
+	return cache.NewRedis(redis.NewClient(&redis.Options{
+		Addr:     cfg.Conn,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	}))
+}
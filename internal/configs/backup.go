@@ -0,0 +1,10 @@
+package configs
+
+// Backup is a config of the backup and restore commands.
+type Backup struct {
+	Database Postgres
+	Media    Media
+	// Redis is used to invalidate the cache of the running app after the restore.
+	Redis Redis
+	Cache Cache
+}
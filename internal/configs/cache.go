@@ -0,0 +1,14 @@
+package configs
+
+import "time"
+
+type Cache struct {
+	// Driver is redis or memory. The memory cache is not shared between processes,
+	// so it suits local runs and a single process with all roles.
+	Driver string `envconfig:"CACHE_DRIVER" default:"redis"`
+	// TTL is a lifetime of cached repository queries.
+	TTL time.Duration `envconfig:"CACHE_TTL" default:"5m"`
+	// MaxEntries and MaxBytes limit the memory cache, zero means no limit.
+	MaxEntries int `envconfig:"CACHE_MAX_ENTRIES" default:"10000"`
+	MaxBytes   int `envconfig:"CACHE_MAX_BYTES" default:"67108864"`
+}
@@ -0,0 +1,10 @@
+package configs
+
+// Events is a config of the events replay command.
+type Events struct {
+	KafkaHosts         []string `envconfig:"KAFKA_HOSTS" required:"true"`
+	PublishedPostTopic string   `envconfig:"PUBLISHED_POST_TOPIC" required:"true"`
+	Location           string   `envconfig:"LOCATION" default:"Asia/Yekaterinburg"`
+	Database           Postgres
+	Tracing            Tracing
+}
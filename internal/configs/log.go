@@ -0,0 +1,8 @@
+package configs
+
+type Log struct {
+	// Level is debug, info, warn or error.
+	Level string `envconfig:"LOG_LEVEL" default:"info"`
+	// Format is text or json.
+	Format string `envconfig:"LOG_FORMAT" default:"text"`
+}
@@ -0,0 +1,10 @@
+package configs
+
+// Markdown is a config of the export-markdown and import-markdown commands.
+type Markdown struct {
+	Location string `envconfig:"LOCATION" default:"Asia/Yekaterinburg"`
+	Database Postgres
+	// Redis is used to invalidate the cache of the running app after the import.
+	Redis Redis
+	Cache Cache
+}
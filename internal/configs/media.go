@@ -0,0 +1,17 @@
+package configs
+
+type Media struct {
+	// Dir is a directory of uploaded and imported media files. They are served by the HTTP server under /media/.
+	Dir string `envconfig:"MEDIA_DIR" default:"media"`
+	URL string `envconfig:"MEDIA_URL" default:"http://localhost:8080/media"`
+}
+
+// ImportTelegram is a config of the import-telegram command.
+type ImportTelegram struct {
+	Location string `envconfig:"LOCATION" default:"Asia/Yekaterinburg"`
+	Database Postgres
+	Media    Media
+	// Redis is used to invalidate the cache of the running app after the import.
+	Redis Redis
+	Cache Cache
+}
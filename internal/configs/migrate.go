@@ -0,0 +1,6 @@
+package configs
+
+// Migrate is a config of the migrate command.
+type Migrate struct {
+	Database Postgres
+}
@@ -1,5 +1,7 @@
 package configs
 
+import "time"
+
 type Poster struct {
 	KafkaHosts         []string `envconfig:"KAFKA_HOSTS" required:"true"`
 	PublishedPostTopic string   `envconfig:"PUBLISHED_POST_TOPIC" required:"true"`
@@ -7,6 +9,25 @@ type Poster struct {
 	Location           string   `envconfig:"LOCATION" default:"Asia/Yekaterinburg"`
 	TGPublishChatID    int64    `envconfig:"TG_PUBLUSH_CHAT_ID" required:"true"`
 	TGAllowedUsers     []int64  `envconfig:"TG_ALLOWED_USERS" required:"true"`
-	Database           Postgres
-	Redis              Redis
+	HTTPAddr           string   `envconfig:"HTTP_ADDR" default:":8080"`
+	PublicURL          string   `envconfig:"PUBLIC_URL" default:"http://localhost:8080"`
+	PostURL            string   `envconfig:"POST_URL" default:"http://localhost:8080/posts/{id}"`
+	FeedTitle          string   `envconfig:"FEED_TITLE" default:"Семёныч, блин!"`
+	// KafkaConsumerGroup commits offsets of processed events, all workers share the group.
+	KafkaConsumerGroup string `envconfig:"KAFKA_CONSUMER_GROUP" default:"poster"`
+	// MaxConsumerLag is a number of unprocessed events after which the worker is not ready.
+	MaxConsumerLag int64 `envconfig:"MAX_CONSUMER_LAG" default:"1000"`
+	// ShutdownDelay is a time between turning readiness off and stopping the app.
+	ShutdownDelay time.Duration `envconfig:"SHUTDOWN_DELAY" default:"0s"`
+	// MigrateOnStart applies new migrations before the start.
+	MigrateOnStart bool `envconfig:"MIGRATE_ON_START" default:"false"`
+	Database       Postgres
+	Redis          Redis
+	Cache          Cache
+	TGState        TGState
+	SMTP           SMTP
+	Media          Media
+	StaticSite     StaticSite
+	Tracing        Tracing
+	Log            Log
 }
@@ -0,0 +1,10 @@
+package configs
+
+// Posts is a config of the post list, create and delete commands.
+type Posts struct {
+	Location string `envconfig:"LOCATION" default:"Asia/Yekaterinburg"`
+	Database Postgres
+	// Redis is used to invalidate the cache of the running app after changes.
+	Redis Redis
+	Cache Cache
+}
@@ -1,7 +1,13 @@
 package configs
 
+// Drivers of the parts which are kept in Redis or in the process memory.
+const (
+	DriverRedis  = "redis"
+	DriverMemory = "memory"
+)
+
 type Redis struct {
-	Conn     string `envconfig:"REDIS_CONN" required:"true"`
-	Password string `envconfig:"REDIS_PASSWORD" required:"true"`
+	Conn     string `envconfig:"REDIS_CONN" default:"localhost:6379"`
+	Password string `envconfig:"REDIS_PASSWORD"`
 	DB       int    `envconfig:"REDIS_DB" default:"0"`
 }
@@ -0,0 +1,8 @@
+package configs
+
+type SMTP struct {
+	Addr     string `envconfig:"SMTP_ADDR" default:"localhost:1025"`
+	User     string `envconfig:"SMTP_USER"`
+	Password string `envconfig:"SMTP_PASSWORD"`
+	From     string `envconfig:"SMTP_FROM" default:"poster@localhost"`
+}
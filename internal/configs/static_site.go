@@ -0,0 +1,17 @@
+package configs
+
+type StaticSite struct {
+	// Dir is an output directory. The static site is not rendered when it is empty.
+	Dir      string `envconfig:"STATIC_SITE_DIR"`
+	Theme    string `envconfig:"STATIC_SITE_THEME"`
+	URL      string `envconfig:"STATIC_SITE_URL" default:"http://localhost:8080"`
+	Title    string `envconfig:"STATIC_SITE_TITLE" default:"Семёныч, блин!"`
+	PageSize int    `envconfig:"STATIC_SITE_PAGE_SIZE" default:"10"`
+}
+
+// ExportSite is a config of the export-site command.
+type ExportSite struct {
+	Location   string `envconfig:"LOCATION" default:"Asia/Yekaterinburg"`
+	Database   Postgres
+	StaticSite StaticSite
+}
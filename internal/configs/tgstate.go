@@ -0,0 +1,11 @@
+package configs
+
+import "time"
+
+// TGState is a config of the bot conversation state.
+type TGState struct {
+	// Driver is redis or memory. The state in memory is lost on restart.
+	Driver string `envconfig:"TG_STATE_DRIVER" default:"redis"`
+	// IdleTTL is a time after which an abandoned conversation is removed.
+	IdleTTL time.Duration `envconfig:"TG_STATE_IDLE_TTL" default:"24h"`
+}
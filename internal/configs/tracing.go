@@ -0,0 +1,8 @@
+package configs
+
+type Tracing struct {
+	// Endpoint is an OTLP HTTP endpoint, e.g. http://localhost:4318. Tracing is disabled when it is empty.
+	Endpoint    string  `envconfig:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ServiceName string  `envconfig:"OTEL_SERVICE_NAME" default:"poster"`
+	SampleRatio float64 `envconfig:"OTEL_SAMPLE_RATIO" default:"1"`
+}
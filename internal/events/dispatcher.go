@@ -1,5 +1,7 @@
 package events
 
+import "context"
+
 type AsyncDispatcher interface {
-	Dispatch(any)
+	Dispatch(ctx context.Context, e any)
 }
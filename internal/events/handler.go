@@ -3,5 +3,5 @@ package events
 import "context"
 
 type Handler interface {
-	Handle(context.Context, []byte)
+	Handle(context.Context, []byte) error
 }
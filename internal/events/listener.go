@@ -1,14 +1,28 @@
 package events
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/kostromin59/poster/internal/metrics"
+	"github.com/kostromin59/poster/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
 
 type Listener struct {
+	topic    string
 	handlers []Handler
-	ch       <-chan []byte
+	ch       <-chan Message
 }
 
-func NewListener(ch <-chan []byte, handlers ...Handler) *Listener {
+// NewListener creates the listener of events of the topic. The topic labels metrics and spans of the listener.
+func NewListener(topic string, ch <-chan Message, handlers ...Handler) *Listener {
 	return &Listener{
+		topic:    topic,
 		handlers: handlers,
 		ch:       ch,
 	}
@@ -21,15 +35,42 @@ func (l *Listener) Start(ctx context.Context) {
 			case <-ctx.Done():
 				return
 
-			case event, ok := <-l.ch:
+			case msg, ok := <-l.ch:
 				if !ok {
 					return
 				}
 
-				for _, h := range l.handlers {
-					h.Handle(ctx, event)
-				}
+				l.handle(ctx, msg)
 			}
 		}
 	}()
 }
+
+// handle continues the trace of the dispatcher from the message metadata.
+func (l *Listener) handle(ctx context.Context, msg Message) {
+	metrics.EventsConsumed.WithLabelValues(l.topic).Inc()
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.Metadata))
+	ctx, span := tracing.Tracer().Start(ctx, l.topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(semconv.MessagingDestinationName(l.topic), semconv.MessagingOperationTypeDeliver),
+	)
+	defer span.End()
+
+	// Failed handlers are not retried by the listener, so the message is acknowledged anyway.
+	if msg.Ack != nil {
+		defer msg.Ack()
+	}
+
+	for _, h := range l.handlers {
+		start := time.Now()
+		err := h.Handle(ctx, msg.Payload)
+		metrics.EventHandleDuration.WithLabelValues(l.topic).Observe(time.Since(start).Seconds())
+
+		if err != nil {
+			metrics.EventsFailed.WithLabelValues(l.topic).Inc()
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package events
+
+// Message is an event received by the listener. Metadata carries the trace context
+// and other headers of the message.
+type Message struct {
+	Payload  []byte
+	Metadata map[string]string
+	// Ack marks the message as processed, it is called by the listener after the handlers.
+	// A message which is not acknowledged is received again after the restart.
+	Ack func()
+}
@@ -9,13 +9,16 @@ type PublishedPost struct {
 }
 
 type PublishedPostData struct {
-	ID          string               `json:"id"`
-	Title       string               `json:"title"`
-	Content     string               `json:"content"`
-	PublishDate time.Time            `json:"publish_date"`
-	Tags        []string             `json:"tags"`
-	Sources     []string             `json:"sources"`
-	Media       []PublishedPostMedia `json:"media"`
+	ID          string                 `json:"id"`
+	Title       string                 `json:"title"`
+	Content     string                 `json:"content"`
+	PublishDate time.Time              `json:"publish_date"`
+	Tags        []string               `json:"tags"`
+	Sources     []string               `json:"sources"`
+	Media       []PublishedPostMedia   `json:"media"`
+	Category    *PublishedPostCategory `json:"category,omitempty"`
+	// TelegramChannels are ids of the target telegram channels. Empty means the default channel.
+	TelegramChannels []string `json:"telegram_channels,omitempty"`
 }
 
 type PublishedPostMedia struct {
@@ -23,3 +26,10 @@ type PublishedPostMedia struct {
 	Filetype string `json:"filetype"`
 	URI      string `json:"uri"`
 }
+
+type PublishedPostCategory struct {
+	ID       string  `json:"id"`
+	ParentID *string `json:"parent_id,omitempty"`
+	Slug     string  `json:"slug"`
+	Title    string  `json:"title"`
+}
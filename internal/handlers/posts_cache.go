@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/kostromin59/poster/internal/logging"
+)
+
+type PostsCacheInvalidator interface {
+	Invalidate(ctx context.Context) error
+}
+
+// PostsCache drops cached posts when a post is published, scheduled posts appear
+// in the published ones only at this moment.
+type PostsCache struct {
+	invalidator PostsCacheInvalidator
+}
+
+func NewPostsCache(invalidator PostsCacheInvalidator) *PostsCache {
+	return &PostsCache{
+		invalidator: invalidator,
+	}
+}
+
+func (pc *PostsCache) Handle(ctx context.Context, _ []byte) error {
+	const op = "handlers.PostsCache.Handle"
+
+	if err := pc.invalidator.Invalidate(ctx); err != nil {
+		logging.FromContext(ctx).Error("unable to invalidate posts cache", slog.String("op", op), slog.String("err", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/internal/publishers"
+	"github.com/kostromin59/poster/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type PublishedPostSourceRepository interface {
+	FindAllConfigs(ctx context.Context) ([]models.SourceConfig, error)
+}
+
+type PublishedPostRegistry interface {
+	New(source models.SourceConfig) (publishers.Publisher, error)
+}
+
+// PublishedPost routes the published post to the publishers of the post sources.
+type PublishedPost struct {
+	sourceRepo PublishedPostSourceRepository
+	registry   PublishedPostRegistry
+}
+
+func NewPublishedPost(sourceRepo PublishedPostSourceRepository, registry PublishedPostRegistry) *PublishedPost {
+	return &PublishedPost{
+		sourceRepo: sourceRepo,
+		registry:   registry,
+	}
+}
+
+// Handle publishes the post to its sources. Failed sources don't stop others, their errors are joined.
+func (pp *PublishedPost) Handle(ctx context.Context, e []byte) error {
+	const op = "handlers.PublishedPost.Handle"
+
+	var publishedPostEvent events.PublishedPost
+	if err := json.Unmarshal(e, &publishedPostEvent); err != nil {
+		logging.FromContext(ctx).Error("unable to unmarshal published post event", slog.String("op", op), slog.String("err", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ctx = logging.With(ctx,
+		slog.String(logging.EventIDKey, publishedPostEvent.EventID),
+		slog.String(logging.PostIDKey, publishedPostEvent.Data.ID),
+	)
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	sources, err := pp.sourceRepo.FindAllConfigs(ctx)
+	if err != nil {
+		if errors.Is(err, models.ErrSourceNotFound) {
+			return nil
+		}
+
+		log.Error("unable to find sources", slog.String("err", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var errs []error
+	for _, source := range sources {
+		if !slices.Contains(publishedPostEvent.Data.Sources, string(source.Source)) {
+			continue
+		}
+
+		sourceLog := log.With(slog.String("source", string(source.Source)), slog.String("type", string(source.Type)))
+
+		publisher, err := pp.registry.New(source)
+		if err != nil {
+			if errors.Is(err, publishers.ErrUnknownType) {
+				sourceLog.Debug("source has no publisher")
+				continue
+			}
+
+			sourceLog.Error("unable to create publisher", slog.String("err", err.Error()))
+			errs = append(errs, fmt.Errorf("%s: %w", source.Source, err))
+			continue
+		}
+
+		if err := pp.publish(ctx, publisher, source, publishedPostEvent); err != nil {
+			sourceLog.Error("unable to publish post", slog.String("err", err.Error()))
+			errs = append(errs, fmt.Errorf("%s: %w", source.Source, err))
+			continue
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (pp *PublishedPost) publish(ctx context.Context, publisher publishers.Publisher, source models.SourceConfig, e events.PublishedPost) error {
+	ctx, span := tracing.Tracer().Start(ctx, "publishers.Publisher.Publish", trace.WithAttributes(
+		attribute.String("post.id", e.Data.ID),
+		attribute.String("event.id", e.EventID),
+		attribute.String("source", string(source.Source)),
+		attribute.String("source.type", string(source.Type)),
+	))
+	defer span.End()
+
+	if err := publisher.Publish(ctx, e); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	return nil
+}
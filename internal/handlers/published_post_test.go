@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/internal/publishers"
+)
+
+type sourceRepoStub []models.SourceConfig
+
+func (s sourceRepoStub) FindAllConfigs(context.Context) ([]models.SourceConfig, error) {
+	return s, nil
+}
+
+type publisherFunc func(ctx context.Context, post events.PublishedPost) error
+
+func (f publisherFunc) Publish(ctx context.Context, post events.PublishedPost) error {
+	return f(ctx, post)
+}
+
+func TestPublishedPostHandle(t *testing.T) {
+	var published []string
+
+	registry := publishers.NewRegistry()
+	registry.Register("fake", func(source models.SourceConfig) (publishers.Publisher, error) {
+		var cfg struct {
+			Name string `json:"name"`
+		}
+
+		if err := json.Unmarshal(source.Config, &cfg); err != nil {
+			return nil, err
+		}
+
+		return publisherFunc(func(_ context.Context, post events.PublishedPost) error {
+			published = append(published, cfg.Name+":"+post.Data.ID)
+			return nil
+		}), nil
+	})
+	registry.Register("broken", func(models.SourceConfig) (publishers.Publisher, error) {
+		return publisherFunc(func(context.Context, events.PublishedPost) error {
+			return errors.New("broken publisher")
+		}), nil
+	})
+
+	handler := NewPublishedPost(sourceRepoStub{
+		{Source: "first", Type: "fake", Config: json.RawMessage(`{"name":"first"}`)},
+		{Source: "second", Type: "fake", Config: json.RawMessage(`{"name":"second"}`)},
+		{Source: "broken", Type: "broken"},
+		{Source: "website", Type: models.SourceTypeWebsite},
+	}, registry)
+
+	event, err := json.Marshal(events.PublishedPost{
+		EventID: "event",
+		Data: events.PublishedPostData{
+			ID:      "post",
+			Sources: []string{"broken", "second", "website"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to marshal event: %q", err)
+	}
+
+	if err := handler.Handle(t.Context(), event); err == nil {
+		t.Error("expected error of the broken publisher but got nil")
+	}
+
+	expected := []string{"second:post"}
+	if !slices.Equal(published, expected) {
+		t.Errorf("expected published %+v but got %+v", expected, published)
+	}
+}
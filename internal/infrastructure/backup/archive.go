@@ -0,0 +1,185 @@
+// Package backup writes the content of the database and media files into a versioned archive
+// and restores it into an empty database.
+//
+// The archive is a gzipped tar with data/<table>.jsonl files (one JSON row per line), media/<name> files
+// of the local media storage and manifest.json with SHA-256 checksums of all other files.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// FormatVersion is a version of the archive layout.
+const FormatVersion = 1
+
+const (
+	manifestName = "manifest.json"
+	dataDir      = "data/"
+	mediaDir     = "media/"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported archive format")
+	ErrSchemaMismatch    = errors.New("schema version of the archive differs from the database")
+	ErrChecksumMismatch  = errors.New("checksum mismatch")
+	ErrNoManifest        = errors.New("manifest not found")
+)
+
+type Manifest struct {
+	Format int `json:"format"`
+	// SchemaVersion is a version of the last applied migration of the backed up database.
+	SchemaVersion int64          `json:"schema_version"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Files         []ManifestFile `json:"files"`
+}
+
+type ManifestFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// Rows is a number of rows of the table file.
+	Rows int `json:"rows,omitempty"`
+}
+
+type archiveWriter struct {
+	f        *os.File
+	gz       *gzip.Writer
+	tw       *tar.Writer
+	manifest Manifest
+}
+
+func newArchiveWriter(filename string, manifest Manifest) (*archiveWriter, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(f)
+
+	return &archiveWriter{f: f, gz: gz, tw: tar.NewWriter(gz), manifest: manifest}, nil
+}
+
+// add writes the file into the archive. The content is buffered in a temporary file,
+// because the tar header needs the size.
+func (w *archiveWriter) add(name string, write func(w io.Writer) (rows int, err error)) error {
+	tmp, err := os.CreateTemp("", "poster-backup-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+
+	rows, err := write(io.MultiWriter(tmp, h))
+	if err != nil {
+		return err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := w.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    size,
+		ModTime: w.manifest.CreatedAt,
+	}); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w.tw, tmp); err != nil {
+		return err
+	}
+
+	w.manifest.Files = append(w.manifest.Files, ManifestFile{
+		Name:   name,
+		Size:   size,
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+		Rows:   rows,
+	})
+
+	return nil
+}
+
+// close writes the manifest and closes the archive.
+func (w *archiveWriter) close(manifest []byte) error {
+	err := w.tw.WriteHeader(&tar.Header{
+		Name:    manifestName,
+		Mode:    0o644,
+		Size:    int64(len(manifest)),
+		ModTime: w.manifest.CreatedAt,
+	})
+	if err == nil {
+		_, err = w.tw.Write(manifest)
+	}
+
+	return errors.Join(err, w.tw.Close(), w.gz.Close(), w.f.Close())
+}
+
+// abort closes the archive and removes the incomplete file.
+func (w *archiveWriter) abort() {
+	_ = w.tw.Close()
+	_ = w.gz.Close()
+	_ = w.f.Close()
+	_ = os.Remove(w.f.Name())
+}
+
+// walkArchive calls fn for every file of the archive.
+func walkArchive(filename string, fn func(name string, r io.Reader) error) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err := fn(header.Name, tr); err != nil {
+			return fmt.Errorf("%s: %w", header.Name, err)
+		}
+	}
+}
+
+func tableName(name string) (string, bool) {
+	table, ok := strings.CutPrefix(name, dataDir)
+	if !ok {
+		return "", false
+	}
+
+	return strings.CutSuffix(table, ".jsonl")
+}
@@ -0,0 +1,248 @@
+package backup
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kostromin59/poster/internal/logging"
+)
+
+// maxRowSize limits the size of the JSON row while restoring.
+const maxRowSize = 64 << 20
+
+type Database interface {
+	Tables() []string
+	SchemaVersion(ctx context.Context) (int64, error)
+	Dump(ctx context.Context, dump func(schemaVersion int64, rows func(table string, fn func(row []byte) error) error) error) error
+	Restore(ctx context.Context, load func(insert func(table string, row []byte) error) error) error
+}
+
+type MediaStorage interface {
+	Name(uri string) (string, bool)
+	Open(name string) (io.ReadCloser, error)
+	Save(ctx context.Context, name string, r io.Reader) (string, error)
+}
+
+type Archiver struct {
+	db      Database
+	storage MediaStorage
+}
+
+func NewArchiver(db Database, storage MediaStorage) *Archiver {
+	return &Archiver{
+		db:      db,
+		storage: storage,
+	}
+}
+
+// Backup writes all tables and files of the local media storage into the archive.
+// Media stored elsewhere keep only their rows.
+func (a *Archiver) Backup(ctx context.Context, filename string) (Manifest, error) {
+	const op = "backup.Archiver.Backup"
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	// Tables are dumped from one snapshot of the database, so rows of relations match their posts.
+	var (
+		w          *archiveWriter
+		mediaNames []string
+	)
+	err := a.db.Dump(ctx, func(schemaVersion int64, rows func(table string, fn func(row []byte) error) error) error {
+		var err error
+		w, err = newArchiveWriter(filename, Manifest{
+			Format:        FormatVersion,
+			SchemaVersion: schemaVersion,
+			CreatedAt:     time.Now().UTC().Truncate(time.Second),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, table := range a.db.Tables() {
+			err := w.add(dataDir+table+".jsonl", func(out io.Writer) (int, error) {
+				count := 0
+				err := rows(table, func(row []byte) error {
+					count++
+
+					if table == "media" {
+						var media struct {
+							URI string `json:"uri"`
+						}
+
+						if err := json.Unmarshal(row, &media); err != nil {
+							return err
+						}
+
+						if name, ok := a.storage.Name(media.URI); ok {
+							mediaNames = append(mediaNames, name)
+						}
+					}
+
+					_, err := out.Write(append(row, '\n'))
+
+					return err
+				})
+
+				return count, err
+			})
+			if err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		if w != nil {
+			w.abort()
+		}
+
+		return Manifest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, name := range mediaNames {
+		r, err := a.storage.Open(name)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				log.Warn("media file not found", slog.String("name", name))
+				continue
+			}
+
+			w.abort()
+			return Manifest{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		err = w.add(mediaDir+name, func(out io.Writer) (int, error) {
+			_, err := io.Copy(out, r)
+			return 0, err
+		})
+		r.Close()
+
+		if err != nil {
+			w.abort()
+			return Manifest{}, fmt.Errorf("%s: %s: %w", op, name, err)
+		}
+	}
+
+	manifest, err := json.MarshalIndent(w.manifest, "", "  ")
+	if err != nil {
+		w.abort()
+		return Manifest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := w.close(manifest); err != nil {
+		_ = os.Remove(filename)
+		return Manifest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return w.manifest, nil
+}
+
+// Restore verifies checksums of the archive and loads it into the empty database in one transaction.
+// Media files are written into the storage before the commit.
+func (a *Archiver) Restore(ctx context.Context, filename string) (Manifest, error) {
+	const op = "backup.Archiver.Restore"
+
+	manifest, err := Verify(filename)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	schemaVersion, err := a.db.SchemaVersion(ctx)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if schemaVersion != manifest.SchemaVersion {
+		return Manifest{}, fmt.Errorf("%s: %w: %d != %d", op, ErrSchemaMismatch, manifest.SchemaVersion, schemaVersion)
+	}
+
+	err = a.db.Restore(ctx, func(insert func(table string, row []byte) error) error {
+		return walkArchive(filename, func(name string, r io.Reader) error {
+			if table, ok := tableName(name); ok {
+				scanner := bufio.NewScanner(r)
+				scanner.Buffer(nil, maxRowSize)
+
+				for scanner.Scan() {
+					if err := insert(table, scanner.Bytes()); err != nil {
+						return err
+					}
+				}
+
+				return scanner.Err()
+			}
+
+			if media, ok := strings.CutPrefix(name, mediaDir); ok {
+				_, err := a.storage.Save(ctx, media, r)
+				return err
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		return Manifest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return manifest, nil
+}
+
+// Verify checks checksums of all files of the archive against its manifest and returns the manifest.
+func Verify(filename string) (Manifest, error) {
+	const op = "backup.Verify"
+
+	var (
+		manifest     Manifest
+		haveManifest bool
+		checksums    = make(map[string]string)
+	)
+
+	err := walkArchive(filename, func(name string, r io.Reader) error {
+		if name == manifestName {
+			haveManifest = true
+			return json.NewDecoder(r).Decode(&manifest)
+		}
+
+		h := sha256.New()
+		if _, err := io.Copy(h, r); err != nil {
+			return err
+		}
+
+		checksums[name] = hex.EncodeToString(h.Sum(nil))
+
+		return nil
+	})
+	if err != nil {
+		return Manifest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !haveManifest {
+		return Manifest{}, fmt.Errorf("%s: %w", op, ErrNoManifest)
+	}
+
+	if manifest.Format != FormatVersion {
+		return Manifest{}, fmt.Errorf("%s: %w: %d", op, ErrUnsupportedFormat, manifest.Format)
+	}
+
+	if len(checksums) != len(manifest.Files) {
+		return Manifest{}, fmt.Errorf("%s: %w: %d files instead of %d", op, ErrChecksumMismatch, len(checksums), len(manifest.Files))
+	}
+
+	for _, f := range manifest.Files {
+		if checksums[f.Name] != f.SHA256 {
+			return Manifest{}, fmt.Errorf("%s: %s: %w", op, f.Name, ErrChecksumMismatch)
+		}
+	}
+
+	return manifest, nil
+}
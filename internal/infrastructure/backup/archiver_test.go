@@ -0,0 +1,178 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/storage"
+)
+
+type databaseStub struct {
+	version int64
+	tables  []string
+	rows    map[string][]string
+}
+
+func (d *databaseStub) Tables() []string {
+	return d.tables
+}
+
+func (d *databaseStub) SchemaVersion(_ context.Context) (int64, error) {
+	return d.version, nil
+}
+
+func (d *databaseStub) Dump(_ context.Context, dump func(schemaVersion int64, rows func(table string, fn func(row []byte) error) error) error) error {
+	return dump(d.version, func(table string, fn func(row []byte) error) error {
+		for _, row := range d.rows[table] {
+			if err := fn([]byte(row)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (d *databaseStub) Restore(_ context.Context, load func(insert func(table string, row []byte) error) error) error {
+	if len(d.rows) != 0 {
+		return models.ErrDatabaseNotEmpty
+	}
+
+	rows := make(map[string][]string)
+	if err := load(func(table string, row []byte) error {
+		rows[table] = append(rows[table], string(row))
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	d.rows = rows
+
+	return nil
+}
+
+func TestArchiverBackupRestore(t *testing.T) {
+	mediaURL := "https://example.com/media"
+
+	source := storage.NewLocal(t.TempDir(), mediaURL)
+	uri, err := source.Save(t.Context(), "telegram/1/photo.jpg", strings.NewReader("photo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := &databaseStub{
+		version: 20251222091530,
+		tables:  []string{"posts", "media"},
+		rows: map[string][]string{
+			"posts": {`{"id":"1","title":"первый"}`, `{"id":"2","title":"второй"}`},
+			"media": {`{"id":"1","uri":"` + uri + `"}`, `{"id":"2","uri":"https://cdn.example.com/2.jpg"}`},
+		},
+	}
+
+	filename := filepath.Join(t.TempDir(), "backup.tar.gz")
+
+	manifest, err := NewArchiver(db, source).Backup(t.Context(), filename)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if len(manifest.Files) != 3 || manifest.Files[0].Rows != 2 {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+
+	target := storage.NewLocal(t.TempDir(), mediaURL)
+	restored := &databaseStub{version: db.version}
+
+	if _, err := NewArchiver(restored, target).Restore(t.Context(), filename); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if !reflect.DeepEqual(db.rows, restored.rows) {
+		t.Errorf("expected rows %v but got %v", db.rows, restored.rows)
+	}
+
+	f, err := target.Open("telegram/1/photo.jpg")
+	if err != nil {
+		t.Fatalf("media has not been restored: %q", err)
+	}
+	defer f.Close()
+
+	if data, _ := io.ReadAll(f); string(data) != "photo" {
+		t.Errorf("unexpected media content %q", data)
+	}
+
+	_, err = NewArchiver(restored, target).Restore(t.Context(), filename)
+	if !errors.Is(err, models.ErrDatabaseNotEmpty) {
+		t.Errorf("expected error %q but got %q", models.ErrDatabaseNotEmpty, err)
+	}
+
+	_, err = NewArchiver(&databaseStub{version: 1}, target).Restore(t.Context(), filename)
+	if !errors.Is(err, ErrSchemaMismatch) {
+		t.Errorf("expected error %q but got %q", ErrSchemaMismatch, err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	db := &databaseStub{
+		tables: []string{"posts"},
+		rows:   map[string][]string{"posts": {`{"id":"1"}`}},
+	}
+
+	filename := filepath.Join(t.TempDir(), "backup.tar.gz")
+	if _, err := NewArchiver(db, storage.NewLocal(t.TempDir(), "")).Backup(t.Context(), filename); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	// Rewrite the archive with the changed row and the original manifest.
+	tampered := filepath.Join(t.TempDir(), "tampered.tar.gz")
+	out, err := os.Create(tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	err = walkArchive(filename, func(name string, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		if name == "data/posts.jsonl" {
+			data = []byte(`{"id":"2"}` + "\n")
+		}
+
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data))}); err != nil {
+			return err
+		}
+
+		_, err = tw.Write(data)
+
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := errors.Join(tw.Close(), gz.Close(), out.Close()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Verify(filename); err != nil {
+		t.Errorf("unexpected error: %q", err)
+	}
+
+	if _, err := Verify(tampered); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected error %q but got %q", ErrChecksumMismatch, err)
+	}
+}
@@ -0,0 +1,157 @@
+package cachedrepository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/kostromin59/poster/internal/logging"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache returns nil data without an error when the key is missing, as cache.Redis does.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	SetWithExpiration(ctx context.Context, key string, data []byte, exp time.Duration) error
+}
+
+const keyPrefix = "poster:cache:"
+
+const (
+	namespacePosts   = "posts"
+	namespaceTags    = "tags"
+	namespaceSources = "sources"
+)
+
+// namespace is a group of cached queries which are invalidated together.
+// Keys of the namespace contain its version stored in the cache, so the invalidation
+// is a write of a new version and it is seen by every process sharing the cache.
+// Entries of old versions are left to expire.
+type namespace struct {
+	name  string
+	cache Cache
+	ttl   time.Duration
+	group singleflight.Group
+}
+
+func newNamespace(name string, cache Cache, ttl time.Duration) *namespace {
+	return &namespace{
+		name:  name,
+		cache: cache,
+		ttl:   ttl,
+	}
+}
+
+func (n *namespace) versionKey() string {
+	return keyPrefix + n.name + ":version"
+}
+
+func (n *namespace) version(ctx context.Context) (string, error) {
+	data, err := n.cache.Get(ctx, n.versionKey())
+	if err != nil {
+		return "", err
+	}
+
+	if data == nil {
+		return "0", nil
+	}
+
+	return string(data), nil
+}
+
+func (n *namespace) invalidate(ctx context.Context) error {
+	const op = "cachedrepository.namespace.invalidate"
+
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := n.cache.SetWithExpiration(ctx, n.versionKey(), []byte(version), 0); err != nil {
+		return fmt.Errorf("%s: %s: %w", op, n.name, err)
+	}
+
+	return nil
+}
+
+// Invalidate invalidates every namespace. It is used after writes which bypass the repositories
+// of this package, e.g. imports and restore of the backup.
+func Invalidate(ctx context.Context, cache Cache) error {
+	const op = "cachedrepository.Invalidate"
+
+	for _, name := range []string{namespacePosts, namespaceTags, namespaceSources} {
+		if err := newNamespace(name, cache, 0).invalidate(ctx); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// load returns the cached result of the query or loads and caches it. Concurrent loads
+// of the same key are collapsed into one. An empty result is cached too and returned as notFound,
+// the same way repositories report it. Cache errors are logged and the repository is queried directly.
+func load[E any](ctx context.Context, n *namespace, query string, notFound error, fetch func(ctx context.Context) ([]E, error)) ([]E, error) {
+	const op = "cachedrepository.load"
+
+	log := logging.FromContext(ctx).With(slog.String("op", op), slog.String("namespace", n.name))
+
+	version, err := n.version(ctx)
+	if err != nil {
+		log.Warn("unable to get cache version", slog.String("err", err.Error()))
+		return fetch(ctx)
+	}
+
+	key := keyPrefix + n.name + ":" + version + ":" + query
+
+	data, err := n.cache.Get(ctx, key)
+	if err != nil {
+		log.Warn("unable to get cached value", slog.String("key", key), slog.String("err", err.Error()))
+	}
+
+	if data != nil {
+		var items []E
+		err := json.Unmarshal(data, &items)
+		if err == nil {
+			if len(items) == 0 {
+				return nil, notFound
+			}
+
+			return items, nil
+		}
+
+		log.Warn("unable to decode cached value", slog.String("key", key), slog.String("err", err.Error()))
+	}
+
+	// The load is shared by callers, so it must not be cancelled together with the first of them.
+	v, err, _ := n.group.Do(key, func() (any, error) {
+		loadCtx := context.WithoutCancel(ctx)
+
+		items, err := fetch(loadCtx)
+		if err != nil && !errors.Is(err, notFound) {
+			return nil, err
+		}
+
+		data, err := json.Marshal(items)
+		if err != nil {
+			log.Warn("unable to encode value", slog.String("key", key), slog.String("err", err.Error()))
+			return items, nil
+		}
+
+		if err := n.cache.SetWithExpiration(loadCtx, key, data, n.ttl); err != nil {
+			log.Warn("unable to cache value", slog.String("key", key), slog.String("err", err.Error()))
+		}
+
+		return items, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := v.([]E)
+	if len(items) == 0 {
+		return nil, notFound
+	}
+
+	return items, nil
+}
@@ -0,0 +1,46 @@
+package cachedrepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type CategoryRepository interface {
+	FindAll(ctx context.Context) ([]models.Category, error)
+	Create(ctx context.Context, dto models.CreateCategoryDTO) (models.Category, error)
+}
+
+// Category invalidates posts after changes of categories, because posts are filtered by them:
+// an empty result for the slug of a new category may be cached.
+// Categories are read only by the bot, so they are not cached.
+type Category struct {
+	repo  CategoryRepository
+	posts *namespace
+}
+
+func NewCategory(repo CategoryRepository, cache Cache, ttl time.Duration) *Category {
+	return &Category{
+		repo:  repo,
+		posts: newNamespace(namespacePosts, cache, ttl),
+	}
+}
+
+func (c *Category) FindAll(ctx context.Context) ([]models.Category, error) {
+	return c.repo.FindAll(ctx)
+}
+
+func (c *Category) Create(ctx context.Context, dto models.CreateCategoryDTO) (models.Category, error) {
+	const op = "cachedrepository.Category.Create"
+
+	category, err := c.repo.Create(ctx, dto)
+	if err != nil {
+		return models.Category{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	invalidate(ctx, op, c.posts)
+
+	return category, nil
+}
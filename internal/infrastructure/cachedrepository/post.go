@@ -0,0 +1,162 @@
+package cachedrepository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type PostRepository interface {
+	Create(ctx context.Context, dto models.CreatePostDTO) (models.Post, error)
+	FindPublished(ctx context.Context, filters models.PostSearchFilters, offset, limit uint64) ([]models.Post, error)
+	Find(ctx context.Context, filters models.PostSearchFilters, offset, limit uint64) ([]models.Post, error)
+	FindByID(ctx context.Context, id models.PostID) (models.Post, error)
+	Update(ctx context.Context, dto models.UpdatePostDTO) (models.Post, error)
+	Delete(ctx context.Context, id models.PostID) error
+}
+
+// Post caches published posts. Find and FindByID are used by the admin commands and are not cached.
+type Post struct {
+	repo  PostRepository
+	posts *namespace
+	tags  *namespace
+}
+
+func NewPost(repo PostRepository, cache Cache, ttl time.Duration) *Post {
+	return &Post{
+		repo:  repo,
+		posts: newNamespace(namespacePosts, cache, ttl),
+		tags:  newNamespace(namespaceTags, cache, ttl),
+	}
+}
+
+// Create creates the post and invalidates posts and tags, the post may add new tags.
+func (p *Post) Create(ctx context.Context, dto models.CreatePostDTO) (models.Post, error) {
+	const op = "cachedrepository.Post.Create"
+
+	post, err := p.repo.Create(ctx, dto)
+	if err != nil {
+		return models.Post{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	invalidate(ctx, op, p.posts, p.tags)
+
+	return post, nil
+}
+
+func (p *Post) FindPublished(ctx context.Context, filters models.PostSearchFilters, offset, limit uint64) ([]models.Post, error) {
+	const op = "cachedrepository.Post.FindPublished"
+
+	query := fmt.Sprintf("published:%s:%d:%d", filtersKey(filters), offset, limit)
+
+	posts, err := load(ctx, p.posts, query, models.ErrPostNotFound, func(ctx context.Context) ([]models.Post, error) {
+		return p.repo.FindPublished(ctx, filters, offset, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return posts, nil
+}
+
+func (p *Post) Find(ctx context.Context, filters models.PostSearchFilters, offset, limit uint64) ([]models.Post, error) {
+	return p.repo.Find(ctx, filters, offset, limit)
+}
+
+func (p *Post) FindByID(ctx context.Context, id models.PostID) (models.Post, error) {
+	return p.repo.FindByID(ctx, id)
+}
+
+// Update updates the post and invalidates posts and tags, the post may add new tags.
+func (p *Post) Update(ctx context.Context, dto models.UpdatePostDTO) (models.Post, error) {
+	const op = "cachedrepository.Post.Update"
+
+	post, err := p.repo.Update(ctx, dto)
+	if err != nil {
+		return models.Post{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	invalidate(ctx, op, p.posts, p.tags)
+
+	return post, nil
+}
+
+func (p *Post) Delete(ctx context.Context, id models.PostID) error {
+	const op = "cachedrepository.Post.Delete"
+
+	if err := p.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	invalidate(ctx, op, p.posts)
+
+	return nil
+}
+
+// Invalidate drops cached posts, e.g. when a scheduled post becomes published.
+func (p *Post) Invalidate(ctx context.Context) error {
+	const op = "cachedrepository.Post.Invalidate"
+
+	if err := p.posts.invalidate(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// invalidate invalidates namespaces after a successful write. The write is not failed
+// because of the cache, stale entries live no longer than the ttl.
+func invalidate(ctx context.Context, op string, namespaces ...*namespace) {
+	for _, n := range namespaces {
+		if err := n.invalidate(ctx); err != nil {
+			logging.FromContext(ctx).Warn("unable to invalidate cache", slog.String("op", op), slog.String("err", err.Error()))
+		}
+	}
+}
+
+// filtersKey returns a hash of the filters. Tags and sources are sorted and deduplicated,
+// so the same filters in a different order share the cache entry.
+func filtersKey(filters models.PostSearchFilters) string {
+	normalized := struct {
+		Title         *string    `json:"title,omitempty"`
+		Tags          []string   `json:"tags,omitempty"`
+		Sources       []string   `json:"sources,omitempty"`
+		PublishedFrom *time.Time `json:"published_from,omitempty"`
+		Category      *string    `json:"category,omitempty"`
+	}{
+		Title:    filters.Title,
+		Tags:     normalizeList(filters.Tags),
+		Sources:  normalizeList(filters.Sources),
+		Category: filters.Category,
+	}
+
+	if filters.PublishedFrom != nil {
+		from := filters.PublishedFrom.UTC()
+		normalized.PublishedFrom = &from
+	}
+
+	// Marshalling of strings and times doesn't fail.
+	data, _ := json.Marshal(normalized)
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeList(list []string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+
+	normalized := slices.Clone(list)
+	slices.Sort(normalized)
+
+	return slices.Compact(normalized)
+}
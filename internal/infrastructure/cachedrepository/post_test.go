@@ -0,0 +1,257 @@
+package cachedrepository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type memoryCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	err  error
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{data: make(map[string][]byte)}
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return c.data[key], nil
+}
+
+func (c *memoryCache) SetWithExpiration(_ context.Context, key string, data []byte, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+
+	c.data[key] = data
+	return nil
+}
+
+type postRepoStub struct {
+	calls   atomic.Int32
+	posts   []models.Post
+	release chan struct{}
+}
+
+func (r *postRepoStub) Create(_ context.Context, dto models.CreatePostDTO) (models.Post, error) {
+	return models.Post{ID: "new", Title: dto.Title}, nil
+}
+
+func (r *postRepoStub) FindPublished(context.Context, models.PostSearchFilters, uint64, uint64) ([]models.Post, error) {
+	r.calls.Add(1)
+
+	if r.release != nil {
+		<-r.release
+	}
+
+	if len(r.posts) == 0 {
+		return nil, models.ErrPostNotFound
+	}
+
+	return r.posts, nil
+}
+
+func (r *postRepoStub) Find(context.Context, models.PostSearchFilters, uint64, uint64) ([]models.Post, error) {
+	return r.posts, nil
+}
+
+func (r *postRepoStub) FindByID(context.Context, models.PostID) (models.Post, error) {
+	return r.posts[0], nil
+}
+
+func (r *postRepoStub) Update(_ context.Context, dto models.UpdatePostDTO) (models.Post, error) {
+	return models.Post{ID: dto.ID, Title: dto.Title}, nil
+}
+
+func (r *postRepoStub) Delete(context.Context, models.PostID) error {
+	return nil
+}
+
+func TestPostFindPublished(t *testing.T) {
+	t.Run("cached with normalized filters", func(t *testing.T) {
+		repo := &postRepoStub{posts: []models.Post{{ID: "1", Title: "Пост", Tags: []models.Tag{"go"}}}}
+		p := NewPost(repo, newMemoryCache(), time.Minute)
+
+		first, err := p.FindPublished(t.Context(), models.PostSearchFilters{Tags: []string{"go", "kafka"}}, 0, 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		second, err := p.FindPublished(t.Context(), models.PostSearchFilters{Tags: []string{"kafka", "go", "go"}}, 0, 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if repo.calls.Load() != 1 {
+			t.Errorf("expected 1 repository call, got %d", repo.calls.Load())
+		}
+
+		if len(second) != 1 || second[0].Title != first[0].Title || second[0].Tags[0] != "go" {
+			t.Errorf("unexpected cached posts: %+v", second)
+		}
+
+		if _, err := p.FindPublished(t.Context(), models.PostSearchFilters{Tags: []string{"go"}}, 0, 10); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if repo.calls.Load() != 2 {
+			t.Errorf("expected other filters to be loaded, got %d calls", repo.calls.Load())
+		}
+	})
+
+	t.Run("not found is cached", func(t *testing.T) {
+		repo := &postRepoStub{}
+		p := NewPost(repo, newMemoryCache(), time.Minute)
+
+		for range 2 {
+			if _, err := p.FindPublished(t.Context(), models.PostSearchFilters{}, 0, 10); !errors.Is(err, models.ErrPostNotFound) {
+				t.Fatalf("expected ErrPostNotFound, got %v", err)
+			}
+		}
+
+		if repo.calls.Load() != 1 {
+			t.Errorf("expected 1 repository call, got %d", repo.calls.Load())
+		}
+	})
+
+	t.Run("invalidated by writes", func(t *testing.T) {
+		repo := &postRepoStub{posts: []models.Post{{ID: "1"}}}
+		cache := newMemoryCache()
+		p := NewPost(repo, cache, time.Minute)
+		tags := NewTag(&tagRepoStub{}, cache, time.Minute)
+		categories := NewCategory(categoryRepoStub{}, cache, time.Minute)
+
+		find := func() {
+			if _, err := p.FindPublished(t.Context(), models.PostSearchFilters{}, 0, 10); err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+		}
+
+		find()
+
+		if err := p.Delete(t.Context(), "1"); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+		find()
+
+		if _, err := p.Create(t.Context(), models.CreatePostDTO{Title: "Новый"}); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+		find()
+
+		if _, err := p.Update(t.Context(), models.UpdatePostDTO{ID: "1", Title: "Изменённый"}); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+		find()
+
+		if err := tags.Rename(t.Context(), "go", "golang"); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+		find()
+
+		if _, err := categories.Create(t.Context(), models.CreateCategoryDTO{Slug: "news", Title: "Новости"}); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+		find()
+
+		if err := p.Invalidate(t.Context()); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+		find()
+
+		if err := Invalidate(t.Context(), cache); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+		find()
+
+		if repo.calls.Load() != 8 {
+			t.Errorf("expected 8 repository calls, got %d", repo.calls.Load())
+		}
+	})
+
+	t.Run("concurrent loads are collapsed", func(t *testing.T) {
+		repo := &postRepoStub{posts: []models.Post{{ID: "1"}}, release: make(chan struct{})}
+		p := NewPost(repo, newMemoryCache(), time.Minute)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Go(func() {
+				if _, err := p.FindPublished(t.Context(), models.PostSearchFilters{}, 0, 10); err != nil {
+					t.Errorf("unexpected error: %q", err)
+				}
+			})
+		}
+
+		// Let the goroutines reach the repository before the first load finishes.
+		time.Sleep(50 * time.Millisecond)
+		close(repo.release)
+		wg.Wait()
+
+		if repo.calls.Load() != 1 {
+			t.Errorf("expected 1 repository call, got %d", repo.calls.Load())
+		}
+	})
+
+	t.Run("cache error falls back to repository", func(t *testing.T) {
+		repo := &postRepoStub{posts: []models.Post{{ID: "1"}}}
+		cache := newMemoryCache()
+		cache.err = errors.New("cache is down")
+		p := NewPost(repo, cache, time.Minute)
+
+		for range 2 {
+			posts, err := p.FindPublished(t.Context(), models.PostSearchFilters{}, 0, 10)
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+
+			if len(posts) != 1 {
+				t.Errorf("expected 1 post, got %d", len(posts))
+			}
+		}
+
+		if repo.calls.Load() != 2 {
+			t.Errorf("expected 2 repository calls, got %d", repo.calls.Load())
+		}
+
+		if err := p.Delete(t.Context(), "1"); err != nil {
+			t.Errorf("expected the write to succeed, got %q", err)
+		}
+	})
+}
+
+type tagRepoStub struct{}
+
+func (tagRepoStub) FindAll(context.Context) ([]models.Tag, error) { return []models.Tag{"go"}, nil }
+
+func (tagRepoStub) FindAllWithCount(context.Context) ([]models.TagUsage, error) { return nil, nil }
+
+func (tagRepoStub) Rename(context.Context, models.Tag, models.Tag) error { return nil }
+
+func (tagRepoStub) Merge(context.Context, models.Tag, models.Tag) error { return nil }
+
+func (tagRepoStub) Delete(context.Context, models.Tag) error { return nil }
+
+type categoryRepoStub struct{}
+
+func (categoryRepoStub) FindAll(context.Context) ([]models.Category, error) { return nil, nil }
+
+func (categoryRepoStub) Create(_ context.Context, dto models.CreateCategoryDTO) (models.Category, error) {
+	return models.Category{Slug: dto.Slug, Title: dto.Title}, nil
+}
@@ -0,0 +1,43 @@
+package cachedrepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type SourceRepository interface {
+	FindAll(ctx context.Context) ([]models.Source, error)
+	FindAllConfigs(ctx context.Context) ([]models.SourceConfig, error)
+}
+
+// Source caches the list of sources. Configs contain credentials of publishers,
+// so they are not put into the cache.
+type Source struct {
+	repo    SourceRepository
+	sources *namespace
+}
+
+func NewSource(repo SourceRepository, cache Cache, ttl time.Duration) *Source {
+	return &Source{
+		repo:    repo,
+		sources: newNamespace(namespaceSources, cache, ttl),
+	}
+}
+
+func (s *Source) FindAll(ctx context.Context) ([]models.Source, error) {
+	const op = "cachedrepository.Source.FindAll"
+
+	sources, err := load(ctx, s.sources, "all", models.ErrSourceNotFound, s.repo.FindAll)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sources, nil
+}
+
+func (s *Source) FindAllConfigs(ctx context.Context) ([]models.SourceConfig, error) {
+	return s.repo.FindAllConfigs(ctx)
+}
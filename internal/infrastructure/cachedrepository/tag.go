@@ -0,0 +1,84 @@
+package cachedrepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type TagRepository interface {
+	FindAll(ctx context.Context) ([]models.Tag, error)
+	FindAllWithCount(ctx context.Context) ([]models.TagUsage, error)
+	Rename(ctx context.Context, tag, newTag models.Tag) error
+	Merge(ctx context.Context, tag, target models.Tag) error
+	Delete(ctx context.Context, tag models.Tag) error
+}
+
+// Tag caches the list of tags. Counts are shown in the bot after changes, so they are not cached.
+// Changes of tags invalidate posts too, because posts contain their tags.
+type Tag struct {
+	repo  TagRepository
+	tags  *namespace
+	posts *namespace
+}
+
+func NewTag(repo TagRepository, cache Cache, ttl time.Duration) *Tag {
+	return &Tag{
+		repo:  repo,
+		tags:  newNamespace(namespaceTags, cache, ttl),
+		posts: newNamespace(namespacePosts, cache, ttl),
+	}
+}
+
+func (t *Tag) FindAll(ctx context.Context) ([]models.Tag, error) {
+	const op = "cachedrepository.Tag.FindAll"
+
+	tags, err := load(ctx, t.tags, "all", models.ErrTagNotFound, t.repo.FindAll)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tags, nil
+}
+
+func (t *Tag) FindAllWithCount(ctx context.Context) ([]models.TagUsage, error) {
+	return t.repo.FindAllWithCount(ctx)
+}
+
+func (t *Tag) Rename(ctx context.Context, tag, newTag models.Tag) error {
+	const op = "cachedrepository.Tag.Rename"
+
+	if err := t.repo.Rename(ctx, tag, newTag); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	invalidate(ctx, op, t.tags, t.posts)
+
+	return nil
+}
+
+func (t *Tag) Merge(ctx context.Context, tag, target models.Tag) error {
+	const op = "cachedrepository.Tag.Merge"
+
+	if err := t.repo.Merge(ctx, tag, target); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	invalidate(ctx, op, t.tags, t.posts)
+
+	return nil
+}
+
+func (t *Tag) Delete(ctx context.Context, tag models.Tag) error {
+	const op = "cachedrepository.Tag.Delete"
+
+	if err := t.repo.Delete(ctx, tag); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	invalidate(ctx, op, t.tags, t.posts)
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package cronjob
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/metrics"
+	"github.com/kostromin59/poster/pkg/lock"
+)
+
+type Locker interface {
+	Acquire(ctx context.Context, key string, ttl time.Duration) (*lock.Lease, error)
+}
+
+// lockTTL is a lease time of the job lock. The lease is renewed while the job runs and is not released
+// after it, so instances which tick a bit later skip the same tick. It must be shorter than job intervals.
+const lockTTL = time.Minute
+
+// withLock runs the job only in the instance which has acquired the job lock. If the lock can't be
+// acquired because of an error, the job is run anyway: publishers skip delivered posts,
+// while a skipped tick is never repeated.
+func withLock(ctx context.Context, locker Locker, job string, run func(ctx context.Context)) {
+	const op = "cronjob.withLock"
+
+	log := logging.FromContext(ctx).With(slog.String("op", op), slog.String("job", job))
+
+	lease, err := locker.Acquire(ctx, "cron:"+job, lockTTL)
+	if err != nil {
+		if errors.Is(err, lock.ErrNotAcquired) {
+			log.Debug("job is run by another instance")
+			metrics.CronSkipped.WithLabelValues(job).Inc()
+			return
+		}
+
+		log.Warn("unable to acquire job lock, running without it", slog.String("err", err.Error()))
+		run(ctx)
+		return
+	}
+
+	ctx, stop := lease.Hold(ctx)
+	defer stop()
+
+	run(logging.With(ctx, slog.Int64("lock_token", lease.Token())))
+}
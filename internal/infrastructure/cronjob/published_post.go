@@ -2,89 +2,183 @@ package cronjob
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/metrics"
 	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/internal/tracing"
 	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type PublishedPostRepository interface {
 	FindPublished(ctx context.Context, filters models.PostSearchFilters, offset, limit uint64) ([]models.Post, error)
 }
 
-func PublishedPost(ctx context.Context, cron *cron.Cron, d events.AsyncDispatcher, postRepo PublishedPostRepository) error {
+// publishedPostJob labels metrics of the PublishedPost job.
+const publishedPostJob = "published_post"
+
+// PublishedPost dispatches posts published since the previous tick every 30 minutes.
+// Only the instance which has acquired the job lock runs a tick.
+func PublishedPost(ctx context.Context, cron *cron.Cron, locker Locker, d events.AsyncDispatcher, postRepo PublishedPostRepository) error {
 	const op = "crojob.PublishedPost.PublishedPost"
-	log := slog.With(slog.String("op", op))
+
+	if _, err := cron.AddFunc("*/30 * * * *", func() {
+		withLock(ctx, locker, publishedPostJob, func(ctx context.Context) {
+			publishedPost(ctx, d, postRepo)
+		})
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func publishedPost(ctx context.Context, d events.AsyncDispatcher, postRepo PublishedPostRepository) {
+	const op = "crojob.PublishedPost.PublishedPost"
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	start := time.Now()
+	defer func() {
+		metrics.CronDuration.WithLabelValues(publishedPostJob).Observe(time.Since(start).Seconds())
+	}()
+
+	ctx, span := tracing.Tracer().Start(ctx, op)
+	defer span.End()
+
+	publishedFrom := start.Add(-30 * time.Minute)
+
+	dispatched, err := DispatchPublishedPosts(ctx, d, postRepo, models.PostSearchFilters{
+		PublishedFrom: &publishedFrom,
+	})
+	metrics.CronPostsFound.WithLabelValues(publishedPostJob).Add(float64(dispatched))
+
+	span.SetAttributes(attribute.Int("posts.found", dispatched))
+
+	if err != nil {
+		log.Error("unable to dispatch published posts", slog.String("err", err.Error()))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// DispatchPublishedPosts dispatches the published post event for every published post matching the filters
+// and returns the number of dispatched events. Publishers skip posts they have already delivered,
+// so the events can be dispatched again.
+func DispatchPublishedPosts(ctx context.Context, d events.AsyncDispatcher, postRepo PublishedPostRepository, filters models.PostSearchFilters) (int, error) {
+	const op = "cronjob.DispatchPublishedPosts"
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	ctx, span := tracing.Tracer().Start(ctx, op)
+	defer span.End()
 
 	const limit = 10
 
-	if _, err := cron.AddFunc("*/30 * * * *", func() {
-		publishedFrom := time.Now().Add(-30 * time.Minute)
-		var offset uint64
+	var (
+		offset     uint64
+		dispatched int
+	)
 
-		for {
-			posts, err := postRepo.FindPublished(ctx, models.PostSearchFilters{
-				PublishedFrom: &publishedFrom,
-			}, offset, limit)
-			if err != nil {
-				log.Error("unable to find published", slog.String("err", err.Error()))
-				return
+	for {
+		posts, err := postRepo.FindPublished(ctx, filters, offset, limit)
+		if err != nil {
+			if errors.Is(err, models.ErrPostNotFound) {
+				return dispatched, nil
 			}
 
-			for _, p := range posts {
-				eventID, err := uuid.NewRandom()
-				if err != nil {
-					log.Error("unable to generate event id", slog.String("err", err.Error()))
-					continue
-				}
-
-				tags := make([]string, len(p.Tags))
-				for i, t := range p.Tags {
-					tags[i] = string(t)
-				}
-
-				sources := make([]string, len(p.Sources))
-				for i, s := range p.Sources {
-					sources[i] = string(s)
-				}
-
-				media := make([]events.PublishedPostMedia, len(p.Media))
-				for i, m := range p.Media {
-					media[i] = events.PublishedPostMedia{
-						ID:       string(m.ID),
-						Filetype: m.Filetype,
-						URI:      m.URI,
-					}
-				}
-
-				d.Dispatch(events.PublishedPost{
-					EventID:   eventID.String(),
-					CreatedAt: time.Now(),
-					Data: events.PublishedPostData{
-						ID:          string(p.ID),
-						Title:       p.Title,
-						Content:     p.Content,
-						PublishDate: p.PublishDate,
-						Tags:        tags,
-						Media:       media,
-						Sources:     sources,
-					},
-				})
-			}
+			return dispatched, fmt.Errorf("%s: %w", op, err)
+		}
 
-			if len(posts) < limit {
-				break
+		for _, p := range posts {
+			eventID, err := uuid.NewRandom()
+			if err != nil {
+				log.Error("unable to generate event id", slog.String("err", err.Error()))
+				continue
 			}
 
-			offset += limit
+			dispatchCtx := logging.With(ctx,
+				slog.String(logging.EventIDKey, eventID.String()),
+				slog.String(logging.PostIDKey, string(p.ID)),
+			)
+			dispatchCtx, postSpan := tracing.Tracer().Start(dispatchCtx, "dispatch published post", trace.WithAttributes(
+				attribute.String("post.id", string(p.ID)),
+				attribute.String("event.id", eventID.String()),
+			))
+			d.Dispatch(dispatchCtx, publishedPostEvent(eventID.String(), p))
+			postSpan.End()
+
+			dispatched++
 		}
-	}); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+
+		if len(posts) < limit {
+			return dispatched, nil
+		}
+
+		offset += limit
 	}
+}
 
-	return nil
+func publishedPostEvent(eventID string, p models.Post) events.PublishedPost {
+	tags := make([]string, len(p.Tags))
+	for i, t := range p.Tags {
+		tags[i] = string(t)
+	}
+
+	sources := make([]string, len(p.Sources))
+	for i, s := range p.Sources {
+		sources[i] = string(s)
+	}
+
+	media := make([]events.PublishedPostMedia, len(p.Media))
+	for i, m := range p.Media {
+		media[i] = events.PublishedPostMedia{
+			ID:       string(m.ID),
+			Filetype: m.Filetype,
+			URI:      m.URI,
+		}
+	}
+
+	telegramChannels := make([]string, len(p.TelegramChannels))
+	for i, tc := range p.TelegramChannels {
+		telegramChannels[i] = string(tc)
+	}
+
+	var category *events.PublishedPostCategory
+	if p.Category != nil {
+		category = &events.PublishedPostCategory{
+			ID:    string(p.Category.ID),
+			Slug:  p.Category.Slug,
+			Title: p.Category.Title,
+		}
+
+		if p.Category.ParentID != nil {
+			parentID := string(*p.Category.ParentID)
+			category.ParentID = &parentID
+		}
+	}
+
+	return events.PublishedPost{
+		EventID:   eventID,
+		CreatedAt: time.Now(),
+		Data: events.PublishedPostData{
+			ID:               string(p.ID),
+			Title:            p.Title,
+			Content:          p.Content,
+			PublishDate:      p.PublishDate,
+			Tags:             tags,
+			Media:            media,
+			Sources:          sources,
+			Category:         category,
+			TelegramChannels: telegramChannels,
+		},
+	}
 }
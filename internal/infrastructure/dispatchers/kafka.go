@@ -1,10 +1,19 @@
 package dispatchers
 
 import (
+	"context"
 	"encoding/json"
 	"log/slog"
 
 	"github.com/IBM/sarama"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/metrics"
+	"github.com/kostromin59/poster/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type AsyncKafka struct {
@@ -19,18 +28,54 @@ func NewAsyncKakfa(asyncProducer sarama.AsyncProducer, topic string) *AsyncKafka
 	}
 }
 
-func (ak *AsyncKafka) Dispatch(e any) {
+// Dispatch sends the event to the topic. The trace context is passed in headers of the message.
+func (ak *AsyncKafka) Dispatch(ctx context.Context, e any) {
 	const op = "dispatchers.AsyncKafka.Dispatch"
-	log := slog.With(slog.String("op", op))
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	ctx, span := tracing.Tracer().Start(ctx, ak.topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(semconv.MessagingSystemKafka, semconv.MessagingDestinationName(ak.topic)),
+	)
+	defer span.End()
 
 	b, err := json.Marshal(e)
 	if err != nil {
 		log.Error("unable to marshal event", slog.String("err", err.Error()))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		metrics.EventsFailed.WithLabelValues(ak.topic).Inc()
 		return
 	}
 
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	headers := make([]sarama.RecordHeader, 0, len(carrier))
+	for k, v := range carrier {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+
 	ak.asyncProducer.Input() <- &sarama.ProducerMessage{
-		Topic: ak.topic,
-		Value: sarama.ByteEncoder(b),
+		Topic:   ak.topic,
+		Value:   sarama.ByteEncoder(b),
+		Headers: headers,
 	}
+	metrics.EventsDispatched.WithLabelValues(ak.topic).Inc()
+}
+
+// HandleErrors logs and counts messages which have not been produced until the producer is closed
+// and returns their number.
+func (ak *AsyncKafka) HandleErrors() int {
+	const op = "dispatchers.AsyncKafka.HandleErrors"
+	log := slog.With(slog.String("op", op))
+
+	failed := 0
+	for err := range ak.asyncProducer.Errors() {
+		log.Error("unable to produce event", slog.String("topic", err.Msg.Topic), slog.String("err", err.Err.Error()))
+		metrics.EventsFailed.WithLabelValues(err.Msg.Topic).Inc()
+		failed++
+	}
+
+	return failed
 }
@@ -0,0 +1,110 @@
+package dispatchers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama/mocks"
+	"github.com/kostromin59/poster/internal/events"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type handlerFunc func(ctx context.Context, e []byte) error
+
+func (f handlerFunc) Handle(ctx context.Context, e []byte) error {
+	return f(ctx, e)
+}
+
+func TestAsyncKafkaPropagatesTraceContext(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+
+	cfg := mocks.NewTestConfig()
+	cfg.Producer.Return.Successes = true
+
+	producer := mocks.NewAsyncProducer(t, cfg)
+	producer.ExpectInputAndSucceed()
+
+	ctx, parent := provider.Tracer("test").Start(t.Context(), "parent")
+	NewAsyncKakfa(producer, "posts").Dispatch(ctx, map[string]string{"id": "post"})
+	parent.End()
+
+	msg := <-producer.Successes()
+	if err := producer.Close(); err != nil {
+		t.Fatalf("unable to close producer: %q", err)
+	}
+
+	metadata := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		metadata[string(h.Key)] = string(h.Value)
+	}
+
+	if metadata["traceparent"] == "" {
+		t.Fatalf("expected traceparent header but got %v", msg.Headers)
+	}
+
+	payload, err := msg.Value.Encode()
+	if err != nil {
+		t.Fatalf("unable to encode message: %q", err)
+	}
+
+	ch := make(chan events.Message, 1)
+	handled := make(chan trace.SpanContext, 1)
+
+	listenerCtx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	events.NewListener("posts", ch, handlerFunc(func(ctx context.Context, _ []byte) error {
+		handled <- trace.SpanContextFromContext(ctx)
+		return nil
+	})).Start(listenerCtx)
+
+	ch <- events.Message{Payload: payload, Metadata: metadata}
+	handlerSpan := <-handled
+
+	if handlerSpan.TraceID() != parent.SpanContext().TraceID() {
+		t.Errorf("expected trace %s but got %s", parent.SpanContext().TraceID(), handlerSpan.TraceID())
+	}
+
+	// The process span ends after the handler returns.
+	var publish, process tracetest.SpanStub
+	for i := 0; i < 100 && process.Name == ""; i++ {
+		time.Sleep(10 * time.Millisecond)
+
+		for _, s := range exporter.GetSpans() {
+			switch s.Name {
+			case "posts publish":
+				publish = s
+			case "posts process":
+				process = s
+			}
+		}
+	}
+
+	if publish.SpanKind != trace.SpanKindProducer {
+		t.Fatalf("expected producer span but got %+v", publish)
+	}
+
+	if publish.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("expected publish span to be a child of the parent span")
+	}
+
+	if process.SpanKind != trace.SpanKindConsumer {
+		t.Fatalf("expected consumer span but got %+v", process)
+	}
+
+	if process.Parent.SpanID() != publish.SpanContext.SpanID() {
+		t.Errorf("expected process span to be a child of the publish span")
+	}
+}
@@ -2,63 +2,180 @@ package listeners
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 
 	"github.com/IBM/sarama"
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/logging"
 )
 
+var ErrConsumerStopped = errors.New("consumer group has been stopped")
+
+type KafkaOffsetClient interface {
+	GetOffset(topic string, partition int32, time int64) (int64, error)
+}
+
+// Kafka consumes the topic in the consumer group. Offsets of processed messages are committed,
+// so the restarted worker continues from them instead of reading the topic from the beginning.
 type Kafka struct {
-	consumer sarama.Consumer
-	topic    string
+	group   sarama.ConsumerGroup
+	offsets KafkaOffsetClient
+	topic   string
+
+	ch  chan events.Message
+	err atomic.Pointer[error]
+
+	mu         sync.RWMutex
+	partitions map[int32]*partitionState
+}
+
+type partitionState struct {
+	claim sarama.ConsumerGroupClaim
+	// next is an offset of the next message, -1 until the starting offset is known.
+	next atomic.Int64
 }
 
-func NewKafka(consumer sarama.Consumer, topic string) *Kafka {
+func NewKafka(group sarama.ConsumerGroup, offsets KafkaOffsetClient, topic string) *Kafka {
 	return &Kafka{
-		consumer: consumer,
-		topic:    topic,
+		group:      group,
+		offsets:    offsets,
+		topic:      topic,
+		partitions: make(map[int32]*partitionState),
 	}
 }
 
-func (k *Kafka) Start(ctx context.Context) (<-chan []byte, error) {
+func (k *Kafka) Start(ctx context.Context) (<-chan events.Message, error) {
 	const op = "listeners.KafkaListener.Start"
 
-	log := slog.With(slog.String("op", op))
+	log := logging.FromContext(ctx).With(slog.String("op", op))
 
-	partitions, err := k.consumer.Partitions(k.topic)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
+	k.ch = make(chan events.Message)
+
+	go func() {
+		for err := range k.group.Errors() {
+			log.Error("consumer error", slog.String("err", err.Error()))
+		}
+	}()
+
+	go func() {
+		defer close(k.ch)
+
+		// Consume returns after every rebalance, the session is joined again until the context is done.
+		for ctx.Err() == nil {
+			if err := k.group.Consume(ctx, []string{k.topic}, k); err != nil {
+				if !errors.Is(err, sarama.ErrClosedConsumerGroup) {
+					log.Error("consumer group stopped", slog.String("err", err.Error()))
+					err = fmt.Errorf("%w: %w", ErrConsumerStopped, err)
+					k.err.Store(&err)
+				}
+
+				return
+			}
+		}
+	}()
+
+	return k.ch, nil
+}
+
+// Setup forgets partitions of the previous session, they may have been assigned to another consumer.
+func (k *Kafka) Setup(sarama.ConsumerGroupSession) error {
+	k.mu.Lock()
+	k.partitions = make(map[int32]*partitionState)
+	k.mu.Unlock()
+
+	return nil
+}
 
-	ch := make(chan []byte, len(partitions))
+func (k *Kafka) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim sends messages of the partition to the listener. The message is marked
+// when the listener acknowledges it, the marked offsets are committed by the group.
+func (k *Kafka) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	const op = "listeners.KafkaListener.ConsumeClaim"
+
+	ctx := session.Context()
+	log := logging.FromContext(ctx).With(slog.String("op", op), slog.Int("partition", int(claim.Partition())))
+
+	state := &partitionState{claim: claim}
 
-	wg := new(sync.WaitGroup)
-	for _, partition := range partitions {
-		partitionConsumer, err := k.consumer.ConsumePartition(k.topic, partition, sarama.OffsetOldest)
+	// The initial offset of a partition without the committed offset is the sentinel
+	// of the config, the lag is counted from the offset it points to.
+	start := claim.InitialOffset()
+	if start < 0 {
+		var err error
+		start, err = k.offsets.GetOffset(claim.Topic(), claim.Partition(), start)
 		if err != nil {
-			return nil, fmt.Errorf("%s: %w", op, err)
+			log.Warn("unable to get starting offset, partition lag is not counted until the first message", slog.String("err", err.Error()))
+			start = -1
 		}
+	}
+	state.next.Store(start)
 
-		wg.Go(func() {
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case msg := <-partitionConsumer.Messages():
-					ch <- msg.Value
-				case err := <-partitionConsumer.Errors():
-					log.Error("consumer error", slog.String("err", err.Error()))
-					return
-				}
+	k.mu.Lock()
+	k.partitions[claim.Partition()] = state
+	k.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
 			}
-		})
+
+			metadata := make(map[string]string, len(msg.Headers))
+			for _, h := range msg.Headers {
+				metadata[string(h.Key)] = string(h.Value)
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case k.ch <- events.Message{
+				Payload:  msg.Value,
+				Metadata: metadata,
+				Ack:      func() { session.MarkMessage(msg, "") },
+			}:
+			}
+
+			state.next.Store(msg.Offset + 1)
+		}
 	}
+}
 
-	go func() {
-		wg.Wait()
-		close(ch)
-	}()
+// Lag returns the number of messages of the claimed partitions which have not been consumed yet.
+// Idle partitions are counted from their starting offset.
+func (k *Kafka) Lag() int64 {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	var lag int64
+	for _, p := range k.partitions {
+		next := p.next.Load()
+		if next == -1 {
+			continue
+		}
+
+		if l := p.claim.HighWaterMarkOffset() - next; l > 0 {
+			lag += l
+		}
+	}
+
+	return lag
+}
+
+// Err returns ErrConsumerStopped when the consumer group has been stopped by an error.
+func (k *Kafka) Err() error {
+	if err := k.err.Load(); err != nil {
+		return *err
+	}
 
-	return ch, nil
+	return nil
 }
@@ -0,0 +1,79 @@
+// Package markdown exports posts to Markdown files with YAML front matter, compatible with Hugo and Jekyll,
+// and imports them back.
+package markdown
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const frontMatterDelimiter = "---\n"
+
+var ErrNoFrontMatter = errors.New("front matter not found")
+
+type FrontMatter struct {
+	ID          string    `yaml:"id"`
+	Title       string    `yaml:"title"`
+	PublishDate time.Time `yaml:"publish_date"`
+	Tags        []string  `yaml:"tags,omitempty"`
+	Sources     []string  `yaml:"sources,omitempty"`
+	Media       []Media   `yaml:"media,omitempty"`
+}
+
+type Media struct {
+	URI  string `yaml:"uri"`
+	Type string `yaml:"type,omitempty"`
+	// File is a path of the downloaded file relative to the export directory.
+	File string `yaml:"file,omitempty"`
+}
+
+// Document is a Markdown file of the post.
+type Document struct {
+	FrontMatter FrontMatter
+	Body        string
+}
+
+func (d Document) Marshal() ([]byte, error) {
+	frontMatter, err := yaml.Marshal(d.FrontMatter)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteString(frontMatterDelimiter)
+	b.Write(frontMatter)
+	b.WriteString(frontMatterDelimiter)
+	b.WriteString("\n")
+	b.WriteString(d.Body)
+	b.WriteString("\n")
+
+	return b.Bytes(), nil
+}
+
+func UnmarshalDocument(data []byte) (Document, error) {
+	s := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(s, frontMatterDelimiter) {
+		return Document{}, ErrNoFrontMatter
+	}
+
+	s = s[len(frontMatterDelimiter):]
+
+	end := strings.Index(s, "\n"+frontMatterDelimiter)
+	if end == -1 {
+		return Document{}, ErrNoFrontMatter
+	}
+
+	var d Document
+	if err := yaml.Unmarshal([]byte(s[:end+1]), &d.FrontMatter); err != nil {
+		return Document{}, fmt.Errorf("front matter: %w", err)
+	}
+
+	d.Body = strings.TrimSpace(s[end+1+len(frontMatterDelimiter):])
+
+	return d, nil
+}
@@ -0,0 +1,170 @@
+package markdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/richtext"
+)
+
+// pageSize is a number of posts loaded at once.
+const pageSize = 100
+
+type PostRepository interface {
+	FindPublished(ctx context.Context, filters models.PostSearchFilters, offset, limit uint64) ([]models.Post, error)
+}
+
+type Exporter struct {
+	postRepo PostRepository
+	client   *http.Client
+	loc      *time.Location
+}
+
+func NewExporter(postRepo PostRepository, client *http.Client, loc *time.Location) *Exporter {
+	return &Exporter{
+		postRepo: postRepo,
+		client:   client,
+		loc:      loc,
+	}
+}
+
+// Export writes a Markdown file for every published post matching the filters into the directory.
+// Files are named as YYYY-MM-DD-<id>.md, media are downloaded into media/<id>/.
+// Media that can't be downloaded keep only their URI in the front matter.
+func (e *Exporter) Export(ctx context.Context, dir string, filters models.PostSearchFilters) (int, error) {
+	const op = "markdown.Exporter.Export"
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var exported int
+	for offset := uint64(0); ; offset += pageSize {
+		posts, err := e.postRepo.FindPublished(ctx, filters, offset, pageSize)
+		if err != nil {
+			if errors.Is(err, models.ErrPostNotFound) {
+				return exported, nil
+			}
+
+			return exported, fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, p := range posts {
+			d := e.document(ctx, dir, p, log)
+
+			data, err := d.Marshal()
+			if err != nil {
+				return exported, fmt.Errorf("%s: post %s: %w", op, p.ID, err)
+			}
+
+			filename := filepath.Join(dir, d.FrontMatter.PublishDate.Format(time.DateOnly)+"-"+string(p.ID)+".md")
+			if err := os.WriteFile(filename, data, 0o644); err != nil {
+				return exported, fmt.Errorf("%s: %w", op, err)
+			}
+
+			exported++
+		}
+
+		if len(posts) < pageSize {
+			return exported, nil
+		}
+	}
+}
+
+func (e *Exporter) document(ctx context.Context, dir string, p models.Post, log *slog.Logger) Document {
+	d := Document{
+		FrontMatter: FrontMatter{
+			ID:          string(p.ID),
+			Title:       p.Title,
+			PublishDate: p.PublishDate.In(e.loc),
+			Tags:        make([]string, len(p.Tags)),
+			Sources:     make([]string, len(p.Sources)),
+			Media:       make([]Media, len(p.Media)),
+		},
+		Body: richtext.ToMarkdown(p.Content),
+	}
+
+	// Hugo and Jekyll tags are plain words.
+	for i, t := range p.Tags {
+		d.FrontMatter.Tags[i] = strings.TrimPrefix(string(t), "#")
+	}
+
+	for i, s := range p.Sources {
+		d.FrontMatter.Sources[i] = string(s)
+	}
+
+	for i, m := range p.Media {
+		d.FrontMatter.Media[i] = Media{URI: m.URI, Type: m.Filetype}
+
+		file := path.Join("media", string(p.ID), fmt.Sprintf("%d-%s", i+1, mediaName(m.URI)))
+		if err := e.download(ctx, m.URI, filepath.Join(dir, filepath.FromSlash(file))); err != nil {
+			log.Warn("unable to download media", slog.String("post_id", string(p.ID)), slog.String("uri", m.URI), slog.String("err", err.Error()))
+			continue
+		}
+
+		d.FrontMatter.Media[i].File = file
+	}
+
+	return d
+}
+
+func (e *Exporter) download(ctx context.Context, uri, filename string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func mediaName(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "file"
+	}
+
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return "file"
+	}
+
+	return name
+}
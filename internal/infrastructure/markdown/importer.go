@@ -0,0 +1,128 @@
+package markdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/richtext"
+)
+
+type PostCreator interface {
+	Create(ctx context.Context, dto models.CreatePostDTO) (models.Post, error)
+}
+
+type MediaRepository interface {
+	Create(ctx context.Context, filetype, uri string) (models.Media, error)
+}
+
+// Stats is a result of the import.
+type Stats struct {
+	Imported int
+	Skipped  int
+}
+
+type Importer struct {
+	postRepo  PostCreator
+	mediaRepo MediaRepository
+}
+
+func NewImporter(postRepo PostCreator, mediaRepo MediaRepository) *Importer {
+	return &Importer{
+		postRepo:  postRepo,
+		mediaRepo: mediaRepo,
+	}
+}
+
+// Import creates posts from Markdown files of the directory. Posts keep ids from the front matter,
+// so posts which already exist are skipped.
+func (i *Importer) Import(ctx context.Context, dir string) (Stats, error) {
+	const op = "markdown.Importer.Import"
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	filenames, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var stats Stats
+	for _, filename := range filenames {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return stats, fmt.Errorf("%s: %w", op, err)
+		}
+
+		d, err := UnmarshalDocument(data)
+		if err != nil {
+			return stats, fmt.Errorf("%s: %s: %w", op, filepath.Base(filename), err)
+		}
+
+		dto, err := i.dto(ctx, d)
+		if err != nil {
+			return stats, fmt.Errorf("%s: %s: %w", op, filepath.Base(filename), err)
+		}
+
+		post, err := i.postRepo.Create(ctx, dto)
+		if err != nil {
+			if errors.Is(err, models.ErrPostAlreadyExists) {
+				stats.Skipped++
+				continue
+			}
+
+			return stats, fmt.Errorf("%s: %s: %w", op, filepath.Base(filename), err)
+		}
+
+		log.Debug("post has been imported", slog.String("file", filepath.Base(filename)), slog.String("post_id", string(post.ID)))
+		stats.Imported++
+	}
+
+	return stats, nil
+}
+
+func (i *Importer) dto(ctx context.Context, d Document) (models.CreatePostDTO, error) {
+	fm := d.FrontMatter
+
+	dto := models.CreatePostDTO{
+		Title:       fm.Title,
+		Content:     richtext.FromMarkdown(d.Body),
+		PublishDate: fm.PublishDate,
+		Tags:        make([]models.Tag, len(fm.Tags)),
+		Sources:     make([]models.Source, len(fm.Sources)),
+		Media:       make([]models.MediaID, len(fm.Media)),
+	}
+
+	if fm.ID != "" {
+		id := models.PostID(fm.ID)
+		dto.ID = &id
+	}
+
+	for j, t := range fm.Tags {
+		if !strings.HasPrefix(t, "#") {
+			t = "#" + t
+		}
+
+		dto.Tags[j] = models.Tag(t)
+	}
+
+	for j, s := range fm.Sources {
+		dto.Sources[j] = models.Source(s)
+	}
+
+	for j, m := range fm.Media {
+		media, err := i.mediaRepo.Create(ctx, m.Type, m.URI)
+		if err != nil {
+			return models.CreatePostDTO{}, err
+		}
+
+		dto.Media[j] = media.ID
+	}
+
+	return dto, nil
+}
@@ -0,0 +1,167 @@
+package markdown
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type postRepoStub struct {
+	posts   []models.Post
+	created []models.CreatePostDTO
+}
+
+func (r *postRepoStub) FindPublished(_ context.Context, _ models.PostSearchFilters, offset, limit uint64) ([]models.Post, error) {
+	if offset >= uint64(len(r.posts)) {
+		return nil, models.ErrPostNotFound
+	}
+
+	return r.posts[offset:min(offset+limit, uint64(len(r.posts)))], nil
+}
+
+func (r *postRepoStub) Create(_ context.Context, dto models.CreatePostDTO) (models.Post, error) {
+	for _, c := range r.created {
+		if *c.ID == *dto.ID {
+			return models.Post{}, models.ErrPostAlreadyExists
+		}
+	}
+
+	r.created = append(r.created, dto)
+
+	return models.Post{ID: *dto.ID}, nil
+}
+
+type mediaRepoStub struct {
+	media []models.Media
+}
+
+func (r *mediaRepoStub) Create(_ context.Context, filetype, uri string) (models.Media, error) {
+	m := models.Media{ID: models.MediaID(uri), Filetype: filetype, URI: uri}
+	r.media = append(r.media, m)
+
+	return m, nil
+}
+
+func TestExportImport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/media/photo.png" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer server.Close()
+
+	loc := time.FixedZone("Asia/Yekaterinburg", 5*60*60)
+
+	post := models.Post{
+		ID:          "0193f5a4-7c1e-7d2a-8b3c-4d5e6f708192",
+		Title:       "Заголовок: с двоеточием",
+		Content:     "<b>Жирный</b> текст\n\n<a href=\"https://example.com\">ссылка</a> и 2 * 2",
+		PublishDate: time.Date(2025, 12, 20, 10, 30, 0, 0, time.UTC),
+		Tags:        []models.Tag{"#новости", "#go"},
+		Sources:     []models.Source{models.SourceTG, models.SourceWebsite},
+		Media: []models.Media{
+			{ID: "1", Filetype: "image/png", URI: server.URL + "/media/photo.png"},
+			{ID: "2", Filetype: "video/mp4", URI: server.URL + "/media/missing.mp4"},
+		},
+	}
+
+	repo := &postRepoStub{posts: []models.Post{post}}
+	dir := t.TempDir()
+
+	exported, err := NewExporter(repo, server.Client(), loc).Export(t.Context(), dir, models.PostSearchFilters{})
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if exported != 1 {
+		t.Errorf("expected 1 exported post, got %d", exported)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "2025-12-20-"+string(post.ID)+".md"))
+	if err != nil {
+		t.Fatalf("unable to read exported post: %q", err)
+	}
+
+	for _, s := range []string{
+		"publish_date: 2025-12-20T15:30:00+05:00",
+		"- новости",
+		"file: media/" + string(post.ID) + "/1-photo.png",
+		"**Жирный** текст\n\n[ссылка](https://example.com) и 2 \\* 2\n",
+	} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("exported post does not contain %q:\n%s", s, data)
+		}
+	}
+
+	if strings.Contains(string(data), "2-missing.mp4") {
+		t.Errorf("missing media must not have a file")
+	}
+
+	media, err := os.ReadFile(filepath.Join(dir, "media", string(post.ID), "1-photo.png"))
+	if err != nil || string(media) != "png" {
+		t.Errorf("media has not been downloaded: %q", err)
+	}
+
+	mediaRepo := &mediaRepoStub{}
+	importer := NewImporter(repo, mediaRepo)
+
+	stats, err := importer.Import(t.Context(), dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if stats.Imported != 1 {
+		t.Fatalf("expected 1 imported post, got %+v", stats)
+	}
+
+	dto := repo.created[0]
+	if *dto.ID != post.ID || dto.Title != post.Title || dto.Content != post.Content || !dto.PublishDate.Equal(post.PublishDate) {
+		t.Errorf("unexpected post %+v", dto)
+	}
+
+	if !reflect.DeepEqual(dto.Tags, post.Tags) || !reflect.DeepEqual(dto.Sources, post.Sources) {
+		t.Errorf("unexpected tags %v or sources %v", dto.Tags, dto.Sources)
+	}
+
+	for i, m := range mediaRepo.media {
+		if m.URI != post.Media[i].URI || m.Filetype != post.Media[i].Filetype {
+			t.Errorf("unexpected media %+v", m)
+		}
+	}
+
+	stats, err = importer.Import(t.Context(), dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if stats.Imported != 0 || stats.Skipped != 1 {
+		t.Errorf("existing post must be skipped: %+v", stats)
+	}
+}
+
+func TestUnmarshalDocument(t *testing.T) {
+	_, err := UnmarshalDocument([]byte("# no front matter"))
+	if err != ErrNoFrontMatter {
+		t.Errorf("expected error %q, got %q", ErrNoFrontMatter, err)
+	}
+
+	d, err := UnmarshalDocument([]byte("---\r\ntitle: hand written\r\ntags: [a, b]\r\n---\r\n\r\nbody\r\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if d.FrontMatter.Title != "hand written" || len(d.FrontMatter.Tags) != 2 || d.Body != "body" {
+		t.Errorf("unexpected document %+v", d)
+	}
+}
@@ -0,0 +1,354 @@
+package mastodon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type PublicationRepository interface {
+	Find(ctx context.Context, postID models.PostID, source models.Source, target string) (models.Publication, error)
+	Save(ctx context.Context, publication models.Publication) error
+}
+
+const (
+	DefaultMaxCharacters = 500
+	DefaultVisibility    = "public"
+
+	// mediaPollAttempts limits waiting for the processing of uploaded media.
+	mediaPollAttempts = 30
+)
+
+var (
+	ErrEmptyBaseURL    = errors.New("mastodon base url is empty")
+	ErrEmptyToken      = errors.New("mastodon token is empty")
+	ErrMediaProcessing = errors.New("mastodon media is still processing")
+)
+
+// Config is a config of the mastodon source.
+type Config struct {
+	BaseURL string `json:"base_url"`
+	Token   string `json:"token"`
+	// Visibility is one of public, unlisted, private and direct.
+	Visibility string `json:"visibility"`
+	Language   string `json:"language"`
+	// MaxCharacters overrides the limit of the instance. The limit is requested from the instance when it is empty.
+	MaxCharacters int `json:"max_characters"`
+}
+
+// APIError is a non-successful response of the instance.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("mastodon api: status %d: %s", e.StatusCode, e.Message)
+}
+
+type statusRequest struct {
+	Status     string   `json:"status"`
+	MediaIDs   []string `json:"media_ids,omitempty"`
+	Visibility string   `json:"visibility,omitempty"`
+	Language   string   `json:"language,omitempty"`
+}
+
+type status struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+type attachment struct {
+	ID  string  `json:"id"`
+	URL *string `json:"url"`
+}
+
+type instance struct {
+	Configuration struct {
+		Statuses struct {
+			MaxCharacters int `json:"max_characters"`
+		} `json:"statuses"`
+	} `json:"configuration"`
+}
+
+type Publisher struct {
+	client            *http.Client
+	publicationRepo   PublicationRepository
+	postURL           string
+	source            models.Source
+	cfg               Config
+	mediaPollInterval time.Duration
+}
+
+// NewPublisher creates the publisher. The postURL is a link to the post on the website,
+// "{id}" in it is replaced with the post id.
+func NewPublisher(client *http.Client, publicationRepo PublicationRepository, postURL string) *Publisher {
+	return &Publisher{
+		client:            client,
+		publicationRepo:   publicationRepo,
+		postURL:           postURL,
+		mediaPollInterval: time.Second,
+	}
+}
+
+// WithSource returns a copy of the publisher configured by the source.
+func (p *Publisher) WithSource(source models.SourceConfig) (*Publisher, error) {
+	const op = "mastodon.Publisher.WithSource"
+
+	cfg := Config{
+		Visibility: DefaultVisibility,
+	}
+
+	if len(source.Config) != 0 {
+		if err := json.Unmarshal(source.Config, &cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("%s: %w", op, ErrEmptyBaseURL)
+	}
+
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("%s: %w", op, ErrEmptyToken)
+	}
+
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	publisher := *p
+	publisher.source = source.Source
+	publisher.cfg = cfg
+
+	return &publisher, nil
+}
+
+// Publish uploads the media of the post and creates the status. The status id is saved
+// to the publications, so the status can be edited or deleted later.
+func (p *Publisher) Publish(ctx context.Context, post events.PublishedPost) error {
+	const op = "mastodon.Publisher.Publish"
+
+	postID := models.PostID(post.Data.ID)
+
+	_, err := p.publicationRepo.Find(ctx, postID, p.source, p.cfg.BaseURL)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, models.ErrPublicationNotFound) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	mediaIDs := make([]string, 0, len(post.Data.Media))
+	for _, m := range post.Data.Media {
+		id, err := p.uploadMedia(ctx, m)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		mediaIDs = append(mediaIDs, id)
+	}
+
+	req := statusRequest{
+		Status:     StatusText(post.Data, p.link(post.Data.ID), p.maxCharacters(ctx)),
+		MediaIDs:   mediaIDs,
+		Visibility: p.cfg.Visibility,
+		Language:   p.cfg.Language,
+	}
+
+	// The idempotency key protects from duplicates when the event is handled again
+	// before the publication is saved.
+	var created status
+	if err := p.do(ctx, http.MethodPost, "/api/v1/statuses", req, map[string]string{
+		"Idempotency-Key": post.Data.ID,
+	}, &created); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := p.publicationRepo.Save(ctx, models.Publication{
+		PostID:      postID,
+		Source:      p.source,
+		Target:      p.cfg.BaseURL,
+		ExternalID:  created.ID,
+		PublishedAt: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (p *Publisher) maxCharacters(ctx context.Context) int {
+	const op = "mastodon.Publisher.maxCharacters"
+
+	if p.cfg.MaxCharacters > 0 {
+		return p.cfg.MaxCharacters
+	}
+
+	var i instance
+	if err := p.do(ctx, http.MethodGet, "/api/v2/instance", nil, nil, &i); err != nil {
+		logging.FromContext(ctx).Warn("unable to get instance limits, default is used", slog.String("op", op), slog.String("err", err.Error()))
+		return DefaultMaxCharacters
+	}
+
+	if i.Configuration.Statuses.MaxCharacters <= 0 {
+		return DefaultMaxCharacters
+	}
+
+	return i.Configuration.Statuses.MaxCharacters
+}
+
+// uploadMedia downloads the media by its uri and uploads it to the instance.
+// It waits until the instance has processed the media, because statuses cannot have unprocessed attachments.
+func (p *Publisher) uploadMedia(ctx context.Context, media events.PublishedPostMedia) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, media.URI, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to download media %s: status %d", media.URI, resp.StatusCode)
+	}
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	fw, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": {fmt.Sprintf(`form-data; name="file"; filename=%q`, path.Base(media.URI))},
+		"Content-Type":        {contentType},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(fw, resp.Body); err != nil {
+		return "", err
+	}
+
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+
+	var uploaded attachment
+	if err := p.doRaw(ctx, http.MethodPost, "/api/v2/media", body, mw.FormDataContentType(), nil, &uploaded); err != nil {
+		return "", err
+	}
+
+	for attempt := 0; uploaded.URL == nil; attempt++ {
+		if attempt == mediaPollAttempts {
+			return "", ErrMediaProcessing
+		}
+
+		timer := time.NewTimer(p.mediaPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", ctx.Err()
+		case <-timer.C:
+		}
+
+		err := p.do(ctx, http.MethodGet, "/api/v1/media/"+url.PathEscape(uploaded.ID), nil, nil, &uploaded)
+
+		// The instance responds with 206 while the media is processing.
+		var apiErr *APIError
+		if err != nil && !(errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusPartialContent) {
+			return "", err
+		}
+	}
+
+	return uploaded.ID, nil
+}
+
+func (p *Publisher) do(ctx context.Context, method, path string, in any, headers map[string]string, out any) error {
+	if in == nil {
+		return p.doRaw(ctx, method, path, nil, "", headers, out)
+	}
+
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	return p.doRaw(ctx, method, path, bytes.NewReader(body), "application/json", headers, out)
+}
+
+func (p *Publisher) doRaw(ctx context.Context, method, path string, body io.Reader, contentType string, headers map[string]string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, p.cfg.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+p.cfg.Token)
+	req.Header.Set("Accept", "application/json")
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusPartialContent {
+		return &APIError{StatusCode: resp.StatusCode, Message: "partial content"}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiResp struct {
+			Error string `json:"error"`
+		}
+
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&apiResp)
+
+		return &APIError{StatusCode: resp.StatusCode, Message: apiResp.Error}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (p *Publisher) link(postID string) string {
+	if p.postURL == "" {
+		return ""
+	}
+
+	return strings.ReplaceAll(p.postURL, "{id}", url.PathEscape(postID))
+}
@@ -0,0 +1,223 @@
+package mastodon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type publicationRepoStub struct {
+	publications map[string]models.Publication
+}
+
+func (r *publicationRepoStub) Find(_ context.Context, postID models.PostID, source models.Source, target string) (models.Publication, error) {
+	p, ok := r.publications[string(postID)+string(source)+target]
+	if !ok {
+		return models.Publication{}, models.ErrPublicationNotFound
+	}
+
+	return p, nil
+}
+
+func (r *publicationRepoStub) Save(_ context.Context, p models.Publication) error {
+	r.publications[string(p.PostID)+string(p.Source)+p.Target] = p
+	return nil
+}
+
+// fakeInstance is a local stand-in of the Mastodon API. Uploaded media are processed on the second request.
+type fakeInstance struct {
+	mu       sync.Mutex
+	media    map[string]int
+	statuses map[string]statusRequest
+}
+
+func newFakeInstance(t *testing.T) (*fakeInstance, *httptest.Server) {
+	t.Helper()
+
+	f := &fakeInstance{
+		media:    make(map[string]int),
+		statuses: make(map[string]statusRequest),
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /files/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("png"))
+	})
+
+	api := http.NewServeMux()
+
+	api.HandleFunc("GET /api/v2/instance", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"configuration":{"statuses":{"max_characters":120}}}`))
+	})
+
+	api.HandleFunc("POST /api/v2/media", func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, `{"error":"file is required"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		_ = file.Close()
+
+		f.mu.Lock()
+		id := fmt.Sprintf("media%d", len(f.media)+1)
+		f.media[id] = 0
+		f.mu.Unlock()
+
+		if header.Header.Get("Content-Type") != "image/png" {
+			t.Errorf("unexpected media content type %q", header.Header.Get("Content-Type"))
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = fmt.Fprintf(w, `{"id":%q,"url":null}`, id)
+	})
+
+	api.HandleFunc("GET /api/v1/media/{id}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		id := r.PathValue("id")
+		f.media[id]++
+
+		if f.media[id] < 2 {
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = fmt.Fprintf(w, `{"id":%q,"url":null}`, id)
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `{"id":%q,"url":"https://example.com/%s.png"}`, id, id)
+	})
+
+	api.HandleFunc("POST /api/v1/statuses", func(w http.ResponseWriter, r *http.Request) {
+		var req statusRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+			return
+		}
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		for _, id := range req.MediaIDs {
+			if f.media[id] < 2 {
+				http.Error(w, `{"error":"Cannot attach files that have not finished processing"}`, http.StatusUnprocessableEntity)
+				return
+			}
+		}
+
+		id := fmt.Sprintf("status%d", len(f.statuses)+1)
+		f.statuses[id] = req
+
+		_, _ = fmt.Fprintf(w, `{"id":%q}`, id)
+	})
+
+	mux.Handle("/api/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, `{"error":"The access token is invalid"}`, http.StatusUnauthorized)
+			return
+		}
+
+		api.ServeHTTP(w, r)
+	}))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return f, server
+}
+
+func newTestPublisher(t *testing.T, baseURL string) (*Publisher, *publicationRepoStub) {
+	t.Helper()
+
+	publicationRepo := &publicationRepoStub{publications: make(map[string]models.Publication)}
+
+	config, err := json.Marshal(Config{BaseURL: baseURL, Token: "token"})
+	if err != nil {
+		t.Fatalf("unable to marshal config: %q", err)
+	}
+
+	publisher, err := NewPublisher(http.DefaultClient, publicationRepo, "https://example.com/posts/{id}").WithSource(models.SourceConfig{
+		Source: "Мастодон",
+		Type:   models.SourceTypeMastodon,
+		Config: config,
+	})
+	if err != nil {
+		t.Fatalf("unable to create publisher: %q", err)
+	}
+
+	publisher.mediaPollInterval = time.Millisecond
+
+	return publisher, publicationRepo
+}
+
+func TestPublisher(t *testing.T) {
+	instance, server := newFakeInstance(t)
+	publisher, publicationRepo := newTestPublisher(t, server.URL)
+
+	post := events.PublishedPost{
+		Data: events.PublishedPostData{
+			ID:      "post",
+			Title:   "Заголовок",
+			Content: strings.Repeat("длинный текст ", 20),
+			Tags:    []string{"go lang"},
+			Media: []events.PublishedPostMedia{
+				{ID: "media", Filetype: "image", URI: server.URL + "/files/picture.png"},
+			},
+		},
+	}
+
+	// The second call must not create the status again.
+	for range 2 {
+		if err := publisher.Publish(t.Context(), post); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+	}
+
+	if len(instance.statuses) != 1 {
+		t.Fatalf("expected 1 status but got %d", len(instance.statuses))
+	}
+
+	publication, err := publicationRepo.Find(t.Context(), "post", "Мастодон", server.URL)
+	if err != nil {
+		t.Fatalf("publication has not been saved: %q", err)
+	}
+
+	created := instance.statuses[publication.ExternalID]
+
+	if Length(created.Status) > 120 {
+		t.Errorf("status is longer than the limit: %d", Length(created.Status))
+	}
+
+	if !strings.HasSuffix(created.Status, "…\n\n#golang\n\nhttps://example.com/posts/post") {
+		t.Errorf("unexpected status: %q", created.Status)
+	}
+
+	if len(created.MediaIDs) != 1 {
+		t.Errorf("expected 1 media but got %d", len(created.MediaIDs))
+	}
+}
+
+func TestPublisherPublishUnauthorized(t *testing.T) {
+	_, server := newFakeInstance(t)
+	publisher, _ := newTestPublisher(t, server.URL)
+	publisher.cfg.Token = "wrong"
+	publisher.cfg.MaxCharacters = DefaultMaxCharacters
+
+	err := publisher.Publish(t.Context(), events.PublishedPost{Data: events.PublishedPostData{ID: "post", Title: "title"}})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized error but got %+v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package mastodon
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/pkg/richtext"
+)
+
+// URLLength is the length of any link in the status: Mastodon counts every URL as 23 characters.
+const URLLength = 23
+
+const ellipsis = "…"
+
+var (
+	urlRe   = regexp.MustCompile(`https?://\S+`)
+	tokenRe = regexp.MustCompile(`\s*\S+`)
+)
+
+// StatusText builds the status of the post that fits the character limit of the instance.
+// Hashtags and the link to the post are always kept, the title and the content are truncated by words.
+func StatusText(post events.PublishedPostData, link string, limit int) string {
+	tail := &strings.Builder{}
+
+	if hashtags := Hashtags(post.Tags); len(hashtags) != 0 {
+		tail.WriteString("\n\n")
+		tail.WriteString(strings.Join(hashtags, " "))
+	}
+
+	if link != "" {
+		tail.WriteString("\n\n")
+		tail.WriteString(link)
+	}
+
+	body := strings.TrimSpace(post.Title)
+	if content := richtext.ToText(post.Content); content != "" {
+		body += "\n\n" + content
+	}
+
+	available := limit - Length(tail.String())
+	if Length(body) <= available {
+		return body + tail.String()
+	}
+
+	available -= utf8.RuneCountInString(ellipsis)
+
+	truncated := &strings.Builder{}
+	for _, token := range tokenRe.FindAllString(body, -1) {
+		if Length(truncated.String()+token) > available {
+			break
+		}
+
+		truncated.WriteString(token)
+	}
+
+	return truncated.String() + ellipsis + tail.String()
+}
+
+// Length returns the length of the text as Mastodon counts it.
+func Length(text string) int {
+	return utf8.RuneCountInString(urlRe.ReplaceAllString(text, strings.Repeat("x", URLLength)))
+}
+
+// Hashtags converts tags to hashtags. Characters that are not allowed in hashtags are removed.
+func Hashtags(tags []string) []string {
+	hashtags := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		hashtag := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+				return r
+			}
+
+			return -1
+		}, tag)
+
+		if hashtag == "" {
+			continue
+		}
+
+		hashtags = append(hashtags, "#"+hashtag)
+	}
+
+	return hashtags
+}
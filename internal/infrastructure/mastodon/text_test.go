@@ -0,0 +1,68 @@
+package mastodon
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kostromin59/poster/internal/events"
+)
+
+func TestStatusText(t *testing.T) {
+	tests := []struct {
+		name  string
+		post  events.PublishedPostData
+		link  string
+		limit int
+		want  string
+	}{
+		{
+			name:  "fits",
+			post:  events.PublishedPostData{Title: "Title", Content: "<b>Text</b>", Tags: []string{"tag"}},
+			link:  "https://example.com/posts/1",
+			limit: 500,
+			want:  "Title\n\nText\n\n#tag\n\nhttps://example.com/posts/1",
+		},
+		{
+			name:  "truncated by words",
+			post:  events.PublishedPostData{Title: "Title", Content: "one two three four"},
+			link:  "https://example.com/posts/1",
+			limit: 45,
+			want:  "Title\n\none two…\n\nhttps://example.com/posts/1",
+		},
+		{
+			name:  "without link",
+			post:  events.PublishedPostData{Title: "Title"},
+			limit: 500,
+			want:  "Title",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := StatusText(tt.post, tt.link, tt.limit)
+			if got != tt.want {
+				t.Errorf("expected %q but got %q", tt.want, got)
+			}
+
+			if Length(got) > tt.limit {
+				t.Errorf("status is longer than the limit: %d", Length(got))
+			}
+		})
+	}
+}
+
+func TestLength(t *testing.T) {
+	if got := Length("см. https://example.com/" + strings.Repeat("a", 100)); got != 4+URLLength {
+		t.Errorf("expected %d but got %d", 4+URLLength, got)
+	}
+}
+
+func TestHashtags(t *testing.T) {
+	got := Hashtags([]string{"#go", "новости дня", "c++", "-"})
+	want := []string{"#go", "#новостидня", "#c"}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v but got %v", want, got)
+	}
+}
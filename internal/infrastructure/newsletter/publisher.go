@@ -0,0 +1,320 @@
+package newsletter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/richtext"
+)
+
+type Sender interface {
+	Send(ctx context.Context, from, to string, msg []byte) error
+}
+
+type SubscriberRepository interface {
+	FindActive(ctx context.Context, after *models.SubscriberID, limit uint64) ([]models.Subscriber, error)
+	MarkBounced(ctx context.Context, email string) error
+}
+
+type PublicationRepository interface {
+	Find(ctx context.Context, postID models.PostID, source models.Source, target string) (models.Publication, error)
+	Save(ctx context.Context, publication models.Publication) error
+}
+
+const (
+	DefaultBatchSize     = 50
+	DefaultBatchInterval = time.Second
+)
+
+// Config is a config of the email source.
+type Config struct {
+	From      string `json:"from"`
+	BatchSize uint64 `json:"batch_size"`
+	// BatchInterval is a pause between batches, e.g. "1s". It keeps the rate below the SMTP provider limits.
+	BatchInterval string `json:"batch_interval"`
+}
+
+var postTemplate = template.Must(template.New("post").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+{{.Content}}
+{{if .Tags}}<p>{{.Tags}}</p>{{end}}
+<hr>
+<p><small><a href="{{.UnsubscribeURL}}">Отписаться от рассылки</a></small></p>
+</body>
+</html>`))
+
+var confirmationTemplate = template.Must(template.New("confirmation").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"></head>
+<body>
+<p>Чтобы получать новые посты, подтвердите подписку:</p>
+<p><a href="{{.ConfirmURL}}">Подтвердить подписку</a></p>
+<p><small>Если вы не подписывались, просто проигнорируйте это письмо.</small></p>
+</body>
+</html>`))
+
+type Publisher struct {
+	sender          Sender
+	subscriberRepo  SubscriberRepository
+	publicationRepo PublicationRepository
+	publicURL       string
+	source          models.Source
+	from            string
+	batchSize       uint64
+	batchInterval   time.Duration
+}
+
+func NewPublisher(
+	sender Sender,
+	subscriberRepo SubscriberRepository,
+	publicationRepo PublicationRepository,
+	publicURL string,
+	from string,
+) *Publisher {
+	return &Publisher{
+		sender:          sender,
+		subscriberRepo:  subscriberRepo,
+		publicationRepo: publicationRepo,
+		publicURL:       strings.TrimSuffix(publicURL, "/"),
+		source:          models.SourceNewsletter,
+		from:            from,
+		batchSize:       DefaultBatchSize,
+		batchInterval:   DefaultBatchInterval,
+	}
+}
+
+// WithSource returns a copy of the publisher configured by the source.
+func (p *Publisher) WithSource(source models.SourceConfig) (*Publisher, error) {
+	const op = "newsletter.Publisher.WithSource"
+
+	var cfg Config
+	if len(source.Config) != 0 {
+		if err := json.Unmarshal(source.Config, &cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	publisher := *p
+	publisher.source = source.Source
+
+	if cfg.From != "" {
+		publisher.from = cfg.From
+	}
+
+	if cfg.BatchSize != 0 {
+		publisher.batchSize = cfg.BatchSize
+	}
+
+	if cfg.BatchInterval != "" {
+		interval, err := time.ParseDuration(cfg.BatchInterval)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		publisher.batchInterval = interval
+	}
+
+	return &publisher, nil
+}
+
+// Publish sends the post to all active subscribers in batches.
+// Subscribers rejected by their mail servers permanently are marked as bounced.
+// Every delivery is saved as the publication with the subscriber id as the target, emails are not kept
+// after unsubscribing. The publication without the target is saved after all subscribers have been handled.
+func (p *Publisher) Publish(ctx context.Context, post events.PublishedPost) error {
+	const op = "newsletter.Publisher.Publish"
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	postID := models.PostID(post.Data.ID)
+
+	_, err := p.publicationRepo.Find(ctx, postID, p.source, "")
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, models.ErrPublicationNotFound) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var (
+		after *models.SubscriberID
+		sent  int
+		errs  []error
+	)
+
+	for {
+		subscribers, err := p.subscriberRepo.FindActive(ctx, after, p.batchSize)
+		if err != nil {
+			if errors.Is(err, models.ErrSubscriberNotFound) {
+				break
+			}
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, s := range subscribers {
+			// Subscribers who have received the post before a failure are skipped on the retry.
+			_, err := p.publicationRepo.Find(ctx, postID, p.source, string(s.ID))
+			if err == nil {
+				continue
+			}
+
+			if !errors.Is(err, models.ErrPublicationNotFound) {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
+			msg, err := p.postMessage(post, s)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
+			if err := p.sender.Send(ctx, p.from, s.Email, msg); err != nil {
+				if !IsPermanent(err) {
+					errs = append(errs, fmt.Errorf("%s: %w", s.Email, err))
+					continue
+				}
+
+				log.Warn("subscriber bounced", slog.String("err", err.Error()))
+
+				if err := p.subscriberRepo.MarkBounced(ctx, s.Email); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", s.Email, err))
+				}
+
+				continue
+			}
+
+			if err := p.publicationRepo.Save(ctx, models.Publication{
+				PostID:      postID,
+				Source:      p.source,
+				Target:      string(s.ID),
+				PublishedAt: time.Now(),
+			}); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.Email, err))
+			}
+
+			sent++
+		}
+
+		if uint64(len(subscribers)) < p.batchSize {
+			break
+		}
+
+		after = &subscribers[len(subscribers)-1].ID
+
+		timer := time.NewTimer(p.batchInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s: %w", op, ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	// Failed sends are retried with the event, so the publication of the post is saved only
+	// when every subscriber has received the post or bounced.
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := p.publicationRepo.Save(ctx, models.Publication{
+		PostID:      postID,
+		Source:      p.source,
+		ExternalID:  fmt.Sprintf("%d", sent),
+		PublishedAt: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SendConfirmation sends the double opt-in email with the confirmation link.
+func (p *Publisher) SendConfirmation(ctx context.Context, subscriber models.Subscriber) error {
+	const op = "newsletter.Publisher.SendConfirmation"
+
+	html := &bytes.Buffer{}
+	if err := confirmationTemplate.Execute(html, struct{ ConfirmURL string }{
+		ConfirmURL: p.link("/newsletter/confirm", subscriber.Token),
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	msg, err := Message{
+		From:    p.from,
+		To:      subscriber.Email,
+		Subject: "Подтвердите подписку",
+		Text:    "Чтобы получать новые посты, подтвердите подписку: " + p.link("/newsletter/confirm", subscriber.Token),
+		HTML:    html.String(),
+	}.Bytes()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := p.sender.Send(ctx, p.from, subscriber.Email, msg); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (p *Publisher) postMessage(post events.PublishedPost, subscriber models.Subscriber) ([]byte, error) {
+	unsubscribeURL := p.link("/newsletter/unsubscribe", subscriber.Token)
+	tags := strings.Join(post.Data.Tags, " ")
+
+	html := &bytes.Buffer{}
+	if err := postTemplate.Execute(html, struct {
+		Title          string
+		Content        template.HTML
+		Tags           string
+		UnsubscribeURL string
+	}{
+		Title:          post.Data.Title,
+		Content:        template.HTML(richtext.ToHTML(post.Data.Content)),
+		Tags:           tags,
+		UnsubscribeURL: unsubscribeURL,
+	}); err != nil {
+		return nil, err
+	}
+
+	text := &strings.Builder{}
+	text.WriteString(post.Data.Title)
+	text.WriteString("\n\n")
+	text.WriteString(richtext.ToText(post.Data.Content))
+	if tags != "" {
+		text.WriteString("\n\n")
+		text.WriteString(tags)
+	}
+	text.WriteString("\n\n--\nОтписаться от рассылки: ")
+	text.WriteString(unsubscribeURL)
+
+	return Message{
+		From:    p.from,
+		To:      subscriber.Email,
+		Subject: post.Data.Title,
+		Text:    text.String(),
+		HTML:    html.String(),
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}.Bytes()
+}
+
+func (p *Publisher) link(path, token string) string {
+	return p.publicURL + path + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,413 @@
+package newsletter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+// smtpServer is a local SMTP stand-in. It rejects recipients from the rejected list with 550.
+type smtpServer struct {
+	ln       net.Listener
+	rejected []string
+
+	mu       sync.Mutex
+	messages map[string]string
+}
+
+func newSMTPServer(t *testing.T, rejected ...string) *smtpServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %q", err)
+	}
+
+	s := &smtpServer{
+		ln:       ln,
+		rejected: rejected,
+		messages: make(map[string]string),
+	}
+
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		fmt.Fprintf(conn, "%s\r\n", line)
+	}
+
+	reply("220 localhost ESMTP")
+
+	var rcpt string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM"), cmd == "RSET", cmd == "NOOP":
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO"):
+			rcpt = strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>")
+			if slices.Contains(s.rejected, rcpt) {
+				reply("550 mailbox unavailable")
+				continue
+			}
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+
+			data := &strings.Builder{}
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+
+				if l == ".\r\n" {
+					break
+				}
+
+				data.WriteString(l)
+			}
+
+			s.mu.Lock()
+			s.messages[rcpt] = data.String()
+			s.mu.Unlock()
+
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func (s *smtpServer) message(to string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg, ok := s.messages[to]
+	return msg, ok
+}
+
+// decodeMessage returns the headers and the decoded parts of the message by content type.
+func decodeMessage(t *testing.T, raw string) (mail.Header, map[string]string) {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("unable to read message: %q", err)
+	}
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("unable to parse content type: %q", err)
+	}
+
+	parts := make(map[string]string)
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("unable to read part: %q", err)
+		}
+
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("unable to read part: %q", err)
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[mediaType] = strings.ReplaceAll(string(body), "\r\n", "\n")
+	}
+
+	return msg.Header, parts
+}
+
+type subscriberRepoStub struct {
+	subscribers []models.Subscriber
+	bounced     []string
+}
+
+func (r *subscriberRepoStub) FindActive(_ context.Context, after *models.SubscriberID, limit uint64) ([]models.Subscriber, error) {
+	var res []models.Subscriber
+	for _, s := range r.subscribers {
+		if after != nil && s.ID <= *after {
+			continue
+		}
+
+		if uint64(len(res)) == limit {
+			break
+		}
+
+		res = append(res, s)
+	}
+
+	if len(res) == 0 {
+		return nil, models.ErrSubscriberNotFound
+	}
+
+	return res, nil
+}
+
+func (r *subscriberRepoStub) MarkBounced(_ context.Context, email string) error {
+	r.bounced = append(r.bounced, email)
+	return nil
+}
+
+type publicationRepoStub struct {
+	publications map[string]models.Publication
+}
+
+func (r *publicationRepoStub) Find(_ context.Context, postID models.PostID, source models.Source, target string) (models.Publication, error) {
+	p, ok := r.publications[string(postID)+string(source)+target]
+	if !ok {
+		return models.Publication{}, models.ErrPublicationNotFound
+	}
+
+	return p, nil
+}
+
+func (r *publicationRepoStub) Save(_ context.Context, p models.Publication) error {
+	r.publications[string(p.PostID)+string(p.Source)+p.Target] = p
+	return nil
+}
+
+func TestPublisherPublish(t *testing.T) {
+	server := newSMTPServer(t, "bounced@example.com")
+
+	subscriberRepo := &subscriberRepoStub{
+		subscribers: []models.Subscriber{
+			{ID: "1", Email: "first@example.com", Token: "token1"},
+			{ID: "2", Email: "bounced@example.com", Token: "token2"},
+			{ID: "3", Email: "third@example.com", Token: "token3"},
+		},
+	}
+	publicationRepo := &publicationRepoStub{publications: make(map[string]models.Publication)}
+
+	config, err := json.Marshal(Config{BatchSize: 2, BatchInterval: "1ms"})
+	if err != nil {
+		t.Fatalf("unable to marshal config: %q", err)
+	}
+
+	publisher, err := NewPublisher(
+		NewSMTP(server.ln.Addr().String(), "", ""),
+		subscriberRepo,
+		publicationRepo,
+		"https://example.com/",
+		"poster@example.com",
+	).WithSource(models.SourceConfig{
+		Source: models.SourceNewsletter,
+		Type:   models.SourceTypeEmail,
+		Config: config,
+	})
+	if err != nil {
+		t.Fatalf("unable to create publisher: %q", err)
+	}
+
+	post := events.PublishedPost{
+		Data: events.PublishedPostData{
+			ID:      "post",
+			Title:   "Заголовок",
+			Content: "<b>bold</b> text",
+			Tags:    []string{"#tag"},
+		},
+	}
+
+	if err := publisher.Publish(t.Context(), post); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	for _, s := range []models.Subscriber{subscriberRepo.subscribers[0], subscriberRepo.subscribers[2]} {
+		msg, ok := server.message(s.Email)
+		if !ok {
+			t.Fatalf("message to %s has not been delivered", s.Email)
+		}
+
+		header, parts := decodeMessage(t, msg)
+
+		unsubscribeURL := "https://example.com/newsletter/unsubscribe?token=" + s.Token
+		if got := header.Get("List-Unsubscribe"); got != "<"+unsubscribeURL+">" {
+			t.Errorf("unexpected List-Unsubscribe: %q", got)
+		}
+
+		if got := header.Get("List-Unsubscribe-Post"); got != "List-Unsubscribe=One-Click" {
+			t.Errorf("unexpected List-Unsubscribe-Post: %q", got)
+		}
+
+		if !strings.Contains(parts["text/plain"], "Заголовок\n\nbold text") || !strings.Contains(parts["text/plain"], unsubscribeURL) {
+			t.Errorf("unexpected text part: %q", parts["text/plain"])
+		}
+
+		if !strings.Contains(parts["text/html"], "<b>bold</b> text") || !strings.Contains(parts["text/html"], unsubscribeURL) {
+			t.Errorf("unexpected html part: %q", parts["text/html"])
+		}
+	}
+
+	if !slices.Equal(subscriberRepo.bounced, []string{"bounced@example.com"}) {
+		t.Errorf("unexpected bounced subscribers: %v", subscriberRepo.bounced)
+	}
+
+	if _, err := publicationRepo.Find(t.Context(), "post", models.SourceNewsletter, ""); err != nil {
+		t.Errorf("publication has not been saved: %q", err)
+	}
+
+	// Deliveries are kept by subscriber ids, emails are not stored.
+	for _, p := range publicationRepo.publications {
+		if strings.Contains(p.Target, "@") {
+			t.Errorf("publication target %q is an email", p.Target)
+		}
+	}
+
+	if _, err := publicationRepo.Find(t.Context(), "post", models.SourceNewsletter, "1"); err != nil {
+		t.Errorf("delivery to the subscriber has not been saved: %q", err)
+	}
+}
+
+func TestPublisherPublishAlreadyPublished(t *testing.T) {
+	server := newSMTPServer(t)
+
+	subscriberRepo := &subscriberRepoStub{
+		subscribers: []models.Subscriber{{ID: "1", Email: "first@example.com", Token: "token1"}},
+	}
+	publicationRepo := &publicationRepoStub{publications: map[string]models.Publication{
+		"post" + string(models.SourceNewsletter): {PostID: "post", Source: models.SourceNewsletter},
+	}}
+
+	publisher := NewPublisher(NewSMTP(server.ln.Addr().String(), "", ""), subscriberRepo, publicationRepo, "https://example.com", "poster@example.com")
+
+	if err := publisher.Publish(t.Context(), events.PublishedPost{Data: events.PublishedPostData{ID: "post"}}); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if _, ok := server.message("first@example.com"); ok {
+		t.Error("already published post has been sent again")
+	}
+}
+
+// senderStub fails sends to the failing emails with a transient error.
+type senderStub struct {
+	failing []string
+	sent    []string
+}
+
+func (s *senderStub) Send(_ context.Context, _, to string, _ []byte) error {
+	if slices.Contains(s.failing, to) {
+		return errors.New("connection reset")
+	}
+
+	s.sent = append(s.sent, to)
+	return nil
+}
+
+func TestPublisherPublishRetry(t *testing.T) {
+	subscriberRepo := &subscriberRepoStub{
+		subscribers: []models.Subscriber{
+			{ID: "1", Email: "first@example.com", Token: "token1"},
+			{ID: "2", Email: "second@example.com", Token: "token2"},
+		},
+	}
+	publicationRepo := &publicationRepoStub{publications: make(map[string]models.Publication)}
+	sender := &senderStub{failing: []string{"second@example.com"}}
+
+	publisher := NewPublisher(sender, subscriberRepo, publicationRepo, "https://example.com", "poster@example.com")
+	post := events.PublishedPost{Data: events.PublishedPostData{ID: "post"}}
+
+	if err := publisher.Publish(t.Context(), post); err == nil {
+		t.Fatal("expected error but got nil")
+	}
+
+	if _, err := publicationRepo.Find(t.Context(), "post", models.SourceNewsletter, ""); !errors.Is(err, models.ErrPublicationNotFound) {
+		t.Errorf("publication has been saved after the transient failure: %v", err)
+	}
+
+	sender.failing = nil
+
+	if err := publisher.Publish(t.Context(), post); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if !slices.Equal(sender.sent, []string{"first@example.com", "second@example.com"}) {
+		t.Errorf("expected every subscriber to receive the post once, got %v", sender.sent)
+	}
+
+	if _, err := publicationRepo.Find(t.Context(), "post", models.SourceNewsletter, ""); err != nil {
+		t.Errorf("publication has not been saved: %q", err)
+	}
+
+	// Deliveries are kept by subscriber ids, emails are not stored.
+	for _, p := range publicationRepo.publications {
+		if strings.Contains(p.Target, "@") {
+			t.Errorf("publication target %q is an email", p.Target)
+		}
+	}
+
+	if _, err := publicationRepo.Find(t.Context(), "post", models.SourceNewsletter, "1"); err != nil {
+		t.Errorf("delivery to the subscriber has not been saved: %q", err)
+	}
+}
+
+func TestPublisherSendConfirmation(t *testing.T) {
+	server := newSMTPServer(t)
+
+	publisher := NewPublisher(NewSMTP(server.ln.Addr().String(), "", ""), &subscriberRepoStub{}, &publicationRepoStub{}, "https://example.com", "poster@example.com")
+
+	if err := publisher.SendConfirmation(t.Context(), models.Subscriber{Email: "new@example.com", Token: "token"}); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	msg, ok := server.message("new@example.com")
+	if !ok {
+		t.Fatal("confirmation has not been delivered")
+	}
+
+	_, parts := decodeMessage(t, msg)
+	if !strings.Contains(parts["text/html"], "https://example.com/newsletter/confirm?token=token") {
+		t.Errorf("confirmation does not contain the link: %q", parts["text/html"])
+	}
+}
@@ -0,0 +1,103 @@
+package newsletter
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+)
+
+// SMTP sends every message in a separate connection.
+type SMTP struct {
+	addr     string
+	username string
+	password string
+}
+
+func NewSMTP(addr, username, password string) *SMTP {
+	return &SMTP{
+		addr:     addr,
+		username: username,
+		password: password,
+	}
+}
+
+func (s *SMTP) Send(ctx context.Context, from, to string, msg []byte) error {
+	const op = "newsletter.SMTP.Send"
+
+	host, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, host)); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := client.Quit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// IsPermanent reports whether the server rejected the message permanently (5xx reply),
+// e.g. the mailbox does not exist. Such addresses are treated as bounced.
+func IsPermanent(err error) bool {
+	var tpErr *textproto.Error
+	return errors.As(err, &tpErr) && tpErr.Code >= 500 && tpErr.Code < 600
+}
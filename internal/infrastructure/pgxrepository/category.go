@@ -0,0 +1,174 @@
+package pgxrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/metrics"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type DBCategory struct {
+	ID        string  `db:"id"`
+	ParentID  *string `db:"parent_id"`
+	Slug      string  `db:"slug"`
+	Title     string  `db:"title"`
+	SortOrder int     `db:"sort_order"`
+}
+
+func (dbc DBCategory) Model() models.Category {
+	category := models.Category{
+		ID:    models.CategoryID(dbc.ID),
+		Slug:  dbc.Slug,
+		Title: dbc.Title,
+		Order: dbc.SortOrder,
+	}
+
+	if dbc.ParentID != nil {
+		parentID := models.CategoryID(*dbc.ParentID)
+		category.ParentID = &parentID
+	}
+
+	return category
+}
+
+type Category struct {
+	pool *pgxpool.Pool
+}
+
+func NewCategory(pool *pgxpool.Pool) *Category {
+	return &Category{
+		pool: pool,
+	}
+}
+
+func (c *Category) Create(ctx context.Context, dto models.CreateCategoryDTO) (models.Category, error) {
+	const op = "pgxrepository.Category.Create"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	category := models.Category{
+		ParentID: dto.ParentID,
+		Slug:     dto.Slug,
+		Title:    dto.Title,
+		Order:    dto.Order,
+	}
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := c.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return models.Category{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	row := tx.QueryRow(ctx, `INSERT INTO categories (parent_id, slug, title, sort_order) VALUES ($1, $2, $3, $4) RETURNING id`, dto.ParentID, dto.Slug, dto.Title, dto.Order)
+	if err := row.Scan(&category.ID); err != nil {
+		if isUniqueViolation(err) {
+			return models.Category{}, fmt.Errorf("%s: %w", op, models.ErrCategoryAlreadyExists)
+		}
+
+		if isForeignKeyViolation(err) {
+			return models.Category{}, fmt.Errorf("%s: %w", op, models.ErrCategoryNotFound)
+		}
+
+		return models.Category{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Category{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return category, nil
+}
+
+// FindAll returns categories in tree order: every parent is followed by its children.
+// The path holds the numeric positions of the categories among their siblings,
+// so any sort order, including negative, is compared as a number.
+func (c *Category) FindAll(ctx context.Context) ([]models.Category, error) {
+	const op = "pgxrepository.Category.FindAll"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := c.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	rows, err := tx.Query(ctx, `WITH RECURSIVE ranked AS (
+			SELECT
+				id,
+				parent_id,
+				slug,
+				title,
+				sort_order,
+				row_number() OVER (PARTITION BY parent_id ORDER BY sort_order, title, id) AS position
+			FROM categories
+		), tree AS (
+			SELECT
+				id,
+				parent_id,
+				slug,
+				title,
+				sort_order,
+				ARRAY[position] AS path
+			FROM ranked
+			WHERE parent_id IS NULL
+			UNION ALL
+			SELECT
+				r.id,
+				r.parent_id,
+				r.slug,
+				r.title,
+				r.sort_order,
+				t.path || r.position
+			FROM ranked r
+			JOIN tree t ON t.id = r.parent_id
+		)
+		SELECT id, parent_id, slug, title, sort_order FROM tree ORDER BY path`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	dbCategories, err := pgx.CollectRows(rows, pgx.RowToStructByName[DBCategory])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(dbCategories) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, models.ErrCategoryNotFound)
+	}
+
+	categories := make([]models.Category, len(dbCategories))
+	for i, dbc := range dbCategories {
+		categories[i] = dbc.Model()
+	}
+
+	return categories, nil
+}
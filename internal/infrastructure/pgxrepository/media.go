@@ -0,0 +1,38 @@
+package pgxrepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/metrics"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type Media struct {
+	pool *pgxpool.Pool
+}
+
+func NewMedia(pool *pgxpool.Pool) *Media {
+	return &Media{
+		pool: pool,
+	}
+}
+
+func (m *Media) Create(ctx context.Context, filetype, uri string) (models.Media, error) {
+	const op = "pgxrepository.Media.Create"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	media := models.Media{
+		Filetype: filetype,
+		URI:      uri,
+	}
+
+	row := m.pool.QueryRow(ctx, `INSERT INTO media (filetype, uri) VALUES ($1, $2) RETURNING id`, filetype, uri)
+	if err := row.Scan(&media.ID); err != nil {
+		return models.Media{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return media, nil
+}
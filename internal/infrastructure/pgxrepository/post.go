@@ -6,22 +6,29 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/metrics"
 	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type DBPost struct {
-	ID          string    `db:"id"`
-	Title       string    `db:"title"`
-	Content     string    `db:"content"`
-	PublishDate time.Time `db:"publish_date"`
-	Tags        []string  `db:"tags"`
-	Sources     []string  `db:"sources"`
-	Media       []byte    `db:"media"`
+	ID               string    `db:"id"`
+	Title            string    `db:"title"`
+	Content          string    `db:"content"`
+	PublishDate      time.Time `db:"publish_date"`
+	Tags             []string  `db:"tags"`
+	Sources          []string  `db:"sources"`
+	Media            []byte    `db:"media"`
+	Category         []byte    `db:"category"`
+	TelegramChannels []string  `db:"telegram_channels"`
 }
 
 type DBPostMedia struct {
@@ -30,6 +37,14 @@ type DBPostMedia struct {
 	URI      string `json:"uri,omitempty"`
 }
 
+type DBPostCategory struct {
+	ID        string  `json:"id"`
+	ParentID  *string `json:"parent_id"`
+	Slug      string  `json:"slug"`
+	Title     string  `json:"title"`
+	SortOrder int     `json:"sort_order"`
+}
+
 type Post struct {
 	pool *pgxpool.Pool
 }
@@ -42,8 +57,12 @@ func NewPost(pool *pgxpool.Pool) *Post {
 
 func (p *Post) Create(ctx context.Context, dto models.CreatePostDTO) (models.Post, error) {
 	const op = "pgxrepository.Post.Create"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	ctx, span := tracing.Tracer().Start(ctx, op)
+	defer span.End()
 
-	log := slog.With(slog.String("op", op))
+	log := logging.FromContext(ctx).With(slog.String("op", op))
 
 	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel:   pgx.ReadCommitted,
@@ -58,46 +77,196 @@ func (p *Post) Create(ctx context.Context, dto models.CreatePostDTO) (models.Pos
 		}
 	}()
 
-	for _, s := range dto.Sources {
-		if _, err := tx.Exec(ctx, `INSERT INTO sources (source) VALUES ($1) ON CONFLICT (source) DO NOTHING`, s); err != nil {
+	post := models.Post{
+		Title:            dto.Title,
+		Content:          dto.Content,
+		PublishDate:      dto.PublishDate,
+		Sources:          dto.Sources,
+		Tags:             dto.Tags,
+		TelegramChannels: dto.TelegramChannels,
+	}
+
+	if dto.Category != nil {
+		category, err := p.category(ctx, tx, *dto.Category)
+		if err != nil {
 			return models.Post{}, fmt.Errorf("%s: %w", op, err)
 		}
+
+		post.Category = &category
 	}
 
-	for _, t := range dto.Tags {
-		if _, err := tx.Exec(ctx, `INSERT INTO tags (tag) VALUES ($1) ON CONFLICT (tag) DO NOTHING`, t); err != nil {
+	postRow := tx.QueryRow(ctx, `INSERT INTO posts (id, title, content, publish_date, category_id) VALUES (COALESCE($1, uuidv7()), $2, $3, $4, $5) RETURNING id`, dto.ID, dto.Title, dto.Content, dto.PublishDate, dto.Category)
+	if err := postRow.Scan(&post.ID); err != nil {
+		if isUniqueViolation(err) {
+			return models.Post{}, fmt.Errorf("%s: %w", op, models.ErrPostAlreadyExists)
+		}
+
+		return models.Post{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	mediaIDs := slices.Clone(dto.Media)
+	for _, m := range dto.NewMedia {
+		var mediaID models.MediaID
+		if err := tx.QueryRow(ctx, `INSERT INTO media (filetype, uri) VALUES ($1, $2) RETURNING id`, m.Filetype, m.URI).Scan(&mediaID); err != nil {
 			return models.Post{}, fmt.Errorf("%s: %w", op, err)
 		}
+
+		mediaIDs = append(mediaIDs, mediaID)
 	}
 
-	post := models.Post{
-		Title:       dto.Title,
-		Content:     dto.Content,
-		PublishDate: dto.PublishDate,
-		Sources:     dto.Sources,
-		Tags:        dto.Tags,
+	postMedia, err := p.insertRelations(ctx, tx, post.ID, dto.Tags, dto.Sources, dto.TelegramChannels, mediaIDs)
+	if err != nil {
+		return models.Post{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	postRow := tx.QueryRow(ctx, `INSERT INTO posts (title, content, publish_date) VALUES ($1, $2, $3) RETURNING id`, dto.Title, dto.Content, dto.PublishDate)
-	if err := postRow.Scan(&post.ID); err != nil {
+	post.Media = postMedia
+	span.SetAttributes(attribute.String("post.id", string(post.ID)))
+
+	if err := tx.Commit(ctx); err != nil {
 		return models.Post{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	for _, s := range dto.Sources {
-		if _, err := tx.Exec(ctx, `INSERT INTO posts_sources (source, post_id) VALUES ($1, $2)`, s, post.ID); err != nil {
+	return post, nil
+}
+
+// Update replaces the fields and the relations of the post.
+func (p *Post) Update(ctx context.Context, dto models.UpdatePostDTO) (models.Post, error) {
+	const op = "pgxrepository.Post.Update"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	ctx, span := tracing.Tracer().Start(ctx, op)
+	defer span.End()
+
+	span.SetAttributes(attribute.String("post.id", string(dto.ID)))
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return models.Post{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	post := models.Post{
+		ID:               dto.ID,
+		Title:            dto.Title,
+		Content:          dto.Content,
+		PublishDate:      dto.PublishDate,
+		Sources:          dto.Sources,
+		Tags:             dto.Tags,
+		TelegramChannels: dto.TelegramChannels,
+	}
+
+	if dto.Category != nil {
+		category, err := p.category(ctx, tx, *dto.Category)
+		if err != nil {
 			return models.Post{}, fmt.Errorf("%s: %w", op, err)
 		}
+
+		post.Category = &category
+	}
+
+	cmdTag, err := tx.Exec(ctx, `UPDATE posts SET title = $2, content = $3, publish_date = $4, category_id = $5 WHERE id = $1`,
+		dto.ID, dto.Title, dto.Content, dto.PublishDate, dto.Category)
+	if err != nil {
+		return models.Post{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	for _, t := range dto.Tags {
-		if _, err := tx.Exec(ctx, `INSERT INTO posts_tags (tag, post_id) VALUES ($1, $2)`, t, post.ID); err != nil {
+	if cmdTag.RowsAffected() == 0 {
+		return models.Post{}, fmt.Errorf("%s: %w", op, models.ErrPostNotFound)
+	}
+
+	for _, table := range []string{"posts_tags", "posts_sources", "posts_telegram_channels", "posts_media"} {
+		if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE post_id = $1`, dto.ID); err != nil {
 			return models.Post{}, fmt.Errorf("%s: %w", op, err)
 		}
 	}
 
-	postMedia := make([]models.Media, 0, len(dto.Media))
-	for _, m := range dto.Media {
-		media := models.Media{
+	post.Media, err = p.insertRelations(ctx, tx, dto.ID, dto.Tags, dto.Sources, dto.TelegramChannels, dto.Media)
+	if err != nil {
+		return models.Post{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Post{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return post, nil
+}
+
+func (p *Post) category(ctx context.Context, tx pgx.Tx, id models.CategoryID) (models.Category, error) {
+	rows, err := tx.Query(ctx, `SELECT id, parent_id, slug, title, sort_order FROM categories WHERE id = $1`, id)
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	dbCategory, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[DBCategory])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Category{}, models.ErrCategoryNotFound
+		}
+
+		return models.Category{}, err
+	}
+
+	return dbCategory.Model(), nil
+}
+
+// insertRelations links tags, sources, telegram channels and media to the post.
+// Missing tags and sources are created.
+func (p *Post) insertRelations(
+	ctx context.Context,
+	tx pgx.Tx,
+	postID models.PostID,
+	tags []models.Tag,
+	sources []models.Source,
+	telegramChannels []models.TelegramChannelID,
+	media []models.MediaID,
+) ([]models.Media, error) {
+	for _, s := range sources {
+		if _, err := tx.Exec(ctx, `INSERT INTO sources (source) VALUES ($1) ON CONFLICT (source) DO NOTHING`, s); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, t := range tags {
+		if _, err := tx.Exec(ctx, `INSERT INTO tags (tag) VALUES ($1) ON CONFLICT (tag) DO NOTHING`, t); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range sources {
+		if _, err := tx.Exec(ctx, `INSERT INTO posts_sources (source, post_id) VALUES ($1, $2)`, s, postID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, t := range tags {
+		if _, err := tx.Exec(ctx, `INSERT INTO posts_tags (tag, post_id) VALUES ($1, $2)`, t, postID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, tc := range telegramChannels {
+		if _, err := tx.Exec(ctx, `INSERT INTO posts_telegram_channels (channel_id, post_id) VALUES ($1, $2)`, tc, postID); err != nil {
+			if isForeignKeyViolation(err) {
+				return nil, models.ErrTelegramChannelNotFound
+			}
+
+			return nil, err
+		}
+	}
+
+	postMedia := make([]models.Media, 0, len(media))
+	for _, m := range media {
+		pm := models.Media{
 			ID: m,
 		}
 
@@ -110,27 +279,49 @@ func (p *Post) Create(ctx context.Context, dto models.CreatePostDTO) (models.Pos
 				m.filetype,
 				m.uri
 			FROM inserted_media im
-			LEFT JOIN media m ON m.id = im.media_id`, m, post.ID)
-		if err := mediaRow.Scan(&media.Filetype, &media.URI); err != nil {
-			return models.Post{}, fmt.Errorf("%s: %w", op, err)
+			LEFT JOIN media m ON m.id = im.media_id`, m, postID)
+		if err := mediaRow.Scan(&pm.Filetype, &pm.URI); err != nil {
+			return nil, err
 		}
 
-		postMedia = append(postMedia, media)
+		postMedia = append(postMedia, pm)
 	}
 
-	post.Media = postMedia
-
-	if err := tx.Commit(ctx); err != nil {
-		return models.Post{}, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return post, nil
+	return postMedia, nil
 }
 
+// FindPublished returns posts with the publish date in the past, the latest first.
 func (p *Post) FindPublished(ctx context.Context, filters models.PostSearchFilters, offset, limit uint64) ([]models.Post, error) {
 	const op = "pgxrepository.Post.FindPublished"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	return p.find(ctx, op, filters, offset, limit, squirrel.Expr("p.publish_date <= NOW()"))
+}
 
-	log := slog.With(slog.String("op", op))
+// Find returns posts including scheduled ones, the latest first.
+func (p *Post) Find(ctx context.Context, filters models.PostSearchFilters, offset, limit uint64) ([]models.Post, error) {
+	const op = "pgxrepository.Post.Find"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	return p.find(ctx, op, filters, offset, limit)
+}
+
+// FindByID returns the post including a scheduled one.
+func (p *Post) FindByID(ctx context.Context, id models.PostID) (models.Post, error) {
+	const op = "pgxrepository.Post.FindByID"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	posts, err := p.find(ctx, op, models.PostSearchFilters{}, 0, 1, squirrel.Eq{"p.id": id})
+	if err != nil {
+		return models.Post{}, err
+	}
+
+	return posts[0], nil
+}
+
+// find returns posts by the filters, conds are additional conditions of the query.
+func (p *Post) find(ctx context.Context, op string, filters models.PostSearchFilters, offset, limit uint64, conds ...squirrel.Sqlizer) ([]models.Post, error) {
+	log := logging.FromContext(ctx).With(slog.String("op", op))
 
 	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel:   pgx.ReadCommitted,
@@ -150,8 +341,8 @@ func (p *Post) FindPublished(ctx context.Context, filters models.PostSearchFilte
 		"p.title",
 		"p.content",
 		"p.publish_date",
-		"array_agg(DISTINCT t.tag ORDER BY t.tag) AS tags",
-		"array_agg(DISTINCT s.source ORDER BY s.source) AS sources",
+		"array_remove(array_agg(DISTINCT t.tag ORDER BY t.tag), NULL) AS tags",
+		"array_remove(array_agg(DISTINCT s.source ORDER BY s.source), NULL) AS sources",
 		`(
 			SELECT COALESCE(
 					json_agg(
@@ -167,15 +358,35 @@ func (p *Post) FindPublished(ctx context.Context, filters models.PostSearchFilte
 			LEFT JOIN media m ON m.id = pm.media_id
 			WHERE pm.post_id = p.id
 		) AS media`,
+		`(
+			SELECT json_build_object(
+					'id', c.id,
+					'parent_id', c.parent_id,
+					'slug', c.slug,
+					'title', c.title,
+					'sort_order', c.sort_order
+			)
+			FROM categories c
+			WHERE c.id = p.category_id
+		) AS category`,
+		`ARRAY(
+			SELECT ptc.channel_id::text
+			FROM posts_telegram_channels ptc
+			WHERE ptc.post_id = p.id
+			ORDER BY ptc.channel_id
+		) AS telegram_channels`,
 	).From("posts p").
 		LeftJoin("posts_tags t ON t.post_id = p.id").
 		LeftJoin("posts_sources s ON s.post_id = p.id").
-		Where("p.publish_date <= NOW()").
 		GroupBy("p.id", "p.title", "p.content", "p.publish_date").
 		OrderBy("p.publish_date DESC").
 		Offset(offset).
 		Limit(limit)
 
+	for _, cond := range conds {
+		query = query.Where(cond)
+	}
+
 	if filters.Title != nil {
 		query = query.Where("p.title ILIKE ?", "%"+*filters.Title+"%")
 	}
@@ -198,6 +409,20 @@ func (p *Post) FindPublished(ctx context.Context, filters models.PostSearchFilte
 		))
 	}
 
+	if filters.Category != nil {
+		query = query.Where(squirrel.Expr(
+			`p.category_id IN (
+				WITH RECURSIVE descendants AS (
+					SELECT id FROM categories WHERE slug = ?
+					UNION ALL
+					SELECT c.id FROM categories c JOIN descendants d ON c.parent_id = d.id
+				)
+				SELECT id FROM descendants
+			)`,
+			*filters.Category,
+		))
+	}
+
 	sql, args, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -248,16 +473,85 @@ func (p *Post) FindPublished(ctx context.Context, filters models.PostSearchFilte
 			postSources[i] = models.Source(t)
 		}
 
+		var postCategory *models.Category
+		if len(dbp.Category) != 0 {
+			var dbCategory DBPostCategory
+			if err := json.Unmarshal(dbp.Category, &dbCategory); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+
+			category := DBCategory(dbCategory).Model()
+			postCategory = &category
+		}
+
+		postTelegramChannels := make([]models.TelegramChannelID, len(dbp.TelegramChannels))
+		for i, tc := range dbp.TelegramChannels {
+			postTelegramChannels[i] = models.TelegramChannelID(tc)
+		}
+
 		posts[i] = models.Post{
-			ID:          models.PostID(dbp.ID),
-			Title:       dbp.Title,
-			Content:     dbp.Content,
-			PublishDate: dbp.PublishDate,
-			Tags:        postTags,
-			Sources:     postSources,
-			Media:       postMedia,
+			ID:               models.PostID(dbp.ID),
+			Title:            dbp.Title,
+			Content:          dbp.Content,
+			PublishDate:      dbp.PublishDate,
+			Tags:             postTags,
+			Sources:          postSources,
+			Media:            postMedia,
+			Category:         postCategory,
+			TelegramChannels: postTelegramChannels,
 		}
 	}
 
 	return posts, nil
 }
+
+// Delete deletes the post with its relations. Publications keep ids of sent messages
+// which are needed to edit or delete them, so a post with publications is not deleted.
+func (p *Post) Delete(ctx context.Context, id models.PostID) error {
+	const op = "pgxrepository.Post.Delete"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	// The lock conflicts with inserts of publications, so none of them is added before the delete.
+	cmdTag, err := tx.Exec(ctx, `SELECT 1 FROM posts WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, models.ErrPostNotFound)
+	}
+
+	var published bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM publications WHERE post_id = $1)`, id).Scan(&published); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if published {
+		return fmt.Errorf("%s: %w", op, models.ErrPostPublished)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM posts WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
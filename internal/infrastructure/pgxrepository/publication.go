@@ -0,0 +1,141 @@
+package pgxrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/metrics"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type DBPublication struct {
+	PostID      string    `db:"post_id"`
+	Source      string    `db:"source"`
+	Target      string    `db:"target"`
+	ExternalID  string    `db:"external_id"`
+	PublishedAt time.Time `db:"published_at"`
+}
+
+func (dbp DBPublication) Model() models.Publication {
+	return models.Publication{
+		PostID:      models.PostID(dbp.PostID),
+		Source:      models.Source(dbp.Source),
+		Target:      dbp.Target,
+		ExternalID:  dbp.ExternalID,
+		PublishedAt: dbp.PublishedAt,
+	}
+}
+
+type Publication struct {
+	pool *pgxpool.Pool
+}
+
+func NewPublication(pool *pgxpool.Pool) *Publication {
+	return &Publication{
+		pool: pool,
+	}
+}
+
+// Save creates the publication or updates the external id of the existing one.
+func (p *Publication) Save(ctx context.Context, publication models.Publication) error {
+	const op = "pgxrepository.Publication.Save"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, `INSERT INTO publications (post_id, source, target, external_id, published_at) 
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (post_id, source, target) DO UPDATE SET 
+			external_id = EXCLUDED.external_id,
+			published_at = EXCLUDED.published_at`,
+		publication.PostID, publication.Source, publication.Target, publication.ExternalID, publication.PublishedAt,
+	); err != nil {
+		if isForeignKeyViolation(err) {
+			return fmt.Errorf("%s: %w", op, models.ErrPostNotFound)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (p *Publication) Find(ctx context.Context, postID models.PostID, source models.Source, target string) (models.Publication, error) {
+	const op = "pgxrepository.Publication.Find"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	return p.findOne(ctx, op, `SELECT post_id, source, target, external_id, published_at 
+		FROM publications 
+		WHERE post_id = $1 AND source = $2 AND target = $3`, postID, source, target)
+}
+
+// FindByExternalID returns the publication by the id of the message in the target, e.g. the telegram message id.
+func (p *Publication) FindByExternalID(ctx context.Context, source models.Source, target, externalID string) (models.Publication, error) {
+	const op = "pgxrepository.Publication.FindByExternalID"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	return p.findOne(ctx, op, `SELECT post_id, source, target, external_id, published_at
+		FROM publications
+		WHERE source = $1 AND target = $2 AND external_id = $3
+		LIMIT 1`, source, target, externalID)
+}
+
+func (p *Publication) findOne(ctx context.Context, op string, sql string, args ...any) (models.Publication, error) {
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return models.Publication{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return models.Publication{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	dbPublication, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[DBPublication])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Publication{}, fmt.Errorf("%s: %w", op, models.ErrPublicationNotFound)
+		}
+
+		return models.Publication{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Publication{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return dbPublication.Model(), nil
+}
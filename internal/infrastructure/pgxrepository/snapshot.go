@@ -0,0 +1,223 @@
+package pgxrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/metrics"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type snapshotTable struct {
+	name  string
+	query string
+}
+
+// snapshotTables are tables of the snapshot in the order of restoring: referenced tables go first.
+var snapshotTables = []snapshotTable{
+	{name: "sources", query: `SELECT row_to_json(t)::text FROM sources t ORDER BY t.source`},
+	{name: "tags", query: `SELECT row_to_json(t)::text FROM tags t ORDER BY t.tag`},
+	// Parent categories must be restored before their children.
+	{name: "categories", query: `WITH RECURSIVE tree AS (
+			SELECT id, 0 AS depth FROM categories WHERE parent_id IS NULL
+			UNION ALL
+			SELECT c.id, tree.depth + 1 FROM categories c JOIN tree ON c.parent_id = tree.id
+		)
+		SELECT row_to_json(t)::text FROM categories t JOIN tree ON tree.id = t.id ORDER BY tree.depth, t.id`},
+	{name: "telegram_channels", query: `SELECT row_to_json(t)::text FROM telegram_channels t ORDER BY t.id`},
+	{name: "media", query: `SELECT row_to_json(t)::text FROM media t ORDER BY t.id`},
+	{name: "posts", query: `SELECT row_to_json(t)::text FROM posts t ORDER BY t.id`},
+	{name: "posts_tags", query: `SELECT row_to_json(t)::text FROM posts_tags t ORDER BY t.post_id, t.tag`},
+	{name: "posts_sources", query: `SELECT row_to_json(t)::text FROM posts_sources t ORDER BY t.post_id, t.source`},
+	{name: "posts_media", query: `SELECT row_to_json(t)::text FROM posts_media t ORDER BY t.post_id, t.media_id`},
+	{name: "posts_telegram_channels", query: `SELECT row_to_json(t)::text FROM posts_telegram_channels t ORDER BY t.post_id, t.channel_id`},
+	{name: "publications", query: `SELECT row_to_json(t)::text FROM publications t ORDER BY t.post_id, t.source, t.target`},
+	{name: "subscribers", query: `SELECT row_to_json(t)::text FROM subscribers t ORDER BY t.id`},
+	{name: "webhook_deliveries", query: `SELECT row_to_json(t)::text FROM webhook_deliveries t ORDER BY t.id`},
+}
+
+// seededTables are filled by migrations, their rows are replaced by the snapshot.
+var seededTables = []string{"sources"}
+
+// Snapshot dumps and restores the whole content of the database as JSON rows.
+type Snapshot struct {
+	pool *pgxpool.Pool
+}
+
+func NewSnapshot(pool *pgxpool.Pool) *Snapshot {
+	return &Snapshot{
+		pool: pool,
+	}
+}
+
+// Tables returns tables of the snapshot in the order of restoring.
+func (s *Snapshot) Tables() []string {
+	tables := make([]string, len(snapshotTables))
+	for i, t := range snapshotTables {
+		tables[i] = t.name
+	}
+
+	return tables
+}
+
+// SchemaVersion returns the version of the last applied migration.
+func (s *Snapshot) SchemaVersion(ctx context.Context) (int64, error) {
+	const op = "pgxrepository.Snapshot.SchemaVersion"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	version, err := schemaVersion(ctx, s.pool)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return version, nil
+}
+
+// Dump reads the database in one read-only repeatable read transaction, so the tables and the schema version
+// are of the same moment even while the app writes. Dump calls the dump function with the schema version
+// and the rows function, which calls fn for every row of the table encoded as a JSON object.
+func (s *Snapshot) Dump(ctx context.Context, dump func(schemaVersion int64, rows func(table string, fn func(row []byte) error) error) error) error {
+	const op = "pgxrepository.Snapshot.Dump"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	version, err := schemaVersion(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows := func(table string, fn func(row []byte) error) error {
+		return dumpTable(ctx, tx, table, fn)
+	}
+
+	if err := dump(version, rows); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func schemaVersion(ctx context.Context, q interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}) (int64, error) {
+	var version int64
+	if err := q.QueryRow(ctx, `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`).Scan(&version); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+func dumpTable(ctx context.Context, tx pgx.Tx, table string, fn func(row []byte) error) error {
+	i := slices.IndexFunc(snapshotTables, func(t snapshotTable) bool { return t.name == table })
+	if i == -1 {
+		return fmt.Errorf("unknown table %q", table)
+	}
+
+	rows, err := tx.Query(ctx, snapshotTables[i].query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			return err
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Restore loads rows into the empty database in one transaction. Load inserts rows by the insert function,
+// the transaction is committed when it returns no error.
+func (s *Snapshot) Restore(ctx context.Context, load func(insert func(table string, row []byte) error) error) error {
+	const op = "pgxrepository.Snapshot.Restore"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.Serializable,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	for _, t := range snapshotTables {
+		if slices.Contains(seededTables, t.name) {
+			continue
+		}
+
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM `+t.name+`)`).Scan(&exists); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if exists {
+			return fmt.Errorf("%s: %s: %w", op, t.name, models.ErrDatabaseNotEmpty)
+		}
+	}
+
+	for _, t := range seededTables {
+		if _, err := tx.Exec(ctx, `DELETE FROM `+t); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	insert := func(table string, row []byte) error {
+		if !slices.ContainsFunc(snapshotTables, func(t snapshotTable) bool { return t.name == table }) {
+			return fmt.Errorf("unknown table %q", table)
+		}
+
+		_, err := tx.Exec(ctx, `INSERT INTO `+table+` SELECT * FROM json_populate_record(NULL::`+table+`, $1::json)`, string(row))
+
+		return err
+	}
+
+	if err := load(insert); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
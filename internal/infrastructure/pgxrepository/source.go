@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/metrics"
 	"github.com/kostromin59/poster/internal/models"
 )
 
@@ -23,8 +26,9 @@ func NewSource(pool *pgxpool.Pool) *Source {
 
 func (t *Source) FindAll(ctx context.Context) ([]models.Source, error) {
 	const op = "pgxrepository.Source.FindAll"
+	defer metrics.ObserveRepository(op, time.Now())
 
-	log := slog.With(slog.String("op", op))
+	log := logging.FromContext(ctx).With(slog.String("op", op))
 
 	tx, err := t.pool.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel:   pgx.ReadCommitted,
@@ -65,3 +69,59 @@ func (t *Source) FindAll(ctx context.Context) ([]models.Source, error) {
 
 	return sources, nil
 }
+
+type DBSourceConfig struct {
+	Source string `db:"source"`
+	Type   string `db:"type"`
+	Config []byte `db:"config"`
+}
+
+func (t *Source) FindAllConfigs(ctx context.Context) ([]models.SourceConfig, error) {
+	const op = "pgxrepository.Source.FindAllConfigs"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := t.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	rows, err := tx.Query(ctx, `SELECT source, type, config FROM sources`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	dbSources, err := pgx.CollectRows(rows, pgx.RowToStructByName[DBSourceConfig])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(dbSources) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, models.ErrSourceNotFound)
+	}
+
+	sources := make([]models.SourceConfig, len(dbSources))
+	for i, dbs := range dbSources {
+		sources[i] = models.SourceConfig{
+			Source: models.Source(dbs.Source),
+			Type:   models.SourceType(dbs.Type),
+			Config: dbs.Config,
+		}
+	}
+
+	return sources, nil
+}
@@ -0,0 +1,210 @@
+package pgxrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/metrics"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type DBSubscriber struct {
+	ID          string     `db:"id"`
+	Email       string     `db:"email"`
+	Status      string     `db:"status"`
+	Token       string     `db:"token"`
+	CreatedAt   time.Time  `db:"created_at"`
+	ConfirmedAt *time.Time `db:"confirmed_at"`
+}
+
+func (dbs DBSubscriber) Model() models.Subscriber {
+	return models.Subscriber{
+		ID:          models.SubscriberID(dbs.ID),
+		Email:       dbs.Email,
+		Status:      models.SubscriberStatus(dbs.Status),
+		Token:       dbs.Token,
+		CreatedAt:   dbs.CreatedAt,
+		ConfirmedAt: dbs.ConfirmedAt,
+	}
+}
+
+type Subscriber struct {
+	pool *pgxpool.Pool
+}
+
+func NewSubscriber(pool *pgxpool.Pool) *Subscriber {
+	return &Subscriber{
+		pool: pool,
+	}
+}
+
+// Subscribe creates a pending subscriber. A subscriber who is not active yet
+// is moved back to pending with the new token, so the confirmation can be requested again.
+// A pending subscriber keeps the token until resendAfter has passed since the last request,
+// so the email can't be flooded with confirmations. Both an active subscriber and a recent
+// request are reported as models.ErrSubscriberAlreadyExists.
+func (s *Subscriber) Subscribe(ctx context.Context, email, token string, resendAfter time.Duration) (models.Subscriber, error) {
+	const op = "pgxrepository.Subscriber.Subscribe"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return models.Subscriber{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	rows, err := tx.Query(ctx, `INSERT INTO subscribers (email, token, status)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (email) DO UPDATE SET
+			token = EXCLUDED.token,
+			status = EXCLUDED.status,
+			updated_at = NOW()
+		WHERE subscribers.status <> $4
+			AND (subscribers.status <> $3 OR subscribers.updated_at <= NOW() - make_interval(secs => $5))
+		RETURNING id, email, status, token, created_at, confirmed_at`,
+		email, token, models.SubscriberStatusPending, models.SubscriberStatusActive, resendAfter.Seconds())
+	if err != nil {
+		return models.Subscriber{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	dbSubscriber, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[DBSubscriber])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Subscriber{}, fmt.Errorf("%s: %w", op, models.ErrSubscriberAlreadyExists)
+		}
+
+		return models.Subscriber{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Subscriber{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return dbSubscriber.Model(), nil
+}
+
+func (s *Subscriber) Confirm(ctx context.Context, token string) error {
+	const op = "pgxrepository.Subscriber.Confirm"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	return s.update(ctx, op, `UPDATE subscribers
+		SET status = $2, confirmed_at = NOW(), updated_at = NOW()
+		WHERE token = $1 AND status = $3`,
+		token, models.SubscriberStatusActive, models.SubscriberStatusPending)
+}
+
+func (s *Subscriber) Unsubscribe(ctx context.Context, token string) error {
+	const op = "pgxrepository.Subscriber.Unsubscribe"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	return s.update(ctx, op, `UPDATE subscribers SET status = $2, updated_at = NOW() WHERE token = $1`,
+		token, models.SubscriberStatusUnsubscribed)
+}
+
+func (s *Subscriber) MarkBounced(ctx context.Context, email string) error {
+	const op = "pgxrepository.Subscriber.MarkBounced"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	return s.update(ctx, op, `UPDATE subscribers SET status = $2, updated_at = NOW() WHERE email = $1`,
+		email, models.SubscriberStatusBounced)
+}
+
+// update changes subscribers, models.ErrSubscriberNotFound is returned when nothing has been changed.
+func (s *Subscriber) update(ctx context.Context, op string, sql string, args ...any) error {
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	cmdTag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, models.ErrSubscriberNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// FindActive returns active subscribers ordered by id and starting after the given id,
+// so the list stays stable while subscribers change their status.
+func (s *Subscriber) FindActive(ctx context.Context, after *models.SubscriberID, limit uint64) ([]models.Subscriber, error) {
+	const op = "pgxrepository.Subscriber.FindActive"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	rows, err := tx.Query(ctx, `SELECT id, email, status, token, created_at, confirmed_at
+		FROM subscribers
+		WHERE status = $1 AND ($2::uuid IS NULL OR id > $2::uuid)
+		ORDER BY id
+		LIMIT $3`, models.SubscriberStatusActive, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	dbSubscribers, err := pgx.CollectRows(rows, pgx.RowToStructByName[DBSubscriber])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(dbSubscribers) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, models.ErrSubscriberNotFound)
+	}
+
+	subscribers := make([]models.Subscriber, len(dbSubscribers))
+	for i, dbs := range dbSubscribers {
+		subscribers[i] = dbs.Model()
+	}
+
+	return subscribers, nil
+}
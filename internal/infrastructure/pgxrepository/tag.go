@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/metrics"
 	"github.com/kostromin59/poster/internal/models"
 )
 
@@ -23,8 +26,9 @@ func NewTag(pool *pgxpool.Pool) *Tag {
 
 func (t *Tag) FindAll(ctx context.Context) ([]models.Tag, error) {
 	const op = "pgxrepository.Tag.FindAll"
+	defer metrics.ObserveRepository(op, time.Now())
 
-	log := slog.With(slog.String("op", op))
+	log := logging.FromContext(ctx).With(slog.String("op", op))
 
 	tx, err := t.pool.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel:   pgx.ReadCommitted,
@@ -65,3 +69,209 @@ func (t *Tag) FindAll(ctx context.Context) ([]models.Tag, error) {
 
 	return tags, nil
 }
+
+type DBTagUsage struct {
+	Tag        string `db:"tag"`
+	PostsCount int    `db:"posts_count"`
+}
+
+func (t *Tag) FindAllWithCount(ctx context.Context) ([]models.TagUsage, error) {
+	const op = "pgxrepository.Tag.FindAllWithCount"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := t.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	rows, err := tx.Query(ctx, `SELECT 
+			t.tag,
+			count(pt.post_id) AS posts_count
+		FROM tags t
+		LEFT JOIN posts_tags pt ON pt.tag = t.tag
+		GROUP BY t.tag
+		ORDER BY t.tag`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	dbTags, err := pgx.CollectRows(rows, pgx.RowToStructByName[DBTagUsage])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(dbTags) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, models.ErrTagNotFound)
+	}
+
+	tags := make([]models.TagUsage, len(dbTags))
+	for i, dbt := range dbTags {
+		tags[i] = models.TagUsage{
+			Tag:        models.Tag(dbt.Tag),
+			PostsCount: dbt.PostsCount,
+		}
+	}
+
+	return tags, nil
+}
+
+// Rename changes the tag name. Links with posts are updated by ON UPDATE CASCADE.
+func (t *Tag) Rename(ctx context.Context, tag, newTag models.Tag) error {
+	const op = "pgxrepository.Tag.Rename"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := t.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	cmdTag, err := tx.Exec(ctx, `UPDATE tags SET tag = $2 WHERE tag = $1`, tag, newTag)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, models.ErrTagAlreadyExists)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, models.ErrTagNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Merge moves all posts from the tag to the target tag and removes the tag.
+func (t *Tag) Merge(ctx context.Context, tag, target models.Tag) error {
+	const op = "pgxrepository.Tag.Merge"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := t.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	var count int
+	countRow := tx.QueryRow(ctx, `SELECT count(*) FROM tags WHERE tag = ANY($1)`, []models.Tag{tag, target})
+	if err := countRow.Scan(&count); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The same tag is counted once, merging it into itself changes nothing but it must exist.
+	expected := 2
+	if tag == target {
+		expected = 1
+	}
+
+	if count != expected {
+		return fmt.Errorf("%s: %w", op, models.ErrTagNotFound)
+	}
+
+	if tag == target {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `INSERT INTO posts_tags (tag, post_id) 
+		SELECT $2, post_id FROM posts_tags WHERE tag = $1
+		ON CONFLICT (tag, post_id) DO NOTHING`, tag, target); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM tags WHERE tag = $1`, tag); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Delete removes the tag only if it is not used by any post.
+func (t *Tag) Delete(ctx context.Context, tag models.Tag) error {
+	const op = "pgxrepository.Tag.Delete"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := t.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	var postsCount int
+	countRow := tx.QueryRow(ctx, `SELECT count(pt.post_id) 
+		FROM tags t 
+		LEFT JOIN posts_tags pt ON pt.tag = t.tag 
+		WHERE t.tag = $1 
+		GROUP BY t.tag`, tag)
+	if err := countRow.Scan(&postsCount); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, models.ErrTagNotFound)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if postsCount != 0 {
+		return fmt.Errorf("%s: %w", op, models.ErrTagInUse)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM tags WHERE tag = $1`, tag); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
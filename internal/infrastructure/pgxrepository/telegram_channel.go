@@ -0,0 +1,184 @@
+package pgxrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/metrics"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type DBTelegramChannel struct {
+	ID          string   `db:"id"`
+	ChatID      int64    `db:"chat_id"`
+	Title       string   `db:"title"`
+	Footer      string   `db:"footer"`
+	Template    string   `db:"template"`
+	DefaultTags []string `db:"default_tags"`
+	Silent      bool     `db:"silent"`
+}
+
+func (dbc DBTelegramChannel) Model() models.TelegramChannel {
+	defaultTags := make([]models.Tag, len(dbc.DefaultTags))
+	for i, t := range dbc.DefaultTags {
+		defaultTags[i] = models.Tag(t)
+	}
+
+	return models.TelegramChannel{
+		ID:          models.TelegramChannelID(dbc.ID),
+		ChatID:      dbc.ChatID,
+		Title:       dbc.Title,
+		Footer:      dbc.Footer,
+		Template:    dbc.Template,
+		DefaultTags: defaultTags,
+		Silent:      dbc.Silent,
+	}
+}
+
+type TelegramChannel struct {
+	pool *pgxpool.Pool
+}
+
+func NewTelegramChannel(pool *pgxpool.Pool) *TelegramChannel {
+	return &TelegramChannel{
+		pool: pool,
+	}
+}
+
+func (tc *TelegramChannel) Create(ctx context.Context, dto models.CreateTelegramChannelDTO) (models.TelegramChannel, error) {
+	const op = "pgxrepository.TelegramChannel.Create"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	defaultTags := make([]string, len(dto.DefaultTags))
+	for i, t := range dto.DefaultTags {
+		defaultTags[i] = string(t)
+	}
+
+	channel := models.TelegramChannel{
+		ChatID:      dto.ChatID,
+		Title:       dto.Title,
+		Footer:      dto.Footer,
+		Template:    dto.Template,
+		DefaultTags: dto.DefaultTags,
+		Silent:      dto.Silent,
+	}
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := tc.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return models.TelegramChannel{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	row := tx.QueryRow(ctx, `INSERT INTO telegram_channels (chat_id, title, footer, template, default_tags, silent) 
+		VALUES ($1, $2, $3, $4, $5, $6) 
+		RETURNING id`, dto.ChatID, dto.Title, dto.Footer, dto.Template, defaultTags, dto.Silent)
+	if err := row.Scan(&channel.ID); err != nil {
+		return models.TelegramChannel{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.TelegramChannel{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return channel, nil
+}
+
+func (tc *TelegramChannel) FindAll(ctx context.Context) ([]models.TelegramChannel, error) {
+	const op = "pgxrepository.TelegramChannel.FindAll"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	return tc.find(ctx, op, `SELECT id, chat_id, title, footer, template, default_tags, silent FROM telegram_channels ORDER BY title`)
+}
+
+// FindByIDs returns the found channels. Channels which have been deleted since the IDs were read
+// are skipped with a warning, so the post is still published to the rest of them.
+func (tc *TelegramChannel) FindByIDs(ctx context.Context, ids []models.TelegramChannelID) ([]models.TelegramChannel, error) {
+	const op = "pgxrepository.TelegramChannel.FindByIDs"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	channels, err := tc.find(ctx, op, `SELECT id, chat_id, title, footer, template, default_tags, silent FROM telegram_channels WHERE id = ANY($1) ORDER BY title`, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(channels) == len(ids) {
+		return channels, nil
+	}
+
+	found := make(map[models.TelegramChannelID]struct{}, len(channels))
+	for _, c := range channels {
+		found[c.ID] = struct{}{}
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			missing = append(missing, string(id))
+		}
+	}
+
+	if len(missing) != 0 {
+		logging.FromContext(ctx).Warn("telegram channels not found", slog.String("op", op), slog.String("ids", strings.Join(missing, ",")))
+	}
+
+	return channels, nil
+}
+
+func (tc *TelegramChannel) find(ctx context.Context, op string, sql string, args ...any) ([]models.TelegramChannel, error) {
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := tc.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	dbChannels, err := pgx.CollectRows(rows, pgx.RowToStructByName[DBTelegramChannel])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(dbChannels) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, models.ErrTelegramChannelNotFound)
+	}
+
+	channels := make([]models.TelegramChannel, len(dbChannels))
+	for i, dbc := range dbChannels {
+		channels[i] = dbc.Model()
+	}
+
+	return channels, nil
+}
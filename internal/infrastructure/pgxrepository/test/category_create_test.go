@@ -0,0 +1,65 @@
+package pgxrepository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestCategoryCreate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	categoryRepo := pgxrepository.NewCategory(pool)
+
+	t.Run("successful", func(t *testing.T) {
+		dto := models.CreateCategoryDTO{Slug: "news", Title: "Новости", Order: 1}
+
+		category, err := categoryRepo.Create(t.Context(), dto)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if category.ID == "" {
+			t.Error("expected not empty category id")
+		}
+
+		if category.Slug != dto.Slug {
+			t.Errorf("expected category slug %q but got %q", dto.Slug, category.Slug)
+		}
+	})
+
+	t.Run("already exists error", func(t *testing.T) {
+		_, err := categoryRepo.Create(t.Context(), models.CreateCategoryDTO{Slug: "news", Title: "Новости 2"})
+		if !errors.Is(err, models.ErrCategoryAlreadyExists) {
+			t.Errorf("expected error %+v but got %+v", models.ErrCategoryAlreadyExists, err)
+		}
+	})
+
+	t.Run("unknown parent error", func(t *testing.T) {
+		parentID := models.CategoryID("019b0000-0000-7000-8000-000000000000")
+
+		_, err := categoryRepo.Create(t.Context(), models.CreateCategoryDTO{ParentID: &parentID, Slug: "city", Title: "Город"})
+		if !errors.Is(err, models.ErrCategoryNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrCategoryNotFound, err)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package pgxrepository_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestCategoryFindAll(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	categoryRepo := pgxrepository.NewCategory(pool)
+
+	t.Run("not found error", func(t *testing.T) {
+		_, err := categoryRepo.FindAll(t.Context())
+		if err == nil {
+			t.Fatal("expected error but got nil")
+		}
+
+		if !errors.Is(err, models.ErrCategoryNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrCategoryNotFound, err)
+		}
+	})
+
+	articles, err := categoryRepo.Create(t.Context(), models.CreateCategoryDTO{Slug: "articles", Title: "Статьи", Order: 2})
+	if err != nil {
+		t.Fatalf("unable to create category: %q", err)
+	}
+
+	news, err := categoryRepo.Create(t.Context(), models.CreateCategoryDTO{Slug: "news", Title: "Новости", Order: 1})
+	if err != nil {
+		t.Fatalf("unable to create category: %q", err)
+	}
+
+	city, err := categoryRepo.Create(t.Context(), models.CreateCategoryDTO{ParentID: &news.ID, Slug: "city", Title: "Город", Order: 1})
+	if err != nil {
+		t.Fatalf("unable to create category: %q", err)
+	}
+
+	pinned, err := categoryRepo.Create(t.Context(), models.CreateCategoryDTO{Slug: "pinned", Title: "Закреплённое", Order: -1})
+	if err != nil {
+		t.Fatalf("unable to create category: %q", err)
+	}
+
+	archive, err := categoryRepo.Create(t.Context(), models.CreateCategoryDTO{Slug: "archive", Title: "Архив", Order: 100})
+	if err != nil {
+		t.Fatalf("unable to create category: %q", err)
+	}
+
+	t.Run("tree order", func(t *testing.T) {
+		categories, err := categoryRepo.FindAll(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		expectedCategories := []models.Category{pinned, news, city, articles, archive}
+		if !reflect.DeepEqual(categories, expectedCategories) {
+			t.Errorf("expected categories %+v but got %+v", expectedCategories, categories)
+		}
+	})
+}
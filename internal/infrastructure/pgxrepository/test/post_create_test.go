@@ -1,6 +1,7 @@
 package pgxrepository_test
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -193,4 +194,66 @@ func TestPostCreate(t *testing.T) {
 			t.Errorf("expected post media %+v but got %+v", media, post.Media)
 		}
 	})
+	t.Run("create with the id", func(t *testing.T) {
+		id := models.PostID("0193f5a4-7c1e-7d2a-8b3c-4d5e6f708192")
+
+		dto := models.CreatePostDTO{
+			ID:          &id,
+			Title:       "my title 4",
+			Content:     "my content 4",
+			PublishDate: time.Now(),
+			Sources:     []models.Source{models.SourceWebsite},
+		}
+
+		post, err := postRepo.Create(t.Context(), dto)
+		if err != nil {
+			t.Fatalf("unable to create post: %q", err)
+		}
+
+		if post.ID != id {
+			t.Errorf("expected post id %q but got %q", id, post.ID)
+		}
+
+		_, err = postRepo.Create(t.Context(), dto)
+		if !errors.Is(err, models.ErrPostAlreadyExists) {
+			t.Errorf("expected error %+v but got %+v", models.ErrPostAlreadyExists, err)
+		}
+	})
+
+	t.Run("create with new media", func(t *testing.T) {
+		id := models.PostID("0193f5a4-7c1e-7d2a-8b3c-4d5e6f708193")
+
+		dto := models.CreatePostDTO{
+			ID:          &id,
+			Title:       "my title 5",
+			Content:     "my content 5",
+			PublishDate: time.Now(),
+			Sources:     []models.Source{models.SourceWebsite},
+			Media:       mediaIDs[:1],
+			NewMedia:    []models.CreateMediaDTO{{Filetype: "image/jpeg", URI: "https://example.com/new.jpg"}},
+		}
+
+		post, err := postRepo.Create(t.Context(), dto)
+		if err != nil {
+			t.Fatalf("unable to create post: %q", err)
+		}
+
+		if len(post.Media) != 2 || post.Media[0] != media[0] || post.Media[1].URI != "https://example.com/new.jpg" || post.Media[1].ID == "" {
+			t.Errorf("unexpected post media %+v", post.Media)
+		}
+
+		_, err = postRepo.Create(t.Context(), dto)
+		if !errors.Is(err, models.ErrPostAlreadyExists) {
+			t.Errorf("expected error %+v but got %+v", models.ErrPostAlreadyExists, err)
+		}
+
+		var count int
+		if err := pool.QueryRow(t.Context(), `SELECT COUNT(*) FROM media WHERE uri = $1`, "https://example.com/new.jpg").Scan(&count); err != nil {
+			t.Fatalf("unable to count media: %q", err)
+		}
+
+		if count != 1 {
+			t.Errorf("expected media of the failed post to be rolled back, got %d rows", count)
+		}
+	})
 }
@@ -0,0 +1,99 @@
+package pgxrepository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestPostDelete(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	postRepo := pgxrepository.NewPost(pool)
+
+	t.Run("not found error", func(t *testing.T) {
+		err := postRepo.Delete(t.Context(), "019b0000-0000-7000-8000-000000000000")
+		if !errors.Is(err, models.ErrPostNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrPostNotFound, err)
+		}
+	})
+
+	t.Run("deletes post with relations", func(t *testing.T) {
+		post, err := postRepo.Create(t.Context(), models.CreatePostDTO{
+			Title:       "title",
+			Content:     "content",
+			PublishDate: time.Now().Add(time.Hour),
+			Tags:        []models.Tag{"#tag"},
+			Sources:     []models.Source{models.SourceTG},
+		})
+		if err != nil {
+			t.Fatalf("unable to create post: %q", err)
+		}
+
+		if err := postRepo.Delete(t.Context(), post.ID); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		var tagsCount int
+		if err := pool.QueryRow(t.Context(), `SELECT COUNT(*) FROM posts_tags WHERE post_id = $1`, post.ID).Scan(&tagsCount); err != nil {
+			t.Fatalf("unable to count tags: %q", err)
+		}
+
+		if tagsCount != 0 {
+			t.Errorf("expected tags of the post to be deleted but got %d", tagsCount)
+		}
+
+		if _, err := postRepo.Find(t.Context(), models.PostSearchFilters{}, 0, 20); !errors.Is(err, models.ErrPostNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrPostNotFound, err)
+		}
+	})
+	t.Run("post with publications is not deleted", func(t *testing.T) {
+		post, err := postRepo.Create(t.Context(), models.CreatePostDTO{
+			Title:       "title",
+			Content:     "content",
+			PublishDate: time.Now().Add(-time.Hour),
+			Sources:     []models.Source{models.SourceTG},
+		})
+		if err != nil {
+			t.Fatalf("unable to create post: %q", err)
+		}
+
+		publicationRepo := pgxrepository.NewPublication(pool)
+		if err := publicationRepo.Save(t.Context(), models.Publication{
+			PostID:      post.ID,
+			Source:      models.SourceTG,
+			ExternalID:  "42",
+			PublishedAt: time.Now(),
+		}); err != nil {
+			t.Fatalf("unable to save publication: %q", err)
+		}
+
+		if err := postRepo.Delete(t.Context(), post.ID); !errors.Is(err, models.ErrPostPublished) {
+			t.Errorf("expected error %+v but got %+v", models.ErrPostPublished, err)
+		}
+
+		if _, err := publicationRepo.Find(t.Context(), post.ID, models.SourceTG, ""); err != nil {
+			t.Errorf("expected publication to be kept but got %+v", err)
+		}
+	})
+}
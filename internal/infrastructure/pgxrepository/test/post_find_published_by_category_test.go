@@ -0,0 +1,90 @@
+package pgxrepository_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestPostFindPublishedByCategory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	postRepo := pgxrepository.NewPost(pool)
+	categoryRepo := pgxrepository.NewCategory(pool)
+
+	news, err := categoryRepo.Create(t.Context(), models.CreateCategoryDTO{Slug: "news", Title: "Новости"})
+	if err != nil {
+		t.Fatalf("unable to create category: %q", err)
+	}
+
+	city, err := categoryRepo.Create(t.Context(), models.CreateCategoryDTO{ParentID: &news.ID, Slug: "city", Title: "Город"})
+	if err != nil {
+		t.Fatalf("unable to create category: %q", err)
+	}
+
+	articles, err := categoryRepo.Create(t.Context(), models.CreateCategoryDTO{Slug: "articles", Title: "Статьи"})
+	if err != nil {
+		t.Fatalf("unable to create category: %q", err)
+	}
+
+	posts := make([]models.Post, 0, 3)
+	for i, category := range []models.Category{news, city, articles} {
+		post, err := postRepo.Create(t.Context(), models.CreatePostDTO{
+			Title:       category.Title,
+			Content:     "content",
+			PublishDate: time.Now().Add(-time.Duration(i+1) * time.Hour).Truncate(time.Second),
+			Tags:        []models.Tag{"tag"},
+			Sources:     []models.Source{models.SourceWebsite},
+			Category:    &category.ID,
+		})
+		if err != nil {
+			t.Fatalf("unable to create post: %q", err)
+		}
+
+		posts = append(posts, post)
+	}
+
+	t.Run("category with descendants", func(t *testing.T) {
+		publishedPosts, err := postRepo.FindPublished(t.Context(), models.PostSearchFilters{Category: &news.Slug}, 0, 20)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		comparePosts(t, posts[:2], publishedPosts)
+
+		for i, p := range publishedPosts {
+			if !reflect.DeepEqual(p.Category, posts[i].Category) {
+				t.Errorf("expected post category %+v but got %+v", posts[i].Category, p.Category)
+			}
+		}
+	})
+
+	t.Run("leaf category", func(t *testing.T) {
+		publishedPosts, err := postRepo.FindPublished(t.Context(), models.PostSearchFilters{Category: &city.Slug}, 0, 20)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		comparePosts(t, []models.Post{posts[1]}, publishedPosts)
+	})
+}
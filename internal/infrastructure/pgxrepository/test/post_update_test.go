@@ -0,0 +1,109 @@
+package pgxrepository_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestPostUpdate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	postRepo := pgxrepository.NewPost(pool)
+
+	var media models.Media
+	mediaRow := pool.QueryRow(t.Context(), `INSERT INTO media (filetype, uri) VALUES ($1, $2) RETURNING id, filetype, uri`, "jpeg", "some path")
+	if err := mediaRow.Scan(&media.ID, &media.Filetype, &media.URI); err != nil {
+		t.Fatalf("unable to insert media: %q", err)
+	}
+
+	t.Run("not found error", func(t *testing.T) {
+		_, err := postRepo.Update(t.Context(), models.UpdatePostDTO{
+			ID:          "019b0000-0000-7000-8000-000000000000",
+			Title:       "title",
+			PublishDate: time.Now(),
+		})
+		if !errors.Is(err, models.ErrPostNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrPostNotFound, err)
+		}
+
+		_, err = postRepo.FindByID(t.Context(), "019b0000-0000-7000-8000-000000000000")
+		if !errors.Is(err, models.ErrPostNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrPostNotFound, err)
+		}
+	})
+
+	t.Run("replaces fields and relations", func(t *testing.T) {
+		post, err := postRepo.Create(t.Context(), models.CreatePostDTO{
+			Title:       "title",
+			Content:     "content",
+			PublishDate: time.Now().Add(time.Hour),
+			Tags:        []models.Tag{"old"},
+			Sources:     []models.Source{models.SourceTG},
+			Media:       []models.MediaID{media.ID},
+		})
+		if err != nil {
+			t.Fatalf("unable to create post: %q", err)
+		}
+
+		publishDate := time.Now().Add(48 * time.Hour).Truncate(time.Microsecond)
+
+		updated, err := postRepo.Update(t.Context(), models.UpdatePostDTO{
+			ID:          post.ID,
+			Title:       "new title",
+			Content:     "new content",
+			PublishDate: publishDate,
+			Tags:        []models.Tag{"new1", "new2"},
+			Sources:     []models.Source{models.SourceWebsite},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if len(updated.Media) != 0 {
+			t.Errorf("expected media to be removed, got %+v", updated.Media)
+		}
+
+		found, err := postRepo.FindByID(t.Context(), post.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if found.Title != "new title" || found.Content != "new content" || !found.PublishDate.Equal(publishDate) {
+			t.Errorf("unexpected post: %+v", found)
+		}
+
+		if !reflect.DeepEqual(found.Tags, []models.Tag{"new1", "new2"}) {
+			t.Errorf("expected tags %v but got %v", []models.Tag{"new1", "new2"}, found.Tags)
+		}
+
+		if !reflect.DeepEqual(found.Sources, []models.Source{models.SourceWebsite}) {
+			t.Errorf("expected sources %v but got %v", []models.Source{models.SourceWebsite}, found.Sources)
+		}
+
+		if len(found.Media) != 0 {
+			t.Errorf("expected media to be removed, got %+v", found.Media)
+		}
+	})
+}
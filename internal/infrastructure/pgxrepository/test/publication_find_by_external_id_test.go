@@ -0,0 +1,68 @@
+package pgxrepository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestPublicationFindByExternalID(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	postRepo := pgxrepository.NewPost(pool)
+	publicationRepo := pgxrepository.NewPublication(pool)
+
+	post, err := postRepo.Create(t.Context(), models.CreatePostDTO{
+		Title:       "title",
+		Content:     "content",
+		PublishDate: time.Now(),
+		Sources:     []models.Source{models.SourceTG},
+	})
+	if err != nil {
+		t.Fatalf("unable to create post: %q", err)
+	}
+
+	if err := publicationRepo.Save(t.Context(), models.Publication{
+		PostID:      post.ID,
+		Source:      models.SourceTG,
+		Target:      "-1001",
+		ExternalID:  "42",
+		PublishedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("unable to save publication: %q", err)
+	}
+
+	publication, err := publicationRepo.FindByExternalID(t.Context(), models.SourceTG, "-1001", "42")
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if publication.PostID != post.ID {
+		t.Errorf("expected post %s but got %s", post.ID, publication.PostID)
+	}
+
+	_, err = publicationRepo.FindByExternalID(t.Context(), models.SourceTG, "-1002", "42")
+	if !errors.Is(err, models.ErrPublicationNotFound) {
+		t.Errorf("expected error %+v but got %+v", models.ErrPublicationNotFound, err)
+	}
+}
@@ -0,0 +1,74 @@
+package pgxrepository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestPublicationSave(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	postRepo := pgxrepository.NewPost(pool)
+	publicationRepo := pgxrepository.NewPublication(pool)
+
+	post, err := postRepo.Create(t.Context(), models.CreatePostDTO{
+		Title:       "title",
+		Content:     "content",
+		PublishDate: time.Now(),
+		Sources:     []models.Source{models.SourceTG},
+	})
+	if err != nil {
+		t.Fatalf("unable to create post: %q", err)
+	}
+
+	t.Run("not found error", func(t *testing.T) {
+		_, err := publicationRepo.Find(t.Context(), post.ID, models.SourceTG, "-1001")
+		if !errors.Is(err, models.ErrPublicationNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrPublicationNotFound, err)
+		}
+	})
+
+	t.Run("save and update", func(t *testing.T) {
+		for _, externalID := range []string{"1", "2"} {
+			if err := publicationRepo.Save(t.Context(), models.Publication{
+				PostID:      post.ID,
+				Source:      models.SourceTG,
+				Target:      "-1001",
+				ExternalID:  externalID,
+				PublishedAt: time.Now(),
+			}); err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+		}
+
+		publication, err := publicationRepo.Find(t.Context(), post.ID, models.SourceTG, "-1001")
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if publication.ExternalID != "2" {
+			t.Errorf("expected external id %q but got %q", "2", publication.ExternalID)
+		}
+	})
+}
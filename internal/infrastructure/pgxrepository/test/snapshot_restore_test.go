@@ -0,0 +1,117 @@
+package pgxrepository_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	categoryRepo := pgxrepository.NewCategory(pool)
+	postRepo := pgxrepository.NewPost(pool)
+	mediaRepo := pgxrepository.NewMedia(pool)
+	snapshot := pgxrepository.NewSnapshot(pool)
+
+	parent, err := categoryRepo.Create(t.Context(), models.CreateCategoryDTO{Slug: "parent", Title: "Parent"})
+	if err != nil {
+		t.Fatalf("unable to create category: %q", err)
+	}
+
+	child, err := categoryRepo.Create(t.Context(), models.CreateCategoryDTO{ParentID: &parent.ID, Slug: "child", Title: "Child"})
+	if err != nil {
+		t.Fatalf("unable to create category: %q", err)
+	}
+
+	media, err := mediaRepo.Create(t.Context(), "image/png", "https://example.com/1.png")
+	if err != nil {
+		t.Fatalf("unable to create media: %q", err)
+	}
+
+	if _, err := postRepo.Create(t.Context(), models.CreatePostDTO{
+		Title:       "title",
+		Content:     "content",
+		PublishDate: time.Now(),
+		Tags:        []models.Tag{"#tag"},
+		Sources:     []models.Source{models.SourceTG},
+		Media:       []models.MediaID{media.ID},
+		Category:    &child.ID,
+	}); err != nil {
+		t.Fatalf("unable to create post: %q", err)
+	}
+
+	dump := func() map[string][]string {
+		rows := make(map[string][]string)
+		if err := snapshot.Dump(t.Context(), func(_ int64, dumpRows func(table string, fn func(row []byte) error) error) error {
+			for _, table := range snapshot.Tables() {
+				if err := dumpRows(table, func(row []byte) error {
+					rows[table] = append(rows[table], string(row))
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}); err != nil {
+			t.Fatalf("unable to dump: %q", err)
+		}
+
+		return rows
+	}
+
+	restore := func(rows map[string][]string) error {
+		return snapshot.Restore(t.Context(), func(insert func(table string, row []byte) error) error {
+			for _, table := range snapshot.Tables() {
+				for _, row := range rows[table] {
+					if err := insert(table, []byte(row)); err != nil {
+						return err
+					}
+				}
+			}
+
+			return nil
+		})
+	}
+
+	expected := dump()
+
+	err = restore(expected)
+	if !errors.Is(err, models.ErrDatabaseNotEmpty) {
+		t.Errorf("expected error %+v but got %+v", models.ErrDatabaseNotEmpty, err)
+	}
+
+	if _, err := pool.Exec(t.Context(), `TRUNCATE posts, media, tags, categories CASCADE`); err != nil {
+		t.Fatalf("unable to truncate tables: %q", err)
+	}
+
+	if err := restore(expected); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if restored := dump(); !reflect.DeepEqual(expected, restored) {
+		t.Errorf("expected rows %+v but got %+v", expected, restored)
+	}
+}
@@ -0,0 +1,61 @@
+package pgxrepository_test
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestSourceFindAllConfigs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	sourceRepo := pgxrepository.NewSource(pool)
+
+	if _, err := pool.Exec(t.Context(), `UPDATE sources SET config = $2 WHERE source = $1`, models.SourceTG, `{"chat_id": 42}`); err != nil {
+		t.Fatalf("unable to update source: %q", err)
+	}
+
+	t.Run("initial sources", func(t *testing.T) {
+		sources, err := sourceRepo.FindAllConfigs(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		expectedTypes := map[models.Source]models.SourceType{
+			models.SourceTG:      models.SourceTypeTelegram,
+			models.SourceWebsite: models.SourceTypeWebsite,
+		}
+
+		if len(sources) != len(expectedTypes) {
+			t.Fatalf("expected sources len %d but got %d", len(expectedTypes), len(sources))
+		}
+
+		for _, s := range sources {
+			if s.Type != expectedTypes[s.Source] {
+				t.Errorf("expected source %q type %q but got %q", s.Source, expectedTypes[s.Source], s.Type)
+			}
+
+			if s.Source == models.SourceTG && string(s.Config) != `{"chat_id": 42}` {
+				t.Errorf("expected source %q config %q but got %q", s.Source, `{"chat_id": 42}`, s.Config)
+			}
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package pgxrepository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestSubscriberSubscribe(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	subscriberRepo := pgxrepository.NewSubscriber(pool)
+
+	subscriber, err := subscriberRepo.Subscribe(t.Context(), "reader@example.com", "token1", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if subscriber.Status != models.SubscriberStatusPending {
+		t.Errorf("expected status %q but got %q", models.SubscriberStatusPending, subscriber.Status)
+	}
+
+	t.Run("pending subscriber keeps token within cooldown", func(t *testing.T) {
+		_, err := subscriberRepo.Subscribe(t.Context(), "reader@example.com", "token2", time.Hour)
+		if !errors.Is(err, models.ErrSubscriberAlreadyExists) {
+			t.Errorf("expected error %+v but got %+v", models.ErrSubscriberAlreadyExists, err)
+		}
+	})
+
+	t.Run("pending subscriber gets new token", func(t *testing.T) {
+		subscriber, err := subscriberRepo.Subscribe(t.Context(), "reader@example.com", "token2", 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if subscriber.Token != "token2" {
+			t.Errorf("expected token %q but got %q", "token2", subscriber.Token)
+		}
+
+		if err := subscriberRepo.Confirm(t.Context(), "token1"); !errors.Is(err, models.ErrSubscriberNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrSubscriberNotFound, err)
+		}
+	})
+
+	t.Run("confirmed subscriber is active", func(t *testing.T) {
+		if err := subscriberRepo.Confirm(t.Context(), "token2"); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		subscribers, err := subscriberRepo.FindActive(t.Context(), nil, 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if len(subscribers) != 1 || subscribers[0].Email != "reader@example.com" {
+			t.Errorf("unexpected active subscribers: %+v", subscribers)
+		}
+
+		_, err = subscriberRepo.Subscribe(t.Context(), "reader@example.com", "token3", 0)
+		if !errors.Is(err, models.ErrSubscriberAlreadyExists) {
+			t.Errorf("expected error %+v but got %+v", models.ErrSubscriberAlreadyExists, err)
+		}
+	})
+
+	t.Run("bounced subscriber is not active", func(t *testing.T) {
+		if err := subscriberRepo.MarkBounced(t.Context(), "reader@example.com"); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		_, err := subscriberRepo.FindActive(t.Context(), nil, 10)
+		if !errors.Is(err, models.ErrSubscriberNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrSubscriberNotFound, err)
+		}
+	})
+}
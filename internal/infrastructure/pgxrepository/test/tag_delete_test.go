@@ -0,0 +1,78 @@
+package pgxrepository_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestTagDelete(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	tagRepo := pgxrepository.NewTag(pool)
+	postRepo := pgxrepository.NewPost(pool)
+
+	if _, err := postRepo.Create(t.Context(), models.CreatePostDTO{
+		Title:       "title",
+		Content:     "content",
+		PublishDate: time.Now(),
+		Tags:        []models.Tag{"used"},
+	}); err != nil {
+		t.Fatalf("unable to create post: %q", err)
+	}
+
+	if _, err := pool.Exec(t.Context(), `INSERT INTO tags (tag) VALUES ($1)`, "unused"); err != nil {
+		t.Fatalf("unable to insert tag: %q", err)
+	}
+
+	t.Run("in use error", func(t *testing.T) {
+		err := tagRepo.Delete(t.Context(), "used")
+		if !errors.Is(err, models.ErrTagInUse) {
+			t.Errorf("expected error %+v but got %+v", models.ErrTagInUse, err)
+		}
+	})
+
+	t.Run("not found error", func(t *testing.T) {
+		err := tagRepo.Delete(t.Context(), "unknown")
+		if !errors.Is(err, models.ErrTagNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrTagNotFound, err)
+		}
+	})
+
+	t.Run("successful", func(t *testing.T) {
+		if err := tagRepo.Delete(t.Context(), "unused"); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		tags, err := tagRepo.FindAll(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		expectedTags := []models.Tag{"used"}
+		if !reflect.DeepEqual(tags, expectedTags) {
+			t.Errorf("expected tags %+v but got %+v", expectedTags, tags)
+		}
+	})
+}
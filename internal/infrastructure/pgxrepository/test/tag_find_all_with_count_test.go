@@ -0,0 +1,78 @@
+package pgxrepository_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestTagFindAllWithCount(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	tagRepo := pgxrepository.NewTag(pool)
+	postRepo := pgxrepository.NewPost(pool)
+
+	t.Run("not found error", func(t *testing.T) {
+		_, err := tagRepo.FindAllWithCount(t.Context())
+		if err == nil {
+			t.Fatal("expected error but got nil")
+		}
+
+		if !errors.Is(err, models.ErrTagNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrTagNotFound, err)
+		}
+	})
+
+	for _, tags := range [][]models.Tag{{"tag1", "tag2"}, {"tag1"}} {
+		if _, err := postRepo.Create(t.Context(), models.CreatePostDTO{
+			Title:       "title",
+			Content:     "content",
+			PublishDate: time.Now(),
+			Tags:        tags,
+		}); err != nil {
+			t.Fatalf("unable to create post: %q", err)
+		}
+	}
+
+	if _, err := pool.Exec(t.Context(), `INSERT INTO tags (tag) VALUES ($1)`, "tag3"); err != nil {
+		t.Fatalf("unable to insert tag: %q", err)
+	}
+
+	t.Run("successful", func(t *testing.T) {
+		tags, err := tagRepo.FindAllWithCount(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		expectedTags := []models.TagUsage{
+			{Tag: "tag1", PostsCount: 2},
+			{Tag: "tag2", PostsCount: 1},
+			{Tag: "tag3", PostsCount: 0},
+		}
+
+		if !reflect.DeepEqual(tags, expectedTags) {
+			t.Errorf("expected tags %+v but got %+v", expectedTags, tags)
+		}
+	})
+}
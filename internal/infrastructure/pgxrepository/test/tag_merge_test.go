@@ -0,0 +1,83 @@
+package pgxrepository_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestTagMerge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	tagRepo := pgxrepository.NewTag(pool)
+	postRepo := pgxrepository.NewPost(pool)
+
+	for _, tags := range [][]models.Tag{{"tag1", "tag2"}, {"tag1"}, {"tag2"}} {
+		if _, err := postRepo.Create(t.Context(), models.CreatePostDTO{
+			Title:       "title",
+			Content:     "content",
+			PublishDate: time.Now(),
+			Tags:        tags,
+		}); err != nil {
+			t.Fatalf("unable to create post: %q", err)
+		}
+	}
+
+	t.Run("successful", func(t *testing.T) {
+		if err := tagRepo.Merge(t.Context(), "tag1", "tag2"); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		tags, err := tagRepo.FindAllWithCount(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		expectedTags := []models.TagUsage{
+			{Tag: "tag2", PostsCount: 3},
+		}
+
+		if !reflect.DeepEqual(tags, expectedTags) {
+			t.Errorf("expected tags %+v but got %+v", expectedTags, tags)
+		}
+	})
+
+	t.Run("not found error", func(t *testing.T) {
+		err := tagRepo.Merge(t.Context(), "unknown", "tag2")
+		if !errors.Is(err, models.ErrTagNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrTagNotFound, err)
+		}
+
+		err = tagRepo.Merge(t.Context(), "unknown", "unknown")
+		if !errors.Is(err, models.ErrTagNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrTagNotFound, err)
+		}
+	})
+
+	t.Run("same tag", func(t *testing.T) {
+		if err := tagRepo.Merge(t.Context(), "tag2", "tag2"); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package pgxrepository_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestTagRename(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	tagRepo := pgxrepository.NewTag(pool)
+	postRepo := pgxrepository.NewPost(pool)
+
+	if _, err := postRepo.Create(t.Context(), models.CreatePostDTO{
+		Title:       "title",
+		Content:     "content",
+		PublishDate: time.Now().Add(-1 * time.Hour),
+		Tags:        []models.Tag{"tag1", "tag2"},
+	}); err != nil {
+		t.Fatalf("unable to create post: %q", err)
+	}
+
+	t.Run("successful", func(t *testing.T) {
+		if err := tagRepo.Rename(t.Context(), "tag1", "renamed"); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		tags, err := tagRepo.FindAllWithCount(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		expectedTags := []models.TagUsage{
+			{Tag: "renamed", PostsCount: 1},
+			{Tag: "tag2", PostsCount: 1},
+		}
+
+		if !reflect.DeepEqual(tags, expectedTags) {
+			t.Errorf("expected tags %+v but got %+v", expectedTags, tags)
+		}
+	})
+
+	t.Run("already exists error", func(t *testing.T) {
+		err := tagRepo.Rename(t.Context(), "renamed", "tag2")
+		if !errors.Is(err, models.ErrTagAlreadyExists) {
+			t.Errorf("expected error %+v but got %+v", models.ErrTagAlreadyExists, err)
+		}
+	})
+
+	t.Run("not found error", func(t *testing.T) {
+		err := tagRepo.Rename(t.Context(), "unknown", "tag3")
+		if !errors.Is(err, models.ErrTagNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrTagNotFound, err)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package pgxrepository_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestTelegramChannelFindByIDs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	channelRepo := pgxrepository.NewTelegramChannel(pool)
+
+	t.Run("not found error", func(t *testing.T) {
+		_, err := channelRepo.FindByIDs(t.Context(), []models.TelegramChannelID{"019b0000-0000-7000-8000-000000000000"})
+		if !errors.Is(err, models.ErrTelegramChannelNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrTelegramChannelNotFound, err)
+		}
+	})
+
+	first, err := channelRepo.Create(t.Context(), models.CreateTelegramChannelDTO{
+		ChatID:      -1001,
+		Title:       "first",
+		Footer:      "first footer",
+		DefaultTags: []models.Tag{"#first"},
+	})
+	if err != nil {
+		t.Fatalf("unable to create channel: %q", err)
+	}
+
+	if _, err := channelRepo.Create(t.Context(), models.CreateTelegramChannelDTO{
+		ChatID: -1002,
+		Title:  "second",
+		Silent: true,
+	}); err != nil {
+		t.Fatalf("unable to create channel: %q", err)
+	}
+
+	t.Run("successful", func(t *testing.T) {
+		channels, err := channelRepo.FindByIDs(t.Context(), []models.TelegramChannelID{first.ID})
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		expected := []models.TelegramChannel{first}
+		if !reflect.DeepEqual(channels, expected) {
+			t.Errorf("expected channels %+v but got %+v", expected, channels)
+		}
+	})
+
+	t.Run("find all", func(t *testing.T) {
+		channels, err := channelRepo.FindAll(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if len(channels) != 2 {
+			t.Errorf("expected channels len %d but got %d", 2, len(channels))
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package pgxrepository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/infrastructure/pgxrepository"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestWebhookDeliveryFindLast(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	pgc.Migrate("migrations")
+
+	pool, err := pgxpool.New(t.Context(), pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to create pool: %q", err)
+	}
+
+	deliveryRepo := pgxrepository.NewWebhookDelivery(pool)
+
+	if _, err := pool.Exec(t.Context(), `INSERT INTO sources (source, type) VALUES ($1, $2)`, "partner", models.SourceTypeWebhook); err != nil {
+		t.Fatalf("unable to insert source: %q", err)
+	}
+
+	t.Run("not found error", func(t *testing.T) {
+		_, err := deliveryRepo.FindLast(t.Context(), "partner", 10)
+		if !errors.Is(err, models.ErrWebhookDeliveryNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrWebhookDeliveryNotFound, err)
+		}
+	})
+
+	now := time.Now().Truncate(time.Millisecond)
+	for attempt := 1; attempt <= 3; attempt++ {
+		if err := deliveryRepo.Create(t.Context(), models.WebhookDelivery{
+			Source:     "partner",
+			URL:        "http://localhost/hook",
+			EventID:    "event",
+			Attempt:    attempt,
+			StatusCode: 502,
+			Error:      "unexpected status code 502",
+			Duration:   15 * time.Millisecond,
+			CreatedAt:  now.Add(time.Duration(attempt) * time.Second),
+		}); err != nil {
+			t.Fatalf("unable to create delivery: %q", err)
+		}
+	}
+
+	t.Run("newest first with limit", func(t *testing.T) {
+		deliveries, err := deliveryRepo.FindLast(t.Context(), "partner", 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if len(deliveries) != 2 {
+			t.Fatalf("expected deliveries len %d but got %d", 2, len(deliveries))
+		}
+
+		if deliveries[0].Attempt != 3 {
+			t.Errorf("expected attempt %d but got %d", 3, deliveries[0].Attempt)
+		}
+
+		if deliveries[0].Duration != 15*time.Millisecond {
+			t.Errorf("expected duration %s but got %s", 15*time.Millisecond, deliveries[0].Duration)
+		}
+	})
+}
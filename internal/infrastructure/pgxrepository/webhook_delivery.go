@@ -0,0 +1,145 @@
+package pgxrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/metrics"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type DBWebhookDelivery struct {
+	Source     string    `db:"source"`
+	URL        string    `db:"url"`
+	EventID    string    `db:"event_id"`
+	PostID     *string   `db:"post_id"`
+	Attempt    int       `db:"attempt"`
+	StatusCode int       `db:"status_code"`
+	Error      string    `db:"error"`
+	DurationMS int64     `db:"duration_ms"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+type WebhookDelivery struct {
+	pool *pgxpool.Pool
+}
+
+func NewWebhookDelivery(pool *pgxpool.Pool) *WebhookDelivery {
+	return &WebhookDelivery{
+		pool: pool,
+	}
+}
+
+func (wd *WebhookDelivery) Create(ctx context.Context, delivery models.WebhookDelivery) error {
+	const op = "pgxrepository.WebhookDelivery.Create"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := wd.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, `INSERT INTO webhook_deliveries (source, url, event_id, post_id, attempt, status_code, error, duration_ms, created_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		delivery.Source,
+		delivery.URL,
+		delivery.EventID,
+		delivery.PostID,
+		delivery.Attempt,
+		delivery.StatusCode,
+		delivery.Error,
+		delivery.Duration.Milliseconds(),
+		delivery.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// FindLast returns the latest deliveries of the source, newest first.
+func (wd *WebhookDelivery) FindLast(ctx context.Context, source models.Source, limit uint64) ([]models.WebhookDelivery, error) {
+	const op = "pgxrepository.WebhookDelivery.FindLast"
+	defer metrics.ObserveRepository(op, time.Now())
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	tx, err := wd.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.ReadCommitted,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("unable to rollback tx", slog.String("err", err.Error()))
+		}
+	}()
+
+	rows, err := tx.Query(ctx, `SELECT source, url, event_id, post_id, attempt, status_code, error, duration_ms, created_at 
+		FROM webhook_deliveries 
+		WHERE source = $1 
+		ORDER BY created_at DESC 
+		LIMIT $2`, source, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	dbDeliveries, err := pgx.CollectRows(rows, pgx.RowToStructByName[DBWebhookDelivery])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(dbDeliveries) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, models.ErrWebhookDeliveryNotFound)
+	}
+
+	deliveries := make([]models.WebhookDelivery, len(dbDeliveries))
+	for i, dbd := range dbDeliveries {
+		var postID *models.PostID
+		if dbd.PostID != nil {
+			id := models.PostID(*dbd.PostID)
+			postID = &id
+		}
+
+		deliveries[i] = models.WebhookDelivery{
+			Source:     models.Source(dbd.Source),
+			URL:        dbd.URL,
+			EventID:    dbd.EventID,
+			PostID:     postID,
+			Attempt:    dbd.Attempt,
+			StatusCode: dbd.StatusCode,
+			Error:      dbd.Error,
+			Duration:   time.Duration(dbd.DurationMS) * time.Millisecond,
+			CreatedAt:  dbd.CreatedAt,
+		}
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,151 @@
+package restapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/feed"
+	"github.com/kostromin59/poster/pkg/richtext"
+)
+
+type FeedsPostRepository interface {
+	FindPublished(ctx context.Context, filters models.PostSearchFilters, offset, limit uint64) ([]models.Post, error)
+}
+
+const feedsLimit = 50
+
+type Feeds struct {
+	repo      FeedsPostRepository
+	title     string
+	publicURL string
+	postURL   string
+}
+
+// NewFeeds creates feed handlers. The postURL is a link to the post on the website,
+// "{id}" in it is replaced with the post id.
+func NewFeeds(repo FeedsPostRepository, title, publicURL, postURL string) *Feeds {
+	return &Feeds{
+		repo:      repo,
+		title:     title,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		postURL:   postURL,
+	}
+}
+
+// Register registers feeds. Feeds accept filters in query: tag and source may be repeated,
+// category is a category slug. Without the source filter posts of the website source are returned.
+func (f *Feeds) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /feed.rss", f.handler(feed.RSS, feed.ContentTypeRSS))
+	mux.HandleFunc("GET /feed.atom", f.handler(feed.Atom, feed.ContentTypeAtom))
+	mux.HandleFunc("GET /feed.json", f.handler(feed.JSON, feed.ContentTypeJSON))
+}
+
+func (f *Feeds) handler(render func(feed.Feed) ([]byte, error), contentType string) http.HandlerFunc {
+	const op = "restapi.Feeds.handler"
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logging.FromContext(r.Context()).With(slog.String("op", op), slog.String("path", r.URL.Path))
+
+		posts, err := f.repo.FindPublished(r.Context(), filtersFromQuery(r.URL.Query()), 0, feedsLimit)
+		if err != nil && !errors.Is(err, models.ErrPostNotFound) {
+			log.Error("unable to find posts", slog.String("err", err.Error()))
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		updated := feedUpdated(posts)
+
+		body, err := render(f.feed(r, posts, updated))
+		if err != nil {
+			log.Error("unable to render feed", slog.String("err", err.Error()))
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		sum := sha256.Sum256(body)
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+		w.Header().Set("Cache-Control", "public, max-age=300")
+
+		// ServeContent answers conditional requests with If-None-Match and If-Modified-Since.
+		http.ServeContent(w, r, "", updated, bytes.NewReader(body))
+	}
+}
+
+func (f *Feeds) feed(r *http.Request, posts []models.Post, updated time.Time) feed.Feed {
+	result := feed.Feed{
+		Title:    f.title,
+		Link:     f.publicURL,
+		FeedLink: f.publicURL + r.URL.RequestURI(),
+		Updated:  updated,
+		Items:    make([]feed.Item, len(posts)),
+	}
+
+	for i, p := range posts {
+		link := strings.ReplaceAll(f.postURL, "{id}", url.PathEscape(string(p.ID)))
+
+		item := feed.Item{
+			ID:          link,
+			Title:       p.Title,
+			Link:        link,
+			ContentHTML: richtext.ToHTML(p.Content),
+			Published:   p.PublishDate,
+			Tags:        make([]string, len(p.Tags)),
+			Enclosures:  make([]feed.Enclosure, len(p.Media)),
+		}
+
+		for j, t := range p.Tags {
+			item.Tags[j] = string(t)
+		}
+
+		for j, m := range p.Media {
+			item.Enclosures[j] = feed.Enclosure{
+				URL:  m.URI,
+				Type: feed.MediaType(m.Filetype, m.URI),
+			}
+		}
+
+		result.Items[i] = item
+	}
+
+	return result
+}
+
+func filtersFromQuery(query url.Values) models.PostSearchFilters {
+	filters := models.PostSearchFilters{
+		Tags:    query["tag"],
+		Sources: query["source"],
+	}
+
+	if len(filters.Sources) == 0 {
+		filters.Sources = []string{string(models.SourceWebsite)}
+	}
+
+	if category := query.Get("category"); category != "" {
+		filters.Category = &category
+	}
+
+	return filters
+}
+
+// feedUpdated returns the publish date of the latest post.
+func feedUpdated(posts []models.Post) (updated time.Time) {
+	for _, p := range posts {
+		if p.PublishDate.After(updated) {
+			updated = p.PublishDate
+		}
+	}
+
+	return updated
+}
@@ -0,0 +1,116 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type feedsPostRepoStub struct {
+	posts   []models.Post
+	filters models.PostSearchFilters
+}
+
+func (r *feedsPostRepoStub) FindPublished(_ context.Context, filters models.PostSearchFilters, _, _ uint64) ([]models.Post, error) {
+	r.filters = filters
+
+	if len(r.posts) == 0 {
+		return nil, models.ErrPostNotFound
+	}
+
+	return r.posts, nil
+}
+
+func TestFeeds(t *testing.T) {
+	publishDate := time.Date(2025, 12, 20, 10, 0, 0, 0, time.UTC)
+
+	repo := &feedsPostRepoStub{posts: []models.Post{{
+		ID:          "1",
+		Title:       "title",
+		Content:     "<b>bold</b>",
+		PublishDate: publishDate,
+		Tags:        []models.Tag{"go"},
+		Media:       []models.Media{{URI: "https://example.com/1.png", Filetype: "png"}},
+	}}}
+
+	mux := http.NewServeMux()
+	NewFeeds(repo, "Poster", "https://example.com", "https://example.com/posts/{id}").Register(mux)
+
+	tests := []struct {
+		target      string
+		contentType string
+		contains    string
+	}{
+		{"/feed.rss", "application/rss+xml; charset=utf-8", `<enclosure url="https://example.com/1.png" length="0" type="image/png">`},
+		{"/feed.atom", "application/atom+xml; charset=utf-8", "&lt;p&gt;&lt;b&gt;bold&lt;/b&gt;&lt;/p&gt;"},
+		{"/feed.json", "application/feed+json; charset=utf-8", `"url": "https://example.com/posts/1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status %d but got %d", http.StatusOK, rec.Code)
+			}
+
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("expected content type %q but got %q", tt.contentType, got)
+			}
+
+			if got := rec.Header().Get("Last-Modified"); got != publishDate.Format(http.TimeFormat) {
+				t.Errorf("unexpected last modified %q", got)
+			}
+
+			if !strings.Contains(rec.Body.String(), tt.contains) {
+				t.Errorf("feed does not contain %q:\n%s", tt.contains, rec.Body.String())
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+
+			rec = httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusNotModified {
+				t.Errorf("expected status %d but got %d", http.StatusNotModified, rec.Code)
+			}
+		})
+	}
+}
+
+func TestFeedsFilters(t *testing.T) {
+	repo := &feedsPostRepoStub{}
+
+	mux := http.NewServeMux()
+	NewFeeds(repo, "Poster", "https://example.com", "https://example.com/posts/{id}").Register(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed.rss", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d but got %d", http.StatusOK, rec.Code)
+	}
+
+	if !slices.Equal(repo.filters.Sources, []string{string(models.SourceWebsite)}) {
+		t.Errorf("expected website source by default but got %v", repo.filters.Sources)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed.json?tag=go&tag=news&source=partner&category=tech", nil))
+
+	if !slices.Equal(repo.filters.Tags, []string{"go", "news"}) || !slices.Equal(repo.filters.Sources, []string{"partner"}) {
+		t.Errorf("unexpected filters: %+v", repo.filters)
+	}
+
+	if repo.filters.Category == nil || *repo.filters.Category != "tech" {
+		t.Errorf("unexpected category filter: %v", repo.filters.Category)
+	}
+}
@@ -0,0 +1,228 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheck returns an error when the component is unhealthy.
+type HealthCheck func(ctx context.Context) error
+
+const healthCheckTimeout = 3 * time.Second
+
+const (
+	healthStatusOK           = "ok"
+	healthStatusFail         = "fail"
+	healthStatusShuttingDown = "shutting_down"
+	healthStatusStarting     = "starting"
+)
+
+type componentStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type healthResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]componentStatus `json:"components"`
+}
+
+type healthComponent struct {
+	name  string
+	check HealthCheck
+}
+
+// Health serves liveness (/healthz) and readiness (/readyz) probes.
+// Liveness checks report problems which are fixed by restarting the process, e.g. the stopped poller.
+// Readiness checks report dependencies without which the process can't do its work. Liveness checks
+// are a part of readiness too.
+type Health struct {
+	mu        sync.RWMutex
+	liveness  []healthComponent
+	readiness []healthComponent
+
+	ready        atomic.Bool
+	shuttingDown atomic.Bool
+}
+
+func NewHealth() *Health {
+	return &Health{}
+}
+
+func (h *Health) AddLiveness(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.liveness = append(h.liveness, healthComponent{name: name, check: check})
+}
+
+func (h *Health) AddReadiness(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.readiness = append(h.readiness, healthComponent{name: name, check: check})
+}
+
+// SetReady marks the app as started.
+func (h *Health) SetReady() {
+	h.ready.Store(true)
+}
+
+// SetShuttingDown turns readiness off, so no new traffic comes while the app is shutting down.
+func (h *Health) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+func (h *Health) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", h.healthz)
+	mux.HandleFunc("GET /readyz", h.readyz)
+}
+
+func (h *Health) healthz(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	components := h.liveness
+	h.mu.RUnlock()
+
+	h.write(w, check(r.Context(), components), "")
+}
+
+func (h *Health) readyz(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	components := append(append([]healthComponent{}, h.liveness...), h.readiness...)
+	h.mu.RUnlock()
+
+	status := ""
+	switch {
+	case h.shuttingDown.Load():
+		status = healthStatusShuttingDown
+	case !h.ready.Load():
+		status = healthStatusStarting
+	}
+
+	h.write(w, check(r.Context(), components), status)
+}
+
+// write writes statuses of components. The status overrides the status of components.
+func (h *Health) write(w http.ResponseWriter, components map[string]componentStatus, status string) {
+	resp := healthResponse{
+		Status:     healthStatusOK,
+		Components: components,
+	}
+
+	for _, c := range components {
+		if c.Status != healthStatusOK {
+			resp.Status = healthStatusFail
+		}
+	}
+
+	if status != "" {
+		resp.Status = status
+	}
+
+	code := http.StatusOK
+	if resp.Status != healthStatusOK {
+		code = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, code, resp)
+}
+
+// check runs checks concurrently, every check is limited by healthCheckTimeout.
+func check(ctx context.Context, components []healthComponent) map[string]componentStatus {
+	statuses := make(map[string]componentStatus, len(components))
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for _, c := range components {
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			status := componentStatus{Status: healthStatusOK}
+			if err := c.check(ctx); err != nil {
+				status = componentStatus{Status: healthStatusFail, Error: err.Error()}
+			}
+
+			mu.Lock()
+			statuses[c.name] = status
+			mu.Unlock()
+		})
+	}
+
+	wg.Wait()
+
+	return statuses
+}
+
+// cachedHealthCheck runs the check at most once per interval and keeps the result.
+type cachedHealthCheck struct {
+	check    HealthCheck
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	err       error
+	checkedAt time.Time
+	// running is closed when the running check is done, it is nil when no check runs.
+	running chan struct{}
+}
+
+// CachedHealthCheck is for checks which are costly or ignore the context, e.g. calls of external APIs.
+// The check runs in the background at most once per interval and only one at a time, probes return
+// the last result. Only the first probe waits for the result.
+func CachedHealthCheck(check HealthCheck, interval time.Duration) HealthCheck {
+	return newCachedHealthCheck(check, interval, time.Now).Check
+}
+
+func newCachedHealthCheck(check HealthCheck, interval time.Duration, now func() time.Time) *cachedHealthCheck {
+	return &cachedHealthCheck{
+		check:    check,
+		interval: interval,
+		now:      now,
+	}
+}
+
+func (c *cachedHealthCheck) Check(ctx context.Context) error {
+	c.mu.Lock()
+	if c.running == nil && c.now().Sub(c.checkedAt) >= c.interval {
+		c.running = make(chan struct{})
+		go c.run(c.running)
+	}
+	running, checked := c.running, !c.checkedAt.IsZero()
+	c.mu.Unlock()
+
+	if !checked {
+		select {
+		case <-running:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+func (c *cachedHealthCheck) run(done chan struct{}) {
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	err := c.check(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.err = err
+	c.checkedAt = c.now()
+	c.running = nil
+}
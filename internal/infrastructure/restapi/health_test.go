@@ -0,0 +1,149 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kostromin59/poster/pkg/fakeclock"
+)
+
+func TestHealth(t *testing.T) {
+	var dbErr error
+
+	health := NewHealth()
+	health.AddLiveness("poller", func(context.Context) error { return nil })
+	health.AddReadiness("postgres", func(context.Context) error { return dbErr })
+
+	mux := http.NewServeMux()
+	health.Register(mux)
+
+	do := func(target string) (int, healthResponse) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+		var resp healthResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("unable to decode response: %q", err)
+		}
+
+		return rec.Code, resp
+	}
+
+	if code, resp := do("/readyz"); code != http.StatusServiceUnavailable || resp.Status != healthStatusStarting {
+		t.Errorf("readyz before start: expected %d %q, got %d %q", http.StatusServiceUnavailable, healthStatusStarting, code, resp.Status)
+	}
+
+	health.SetReady()
+
+	code, resp := do("/readyz")
+	if code != http.StatusOK || resp.Status != healthStatusOK {
+		t.Errorf("readyz: expected %d %q, got %d %q", http.StatusOK, healthStatusOK, code, resp.Status)
+	}
+
+	if len(resp.Components) != 2 {
+		t.Errorf("readyz: expected liveness and readiness components, got %v", resp.Components)
+	}
+
+	dbErr = errors.New("connection refused")
+
+	code, resp = do("/readyz")
+	if code != http.StatusServiceUnavailable || resp.Status != healthStatusFail {
+		t.Errorf("readyz with failed postgres: expected %d %q, got %d %q", http.StatusServiceUnavailable, healthStatusFail, code, resp.Status)
+	}
+
+	if c := resp.Components["postgres"]; c.Status != healthStatusFail || c.Error != dbErr.Error() {
+		t.Errorf("expected failed postgres with error, got %+v", c)
+	}
+
+	code, resp = do("/healthz")
+	if code != http.StatusOK || len(resp.Components) != 1 {
+		t.Errorf("healthz: expected %d with liveness components only, got %d %v", http.StatusOK, code, resp.Components)
+	}
+
+	dbErr = nil
+	health.SetShuttingDown()
+
+	if code, resp := do("/readyz"); code != http.StatusServiceUnavailable || resp.Status != healthStatusShuttingDown {
+		t.Errorf("readyz while shutting down: expected %d %q, got %d %q", http.StatusServiceUnavailable, healthStatusShuttingDown, code, resp.Status)
+	}
+
+	if code, _ := do("/healthz"); code != http.StatusOK {
+		t.Errorf("healthz while shutting down: expected %d, got %d", http.StatusOK, code)
+	}
+}
+
+func TestCachedHealthCheck(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		calls   int
+		result  = errors.New("unauthorized")
+		release = make(chan struct{})
+		clock   = fakeclock.New()
+	)
+
+	close(release)
+
+	c := newCachedHealthCheck(func(context.Context) error {
+		mu.Lock()
+		calls++
+		err, wait := result, release
+		mu.Unlock()
+
+		<-wait
+
+		return err
+	}, time.Minute, clock.Now)
+
+	// wait waits for the check running in the background.
+	wait := func() {
+		c.mu.Lock()
+		running := c.running
+		c.mu.Unlock()
+
+		if running != nil {
+			<-running
+		}
+	}
+
+	if err := c.Check(t.Context()); err == nil || err.Error() != "unauthorized" {
+		t.Fatalf("expected the result of the first check, got %v", err)
+	}
+
+	if err := c.Check(t.Context()); err == nil || calls != 1 {
+		t.Errorf("expected the cached result, got %v after %d calls", err, calls)
+	}
+
+	mu.Lock()
+	result = nil
+	release = make(chan struct{})
+	mu.Unlock()
+
+	clock.Advance(time.Minute)
+
+	// The check hangs, probes get the last result and don't start more checks.
+	for range 3 {
+		if err := c.Check(t.Context()); err == nil {
+			t.Error("expected the last result while the check is running")
+		}
+	}
+
+	mu.Lock()
+	close(release)
+	mu.Unlock()
+
+	wait()
+
+	if err := c.Check(t.Context()); err != nil {
+		t.Errorf("expected the new result, got %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
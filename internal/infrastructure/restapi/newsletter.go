@@ -0,0 +1,177 @@
+package restapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type NewsletterSubscriberRepository interface {
+	Subscribe(ctx context.Context, email, token string, resendAfter time.Duration) (models.Subscriber, error)
+	Confirm(ctx context.Context, token string) error
+	Unsubscribe(ctx context.Context, token string) error
+}
+
+type NewsletterConfirmationSender interface {
+	SendConfirmation(ctx context.Context, subscriber models.Subscriber) error
+}
+
+// confirmationResendAfter is the time after which the confirmation is sent to a pending subscriber again.
+const confirmationResendAfter = 10 * time.Minute
+
+// formTemplate asks to confirm the action of the link, the form sends POST to the same link.
+var formTemplate = template.Must(template.New("form").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<p>{{.Question}}</p>
+<form method="post" action="{{.Action}}?token={{.Token}}">
+<button type="submit">{{.Button}}</button>
+</form>
+</body>
+</html>`))
+
+type linkForm struct {
+	Title    string
+	Question string
+	Action   string
+	Button   string
+	Token    string
+}
+
+type subscribeRequest struct {
+	Email string `json:"email"`
+}
+
+type Newsletter struct {
+	repo   NewsletterSubscriberRepository
+	sender NewsletterConfirmationSender
+}
+
+func NewNewsletter(repo NewsletterSubscriberRepository, sender NewsletterConfirmationSender) *Newsletter {
+	return &Newsletter{
+		repo:   repo,
+		sender: sender,
+	}
+}
+
+func (n *Newsletter) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /newsletter/subscribe", n.subscribe)
+	// Links of emails opened with GET only show the form: link scanners of mail providers
+	// must not confirm or unsubscribe anyone. Mail clients send POST for the one-click unsubscribe (RFC 8058).
+	mux.HandleFunc("GET /newsletter/confirm", n.confirmForm)
+	mux.HandleFunc("POST /newsletter/confirm", n.confirm)
+	mux.HandleFunc("GET /newsletter/unsubscribe", n.unsubscribeForm)
+	mux.HandleFunc("POST /newsletter/unsubscribe", n.unsubscribe)
+}
+
+func (n *Newsletter) subscribe(w http.ResponseWriter, r *http.Request) {
+	const op = "restapi.Newsletter.subscribe"
+
+	log := logging.FromContext(r.Context()).With(slog.String("op", op))
+
+	var req subscribeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	addr, err := mail.ParseAddress(req.Email)
+	if err != nil || addr.Name != "" {
+		writeError(w, http.StatusBadRequest, "invalid email")
+		return
+	}
+
+	subscriber, err := n.repo.Subscribe(r.Context(), addr.Address, rand.Text(), confirmationResendAfter)
+	if err != nil {
+		// The response does not reveal whether the email is already subscribed
+		// or the confirmation has been sent recently.
+		if errors.Is(err, models.ErrSubscriberAlreadyExists) {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		log.Error("unable to subscribe", slog.String("err", err.Error()))
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := n.sender.SendConfirmation(r.Context(), subscriber); err != nil {
+		log.Error("unable to send confirmation", slog.String("err", err.Error()))
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (n *Newsletter) confirmForm(w http.ResponseWriter, r *http.Request) {
+	renderForm(w, "restapi.Newsletter.confirmForm", linkForm{
+		Title:    "Подтверждение подписки",
+		Question: "Подтвердить подписку на рассылку?",
+		Action:   "confirm",
+		Button:   "Подтвердить",
+		Token:    r.URL.Query().Get("token"),
+	})
+}
+
+func (n *Newsletter) confirm(w http.ResponseWriter, r *http.Request) {
+	const op = "restapi.Newsletter.confirm"
+
+	if err := n.repo.Confirm(r.Context(), r.URL.Query().Get("token")); err != nil {
+		if errors.Is(err, models.ErrSubscriberNotFound) {
+			http.Error(w, "Ссылка недействительна.", http.StatusNotFound)
+			return
+		}
+
+		slog.Error("unable to confirm", slog.String("op", op), slog.String("err", err.Error()))
+		http.Error(w, "Что-то пошло не так! Попробуйте ещё раз!", http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write([]byte("Подписка подтверждена!"))
+}
+
+func (n *Newsletter) unsubscribeForm(w http.ResponseWriter, r *http.Request) {
+	renderForm(w, "restapi.Newsletter.unsubscribeForm", linkForm{
+		Title:    "Отписка от рассылки",
+		Question: "Отписаться от рассылки?",
+		Action:   "unsubscribe",
+		Button:   "Отписаться",
+		Token:    r.URL.Query().Get("token"),
+	})
+}
+
+func (n *Newsletter) unsubscribe(w http.ResponseWriter, r *http.Request) {
+	const op = "restapi.Newsletter.unsubscribe"
+
+	if err := n.repo.Unsubscribe(r.Context(), r.URL.Query().Get("token")); err != nil {
+		if errors.Is(err, models.ErrSubscriberNotFound) {
+			http.Error(w, "Ссылка недействительна.", http.StatusNotFound)
+			return
+		}
+
+		slog.Error("unable to unsubscribe", slog.String("op", op), slog.String("err", err.Error()))
+		http.Error(w, "Что-то пошло не так! Попробуйте ещё раз!", http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write([]byte("Вы отписались от рассылки."))
+}
+
+func renderForm(w http.ResponseWriter, op string, form linkForm) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if err := formTemplate.Execute(w, form); err != nil {
+		slog.Error("unable to render form", slog.String("op", op), slog.String("err", err.Error()))
+	}
+}
@@ -0,0 +1,140 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type subscriberRepoStub struct {
+	subscribers map[string]models.Subscriber
+}
+
+func (r *subscriberRepoStub) Subscribe(_ context.Context, email, token string, _ time.Duration) (models.Subscriber, error) {
+	if s, ok := r.subscribers[email]; ok && (s.Status == models.SubscriberStatusActive || s.Status == models.SubscriberStatusPending) {
+		return models.Subscriber{}, models.ErrSubscriberAlreadyExists
+	}
+
+	s := models.Subscriber{Email: email, Token: token, Status: models.SubscriberStatusPending}
+	r.subscribers[email] = s
+
+	return s, nil
+}
+
+func (r *subscriberRepoStub) setStatus(token string, from, to models.SubscriberStatus) error {
+	for email, s := range r.subscribers {
+		if s.Token == token && (from == "" || s.Status == from) {
+			s.Status = to
+			r.subscribers[email] = s
+			return nil
+		}
+	}
+
+	return models.ErrSubscriberNotFound
+}
+
+func (r *subscriberRepoStub) Confirm(_ context.Context, token string) error {
+	return r.setStatus(token, models.SubscriberStatusPending, models.SubscriberStatusActive)
+}
+
+func (r *subscriberRepoStub) Unsubscribe(_ context.Context, token string) error {
+	return r.setStatus(token, "", models.SubscriberStatusUnsubscribed)
+}
+
+type confirmationSenderStub struct {
+	sent []models.Subscriber
+}
+
+func (s *confirmationSenderStub) SendConfirmation(_ context.Context, subscriber models.Subscriber) error {
+	s.sent = append(s.sent, subscriber)
+	return nil
+}
+
+func TestNewsletter(t *testing.T) {
+	repo := &subscriberRepoStub{subscribers: make(map[string]models.Subscriber)}
+	sender := &confirmationSenderStub{}
+
+	mux := http.NewServeMux()
+	NewNewsletter(repo, sender).Register(mux)
+
+	do := func(method, target, body string) int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec.Code
+	}
+
+	if code := do(http.MethodPost, "/newsletter/subscribe", `{"email":"not an email"}`); code != http.StatusBadRequest {
+		t.Errorf("invalid email: expected %d, got %d", http.StatusBadRequest, code)
+	}
+
+	if code := do(http.MethodPost, "/newsletter/subscribe", `{"email":"reader@example.com"}`); code != http.StatusAccepted {
+		t.Fatalf("subscribe: expected %d, got %d", http.StatusAccepted, code)
+	}
+
+	if len(sender.sent) != 1 || sender.sent[0].Token == "" {
+		t.Fatalf("confirmation has not been sent: %v", sender.sent)
+	}
+
+	if code := do(http.MethodPost, "/newsletter/subscribe", `{"email":"reader@example.com"}`); code != http.StatusAccepted {
+		t.Errorf("subscribe pending again: expected %d, got %d", http.StatusAccepted, code)
+	}
+
+	if len(sender.sent) != 1 {
+		t.Fatalf("confirmation has been sent again within the cooldown: %v", sender.sent)
+	}
+
+	token := sender.sent[0].Token
+
+	if code := do(http.MethodPost, "/newsletter/confirm?token=wrong", ""); code != http.StatusNotFound {
+		t.Errorf("confirm with wrong token: expected %d, got %d", http.StatusNotFound, code)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/newsletter/confirm?token="+token, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `action="confirm?token=`+token+`"`) {
+		t.Errorf("confirm form: unexpected response %d %q", rec.Code, rec.Body.String())
+	}
+
+	if status := repo.subscribers["reader@example.com"].Status; status != models.SubscriberStatusPending {
+		t.Errorf("expected GET to keep the subscriber pending, got %q", status)
+	}
+
+	if code := do(http.MethodPost, "/newsletter/confirm?token="+token, ""); code != http.StatusOK {
+		t.Errorf("confirm: expected %d, got %d", http.StatusOK, code)
+	}
+
+	if status := repo.subscribers["reader@example.com"].Status; status != models.SubscriberStatusActive {
+		t.Errorf("expected active subscriber, got %q", status)
+	}
+
+	if code := do(http.MethodPost, "/newsletter/subscribe", `{"email":"reader@example.com"}`); code != http.StatusAccepted {
+		t.Errorf("subscribe again: expected %d, got %d", http.StatusAccepted, code)
+	}
+
+	if len(sender.sent) != 1 {
+		t.Errorf("confirmation has been sent to the active subscriber")
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/newsletter/unsubscribe?token="+token, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `action="unsubscribe?token=`+token+`"`) {
+		t.Errorf("unsubscribe form: unexpected response %d %q", rec.Code, rec.Body.String())
+	}
+
+	if status := repo.subscribers["reader@example.com"].Status; status != models.SubscriberStatusActive {
+		t.Errorf("expected GET to keep the subscriber active, got %q", status)
+	}
+
+	if code := do(http.MethodPost, "/newsletter/unsubscribe?token="+token, "List-Unsubscribe=One-Click"); code != http.StatusOK {
+		t.Errorf("unsubscribe: expected %d, got %d", http.StatusOK, code)
+	}
+
+	if status := repo.subscribers["reader@example.com"].Status; status != models.SubscriberStatusUnsubscribed {
+		t.Errorf("expected unsubscribed subscriber, got %q", status)
+	}
+}
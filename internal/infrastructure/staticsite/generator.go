@@ -0,0 +1,355 @@
+// Package staticsite renders published posts into a static blog.
+package staticsite
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type PostRepository interface {
+	FindPublished(ctx context.Context, filters models.PostSearchFilters, offset, limit uint64) ([]models.Post, error)
+}
+
+const (
+	DefaultPageSize = 10
+
+	// fetchLimit is a page size of posts requested from the repository.
+	fetchLimit = 100
+)
+
+var ErrEmptyDir = errors.New("static site dir is empty")
+
+//go:embed themes/default
+var defaultTheme embed.FS
+
+// pages are templates every theme has. Each of them is parsed together with layout.html.
+var pages = []string{"index", "post", "tag", "tags", "archive", "archives"}
+
+type Config struct {
+	// Dir is an output directory.
+	Dir string
+	// Theme is a directory with templates. The default theme is used when it is empty.
+	Theme    string
+	BaseURL  string
+	Title    string
+	PageSize int
+	Location *time.Location
+}
+
+type Generator struct {
+	repo      PostRepository
+	cfg       Config
+	source    models.Source
+	theme     fs.FS
+	templates map[string]*template.Template
+	// mu is shared by copies of the generator, because they write to the same directory.
+	mu *sync.Mutex
+}
+
+func NewGenerator(repo PostRepository, cfg Config) (*Generator, error) {
+	const op = "staticsite.NewGenerator"
+
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("%s: %w", op, ErrEmptyDir)
+	}
+
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = DefaultPageSize
+	}
+
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	var theme fs.FS
+	if cfg.Theme != "" {
+		theme = os.DirFS(cfg.Theme)
+	} else {
+		var err error
+		theme, err = fs.Sub(defaultTheme, "themes/default")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	funcs := template.FuncMap{
+		"date": func(t time.Time) string {
+			return t.In(cfg.Location).Format("02.01.2006 15:04")
+		},
+	}
+
+	templates := make(map[string]*template.Template, len(pages))
+	for _, page := range pages {
+		tmpl, err := template.New(page).Funcs(funcs).ParseFS(theme, "layout.html", page+".html")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		templates[page] = tmpl
+	}
+
+	return &Generator{
+		repo:      repo,
+		cfg:       cfg,
+		source:    models.SourceWebsite,
+		theme:     theme,
+		templates: templates,
+		mu:        &sync.Mutex{},
+	}, nil
+}
+
+// WithSource returns a copy of the generator that renders posts of the source.
+func (g *Generator) WithSource(source models.SourceConfig) (*Generator, error) {
+	generator := *g
+	generator.source = source.Source
+
+	return &generator, nil
+}
+
+// Build renders the whole site. The site is rendered into a temporary directory first
+// and replaces the previous one at the end, so pages of deleted posts do not remain.
+func (g *Generator) Build(ctx context.Context) error {
+	const op = "staticsite.Generator.Build"
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	s, err := g.load(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	parent := filepath.Dir(filepath.Clean(g.cfg.Dir))
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tmpDir, err := os.MkdirTemp(parent, ".staticsite-")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	if err := g.render(s, tmpDir, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.RemoveAll(g.cfg.Dir); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Rename(tmpDir, g.cfg.Dir); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Publish rebuilds pages affected by the published post: the post page, listings
+// of the post, feeds and the sitemap. It implements the publisher of the website source.
+func (g *Generator) Publish(ctx context.Context, post events.PublishedPost) error {
+	const op = "staticsite.Generator.Publish"
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	s, err := g.load(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	i := slices.IndexFunc(s.posts, func(p postView) bool {
+		return p.ID == post.Data.ID
+	})
+	if i == -1 {
+		return fmt.Errorf("%s: %w", op, models.ErrPostNotFound)
+	}
+
+	if err := g.render(s, g.cfg.Dir, &s.posts[i]); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (g *Generator) load(ctx context.Context) (site, error) {
+	var posts []models.Post
+	for offset := uint64(0); ; offset += fetchLimit {
+		batch, err := g.repo.FindPublished(ctx, models.PostSearchFilters{
+			Sources: []string{string(g.source)},
+		}, offset, fetchLimit)
+		if err != nil {
+			if errors.Is(err, models.ErrPostNotFound) {
+				break
+			}
+
+			return site{}, err
+		}
+
+		posts = append(posts, batch...)
+
+		if len(batch) < fetchLimit {
+			break
+		}
+	}
+
+	return newSite(g.cfg, posts), nil
+}
+
+// render writes pages of the site. When only is set, pages that do not list the post are skipped.
+func (g *Generator) render(s site, dir string, only *postView) error {
+	w := writer{dir: dir}
+
+	// A new post shifts the pagination, so index pages are always rendered.
+	for _, p := range s.indexPages() {
+		if err := g.write(w, p.path, "index", p.page); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range s.posts {
+		if only != nil && p.ID != only.ID {
+			continue
+		}
+
+		if err := g.write(w, p.path, "post", s.page(p.Title, func(pg *page) { pg.Post = &p })); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range s.tags {
+		if only != nil && !slices.ContainsFunc(only.Tags, func(pt tagView) bool { return pt.slug == t.slug }) {
+			continue
+		}
+
+		if err := g.write(w, t.path, "tag", s.page(t.Name, func(pg *page) { pg.Posts = s.postsByTag[t.slug] })); err != nil {
+			return err
+		}
+	}
+
+	if err := g.write(w, "tags/index.html", "tags", s.page("Теги", func(pg *page) { pg.Tags = s.tags })); err != nil {
+		return err
+	}
+
+	for _, m := range s.months {
+		if only != nil && m.key != only.month {
+			continue
+		}
+
+		if err := g.write(w, m.path, "archive", s.page(m.Title, func(pg *page) { pg.Posts = s.postsByMonth[m.key] })); err != nil {
+			return err
+		}
+	}
+
+	if err := g.write(w, "archive/index.html", "archives", s.page("Архив", func(pg *page) { pg.Months = s.months })); err != nil {
+		return err
+	}
+
+	files, err := s.feeds()
+	if err != nil {
+		return err
+	}
+
+	sitemap, err := s.sitemap()
+	if err != nil {
+		return err
+	}
+	files["sitemap.xml"] = sitemap
+
+	for name, data := range files {
+		if err := w.write(name, data); err != nil {
+			return err
+		}
+	}
+
+	return g.copyStatic(w)
+}
+
+func (g *Generator) write(w writer, name, tmpl string, p page) error {
+	b := &bytes.Buffer{}
+	if err := g.templates[tmpl].ExecuteTemplate(b, "layout", p); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return w.write(name, b.Bytes())
+}
+
+// copyStatic copies the static directory of the theme, if the theme has it.
+func (g *Generator) copyStatic(w writer) error {
+	err := fs.WalkDir(g.theme, "static", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(g.theme, name)
+		if err != nil {
+			return err
+		}
+
+		return w.write(name, data)
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+type writer struct {
+	dir string
+}
+
+// write replaces the file atomically and skips files with the same content.
+func (w writer) write(name string, data []byte) error {
+	filename := filepath.Join(w.dir, filepath.FromSlash(path.Clean("/"+name)))
+
+	if current, err := os.ReadFile(filename); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(filename), ".tmp-")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), filename)
+}
@@ -0,0 +1,192 @@
+package staticsite
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type postRepoStub struct {
+	posts []models.Post
+}
+
+func (r *postRepoStub) FindPublished(_ context.Context, _ models.PostSearchFilters, offset, limit uint64) ([]models.Post, error) {
+	if offset >= uint64(len(r.posts)) {
+		return nil, models.ErrPostNotFound
+	}
+
+	return r.posts[offset:min(offset+limit, uint64(len(r.posts)))], nil
+}
+
+func testPosts(n int) []models.Post {
+	posts := make([]models.Post, n)
+	for i := range posts {
+		posts[i] = models.Post{
+			ID:          models.PostID(fmt.Sprintf("post%d", n-i)),
+			Title:       fmt.Sprintf("Пост %d", n-i),
+			Content:     "<b>text</b>",
+			PublishDate: time.Date(2025, 12, 20, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -i*10),
+			Tags:        []models.Tag{"Новости дня"},
+			Media:       []models.Media{{URI: "https://example.com/1.png", Filetype: "png"}},
+		}
+	}
+
+	return posts
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatalf("unable to read %s: %q", name, err)
+	}
+
+	return string(b)
+}
+
+func TestGeneratorBuild(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "site")
+
+	repo := &postRepoStub{posts: testPosts(3)}
+
+	generator, err := NewGenerator(repo, Config{
+		Dir:      dir,
+		BaseURL:  "https://example.com/",
+		Title:    "Poster",
+		PageSize: 2,
+	})
+	if err != nil {
+		t.Fatalf("unable to create generator: %q", err)
+	}
+
+	// A stale file of the previous build must be removed.
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "stale.html"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := generator.Build(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "stale.html")); !os.IsNotExist(err) {
+		t.Errorf("stale file has not been removed")
+	}
+
+	tests := []struct {
+		name     string
+		contains []string
+	}{
+		{"index.html", []string{"Пост 3", "Пост 2", `href="https://example.com/page/2/"`}},
+		{"page/2/index.html", []string{"Пост 1", `href="https://example.com/"`}},
+		{"posts/post1/index.html", []string{"<b>text</b>", `<img src="https://example.com/1.png"`}},
+		{"tags/новости-дня/index.html", []string{"Пост 1", "Пост 2", "Пост 3"}},
+		{"tags/index.html", []string{"Новости дня</a> (3)"}},
+		{"archive/2025/12/index.html", []string{"Пост 3", "Пост 2"}},
+		{"archive/index.html", []string{"Декабрь 2025</a> (2)", "Ноябрь 2025</a> (1)"}},
+		{"feed.rss", []string{"<link>https://example.com/posts/post3/</link>"}},
+		{"feed.atom", []string{`href="https://example.com/feed.atom"`}},
+		{"feed.json", []string{`"url": "https://example.com/posts/post1/"`}},
+		{"sitemap.xml", []string{"<loc>https://example.com/posts/post2/</loc>", "<lastmod>2025-12-10</lastmod>"}},
+		{"static/style.css", []string{"body"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := readFile(t, dir, tt.name)
+			for _, c := range tt.contains {
+				if !strings.Contains(content, c) {
+					t.Errorf("%s does not contain %q", tt.name, c)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratorPublish(t *testing.T) {
+	dir := t.TempDir()
+
+	repo := &postRepoStub{posts: testPosts(1)}
+
+	generator, err := NewGenerator(repo, Config{Dir: dir, BaseURL: "https://example.com", Title: "Poster"})
+	if err != nil {
+		t.Fatalf("unable to create generator: %q", err)
+	}
+
+	if err := generator.Build(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	repo.posts = testPosts(2)
+
+	if err := generator.Publish(t.Context(), events.PublishedPost{Data: events.PublishedPostData{ID: "post2"}}); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if content := readFile(t, dir, "posts/post2/index.html"); !strings.Contains(content, "Пост 2") {
+		t.Errorf("post page has not been rendered")
+	}
+
+	if content := readFile(t, dir, "index.html"); !strings.Contains(content, "Пост 2") {
+		t.Errorf("index page has not been updated")
+	}
+
+	if content := readFile(t, dir, "feed.rss"); !strings.Contains(content, "Пост 2") {
+		t.Errorf("feed has not been updated")
+	}
+
+	err = generator.Publish(t.Context(), events.PublishedPost{Data: events.PublishedPostData{ID: "unknown"}})
+	if err == nil {
+		t.Errorf("expected error for unknown post")
+	}
+}
+
+func TestGeneratorTheme(t *testing.T) {
+	theme := t.TempDir()
+
+	files := map[string]string{
+		"layout.html":   `{{define "layout"}}custom {{template "content" .}}{{end}}`,
+		"index.html":    `{{define "content"}}{{range .Posts}}{{.Title}};{{end}}{{end}}`,
+		"post.html":     `{{define "content"}}{{.Post.Title}}{{end}}`,
+		"tag.html":      `{{define "content"}}{{.Title}}{{end}}`,
+		"tags.html":     `{{define "content"}}tags{{end}}`,
+		"archive.html":  `{{define "content"}}{{.Title}}{{end}}`,
+		"archives.html": `{{define "content"}}archives{{end}}`,
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(theme, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := filepath.Join(t.TempDir(), "site")
+
+	generator, err := NewGenerator(&postRepoStub{posts: testPosts(2)}, Config{Dir: dir, Theme: theme})
+	if err != nil {
+		t.Fatalf("unable to create generator: %q", err)
+	}
+
+	if err := generator.Build(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if content := readFile(t, dir, "index.html"); content != "custom Пост 2;Пост 1;" {
+		t.Errorf("unexpected index page %q", content)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "static")); !os.IsNotExist(err) {
+		t.Errorf("theme without static files must not have static dir")
+	}
+}
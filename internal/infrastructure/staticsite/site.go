@@ -0,0 +1,366 @@
+package staticsite
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/feed"
+	"github.com/kostromin59/poster/pkg/richtext"
+)
+
+// feedLimit is a number of the latest posts in feeds.
+const feedLimit = 50
+
+var monthNames = [...]string{
+	"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь",
+	"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь",
+}
+
+// siteInfo is available in templates as .Site.
+type siteInfo struct {
+	Title   string
+	BaseURL string
+}
+
+// URL returns the absolute URL of the path on the site.
+func (s siteInfo) URL(path string) string {
+	return s.BaseURL + path
+}
+
+// page is the data of every template.
+type page struct {
+	Site       siteInfo
+	Title      string
+	Posts      []postView
+	Post       *postView
+	Pagination *pagination
+	Tags       []tagView
+	Months     []monthView
+}
+
+type postView struct {
+	ID          string
+	Title       string
+	URL         string
+	Content     template.HTML
+	PublishDate time.Time
+	Category    string
+	Tags        []tagView
+	Media       []mediaView
+
+	path  string
+	month string
+}
+
+type tagView struct {
+	Name  string
+	URL   string
+	Count int
+
+	slug string
+	path string
+}
+
+type monthView struct {
+	Title string
+	URL   string
+	Count int
+
+	key  string
+	path string
+}
+
+type mediaView struct {
+	URL     string
+	Type    string
+	IsImage bool
+}
+
+type pagination struct {
+	Page    int
+	Total   int
+	PrevURL string
+	NextURL string
+}
+
+type indexPage struct {
+	path string
+	page page
+}
+
+type site struct {
+	info         siteInfo
+	pageSize     int
+	posts        []postView
+	tags         []tagView
+	postsByTag   map[string][]postView
+	months       []monthView
+	postsByMonth map[string][]postView
+}
+
+// newSite groups posts by tags and months. Posts are sorted by the publish date descending.
+func newSite(cfg Config, posts []models.Post) site {
+	s := site{
+		info:         siteInfo{Title: cfg.Title, BaseURL: cfg.BaseURL},
+		pageSize:     cfg.PageSize,
+		posts:        make([]postView, len(posts)),
+		postsByTag:   make(map[string][]postView),
+		postsByMonth: make(map[string][]postView),
+	}
+
+	tagIndex := make(map[string]int)
+	monthIndex := make(map[string]int)
+
+	for i, p := range posts {
+		publishDate := p.PublishDate.In(cfg.Location)
+
+		view := postView{
+			ID:          string(p.ID),
+			Title:       p.Title,
+			URL:         s.info.URL("/posts/" + string(p.ID) + "/"),
+			Content:     template.HTML(richtext.ToHTML(p.Content)),
+			PublishDate: publishDate,
+			Tags:        make([]tagView, 0, len(p.Tags)),
+			Media:       make([]mediaView, len(p.Media)),
+			path:        "posts/" + string(p.ID) + "/index.html",
+			month:       publishDate.Format("2006/01"),
+		}
+
+		if p.Category != nil {
+			view.Category = p.Category.Title
+		}
+
+		for j, m := range p.Media {
+			mediaType := feed.MediaType(m.Filetype, m.URI)
+			view.Media[j] = mediaView{
+				URL:     m.URI,
+				Type:    mediaType,
+				IsImage: strings.HasPrefix(mediaType, "image/"),
+			}
+		}
+
+		for _, t := range p.Tags {
+			slug := slugify(string(t))
+
+			j, ok := tagIndex[slug]
+			if !ok {
+				j = len(s.tags)
+				tagIndex[slug] = j
+				s.tags = append(s.tags, tagView{
+					Name: string(t),
+					URL:  s.info.URL("/tags/" + slug + "/"),
+					slug: slug,
+					path: "tags/" + slug + "/index.html",
+				})
+			}
+
+			s.tags[j].Count++
+			view.Tags = append(view.Tags, s.tags[j])
+		}
+
+		j, ok := monthIndex[view.month]
+		if !ok {
+			j = len(s.months)
+			monthIndex[view.month] = j
+			s.months = append(s.months, monthView{
+				Title: fmt.Sprintf("%s %d", monthNames[publishDate.Month()-1], publishDate.Year()),
+				URL:   s.info.URL("/archive/" + view.month + "/"),
+				key:   view.month,
+				path:  "archive/" + view.month + "/index.html",
+			})
+		}
+
+		s.months[j].Count++
+
+		s.posts[i] = view
+	}
+
+	for _, p := range s.posts {
+		for _, t := range p.Tags {
+			s.postsByTag[t.slug] = append(s.postsByTag[t.slug], p)
+		}
+
+		s.postsByMonth[p.month] = append(s.postsByMonth[p.month], p)
+	}
+
+	return s
+}
+
+func (s site) page(title string, fill func(p *page)) page {
+	p := page{
+		Site:  s.info,
+		Title: title,
+	}
+
+	fill(&p)
+
+	return p
+}
+
+// indexPages splits posts into pages: the first page is the site root, others are /page/N/.
+func (s site) indexPages() []indexPage {
+	total := max(1, (len(s.posts)+s.pageSize-1)/s.pageSize)
+
+	pageURL := func(n int) string {
+		if n == 1 {
+			return s.info.URL("/")
+		}
+
+		return s.info.URL(fmt.Sprintf("/page/%d/", n))
+	}
+
+	pages := make([]indexPage, total)
+	for i := range pages {
+		n := i + 1
+
+		p := indexPage{
+			path: fmt.Sprintf("page/%d/index.html", n),
+			page: page{
+				Site:       s.info,
+				Posts:      s.posts[min(i*s.pageSize, len(s.posts)):min(n*s.pageSize, len(s.posts))],
+				Pagination: &pagination{Page: n, Total: total},
+			},
+		}
+
+		if n == 1 {
+			p.path = "index.html"
+		}
+
+		if n > 1 {
+			p.page.Pagination.PrevURL = pageURL(n - 1)
+		}
+
+		if n < total {
+			p.page.Pagination.NextURL = pageURL(n + 1)
+		}
+
+		pages[i] = p
+	}
+
+	return pages
+}
+
+// feeds renders feeds of the latest posts by file names.
+func (s site) feeds() (map[string][]byte, error) {
+	latest := s.posts[:min(feedLimit, len(s.posts))]
+
+	f := feed.Feed{
+		Title: s.info.Title,
+		Link:  s.info.URL("/"),
+		Items: make([]feed.Item, len(latest)),
+	}
+
+	if len(latest) != 0 {
+		f.Updated = latest[0].PublishDate
+	}
+
+	for i, p := range latest {
+		item := feed.Item{
+			ID:          p.URL,
+			Title:       p.Title,
+			Link:        p.URL,
+			ContentHTML: string(p.Content),
+			Published:   p.PublishDate,
+			Tags:        make([]string, len(p.Tags)),
+			Enclosures:  make([]feed.Enclosure, len(p.Media)),
+		}
+
+		for j, t := range p.Tags {
+			item.Tags[j] = t.Name
+		}
+
+		for j, m := range p.Media {
+			item.Enclosures[j] = feed.Enclosure{URL: m.URL, Type: m.Type}
+		}
+
+		f.Items[i] = item
+	}
+
+	renderers := map[string]func(feed.Feed) ([]byte, error){
+		"feed.rss":  feed.RSS,
+		"feed.atom": feed.Atom,
+		"feed.json": feed.JSON,
+	}
+
+	files := make(map[string][]byte, len(renderers))
+	for name, render := range renderers {
+		f.FeedLink = s.info.URL("/" + name)
+
+		data, err := render(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		files[name] = data
+	}
+
+	return files, nil
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+func (s site) sitemap() ([]byte, error) {
+	urls := []sitemapURL{{Loc: s.info.URL("/")}}
+
+	if len(s.posts) != 0 {
+		urls[0].LastMod = s.posts[0].PublishDate.Format(time.DateOnly)
+	}
+
+	for _, p := range s.posts {
+		urls = append(urls, sitemapURL{Loc: p.URL, LastMod: p.PublishDate.Format(time.DateOnly)})
+	}
+
+	for _, t := range s.tags {
+		urls = append(urls, sitemapURL{Loc: t.URL, LastMod: s.postsByTag[t.slug][0].PublishDate.Format(time.DateOnly)})
+	}
+
+	for _, m := range s.months {
+		urls = append(urls, sitemapURL{Loc: m.URL, LastMod: s.postsByMonth[m.key][0].PublishDate.Format(time.DateOnly)})
+	}
+
+	b, err := xml.MarshalIndent(sitemapURLSet{URLs: urls}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), b...), nil
+}
+
+// slugify makes a path segment of the tag: letters and digits are lowercased, other characters become dashes.
+func slugify(s string) string {
+	b := &strings.Builder{}
+
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+
+		if !dash && b.Len() != 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		return "tag"
+	}
+
+	return slug
+}
@@ -1,24 +1,30 @@
 package tgbot
 
-import "gopkg.in/telebot.v4"
-
 type CheckboxKeyboardItem struct {
 	Value      string
 	Label      string
 	IsSelected bool
 }
 
-func CheckboxButtons(kb *telebot.ReplyMarkup, action string, items []CheckboxKeyboardItem) []telebot.Row {
-	buttons := make([]telebot.Row, len(items))
+// CheckboxWizardButtons returns buttons of the items, selected ones are marked.
+func CheckboxWizardButtons(action string, items []CheckboxKeyboardItem) []WizardButton {
+	buttons := make([]WizardButton, len(items))
 	for i, item := range items {
 		text := item.Label
 		if item.IsSelected {
 			text = "✅ " + text
 		}
 
-		btn := kb.Data(text, action, item.Value)
-		buttons[i] = kb.Row(btn)
+		buttons[i] = WizardButton{Label: text, Action: action, Value: item.Value}
 	}
 
 	return buttons
 }
+
+func toggleCheckbox(items []CheckboxKeyboardItem, value string) {
+	for i, item := range items {
+		if item.Value == value {
+			items[i].IsSelected = !item.IsSelected
+		}
+	}
+}
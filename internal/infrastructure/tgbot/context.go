@@ -2,8 +2,10 @@ package tgbot
 
 import (
 	"context"
+	"log/slog"
 	"time"
 
+	"github.com/kostromin59/poster/internal/logging"
 	"gopkg.in/telebot.v4"
 )
 
@@ -15,6 +17,11 @@ func ContextMiddleware() telebot.MiddlewareFunc {
 			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 			defer cancel()
 
+			ctx = logging.With(ctx, slog.Int(logging.UpdateIDKey, c.Update().ID))
+			if sender := c.Sender(); sender != nil {
+				ctx = logging.With(ctx, slog.Int64(logging.UserIDKey, sender.ID))
+			}
+
 			c.Set(ContextKey, ctx)
 
 			return next(c)
@@ -0,0 +1,78 @@
+package tgbot
+
+import (
+	"strings"
+
+	"gopkg.in/telebot.v4"
+)
+
+// Conversation handles text answers of its steps, the step is kept as "<name>:<step>".
+type Conversation interface {
+	Name() string
+	HandleText(c telebot.Context) error
+}
+
+// FileConversation handles files sent at its steps, e.g. media of a post.
+type FileConversation interface {
+	Conversation
+	HandleFile(c telebot.Context) error
+}
+
+// Conversations routes text messages to the handler of the current step of the user,
+// so the order of handlers doesn't matter.
+type Conversations struct {
+	step          Step
+	conversations map[string]Conversation
+	handlers      map[string]telebot.HandlerFunc
+}
+
+func NewConversations(step Step) *Conversations {
+	return &Conversations{
+		step:          step,
+		conversations: make(map[string]Conversation),
+		handlers:      make(map[string]telebot.HandlerFunc),
+	}
+}
+
+// Add adds the conversation, e.g. a wizard.
+func (cs *Conversations) Add(conversation Conversation) {
+	cs.conversations[conversation.Name()] = conversation
+}
+
+// Handle adds the handler of the single step.
+func (cs *Conversations) Handle(step string, handler telebot.HandlerFunc) {
+	cs.handlers[step] = handler
+}
+
+// Handler is the handler of text messages.
+func (cs *Conversations) Handler() telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		step := cs.step.Get(c.Sender().ID)
+		if step == "" {
+			return nil
+		}
+
+		if handler, ok := cs.handlers[step]; ok {
+			return handler(c)
+		}
+
+		name, _, _ := strings.Cut(step, ":")
+		if conversation, ok := cs.conversations[name]; ok {
+			return conversation.HandleText(c)
+		}
+
+		return nil
+	}
+}
+
+// FileHandler is the handler of photos, videos and documents. Only conversations accept files.
+func (cs *Conversations) FileHandler() telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		name, _, _ := strings.Cut(cs.step.Get(c.Sender().ID), ":")
+		if conversation, ok := cs.conversations[name].(FileConversation); ok {
+			return conversation.HandleFile(c)
+		}
+
+		return nil
+	}
+}
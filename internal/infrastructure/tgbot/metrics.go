@@ -0,0 +1,59 @@
+package tgbot
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kostromin59/poster/internal/metrics"
+)
+
+type metricsTransport struct {
+	next http.RoundTripper
+}
+
+// NewMetricsTransport counts Telegram Bot API calls by method and status and observes their duration.
+// It is used as the transport of the bot client.
+func NewMetricsTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &metricsTransport{
+		next: next,
+	}
+}
+
+func (t *metricsTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	method := apiMethod(r.URL.Path)
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(r)
+	metrics.TelegramAPIDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	metrics.TelegramAPICalls.WithLabelValues(method, status).Inc()
+
+	return resp, err
+}
+
+// apiMethod returns the method of the API path (/bot<token>/<method>), file downloads
+// (/file/bot<token>/<path>) are labeled as "file". The token never gets into labels.
+func apiMethod(path string) string {
+	path = strings.TrimPrefix(path, "/")
+
+	if strings.HasPrefix(path, "file/") {
+		return "file"
+	}
+
+	_, method, ok := strings.Cut(path, "/")
+	if !ok || method == "" {
+		return "unknown"
+	}
+
+	return method
+}
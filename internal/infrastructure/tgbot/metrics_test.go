@@ -0,0 +1,19 @@
+package tgbot
+
+import "testing"
+
+func TestAPIMethod(t *testing.T) {
+	tests := map[string]string{
+		"/bot123:secret/sendMessage":       "sendMessage",
+		"/bot123:secret/getUpdates":        "getUpdates",
+		"/file/bot123:secret/photos/1.jpg": "file",
+		"/bot123:secret":                   "unknown",
+		"/":                                "unknown",
+	}
+
+	for path, expected := range tests {
+		if method := apiMethod(path); method != expected {
+			t.Errorf("%s: expected %q but got %q", path, expected, method)
+		}
+	}
+}
@@ -0,0 +1,759 @@
+package tgbot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+type CreatePostTagRepository interface {
+	FindAll(ctx context.Context) ([]models.Tag, error)
+}
+
+type CreatePostSourceRepository interface {
+	FindAll(ctx context.Context) ([]models.Source, error)
+	FindAllConfigs(ctx context.Context) ([]models.SourceConfig, error)
+}
+
+type CreatePostTelegramChannelRepository interface {
+	FindAll(ctx context.Context) ([]models.TelegramChannel, error)
+}
+
+type CreatePostCategoryRepository interface {
+	FindAll(ctx context.Context) ([]models.Category, error)
+}
+
+type CreatePostMediaRepository interface {
+	Create(ctx context.Context, filetype, uri string) (models.Media, error)
+}
+
+// CreatePostMediaStorage stores files sent to the bot and returns their URI.
+type CreatePostMediaStorage interface {
+	Save(ctx context.Context, name string, r io.Reader) (string, error)
+}
+
+// fileDownloader downloads files sent to the bot, it is implemented by telebot.Bot.
+type fileDownloader interface {
+	File(file *telebot.File) (io.ReadCloser, error)
+}
+
+// CreatePostPreviewer renders the post as the telegram publisher sends it.
+type CreatePostPreviewer interface {
+	Preview(ctx context.Context, post events.PublishedPost) (PostMessage, error)
+}
+
+// CreatePostPreviewerFactory creates a previewer of the telegram source.
+type CreatePostPreviewerFactory func(source models.SourceConfig) (CreatePostPreviewer, error)
+
+// CreatePostState is the data of the post form. It is used to create and to edit posts.
+type CreatePostState struct {
+	// ID is the edited post, it is empty for a new post.
+	ID                       string
+	Title                    string
+	Content                  string
+	CheckboxTags             []CheckboxKeyboardItem
+	Tags                     []string
+	CheckboxSources          []CheckboxKeyboardItem
+	CheckboxTelegramChannels []CheckboxKeyboardItem
+	CheckboxMedia            []CheckboxKeyboardItem
+	// Media are all media of the post and the uploaded files, the selected ones are shown in the preview.
+	Media       []models.Media
+	Category    string
+	PublishDate time.Time
+}
+
+const (
+	actionToggle         = "toggle"
+	actionSelectCategory = "category"
+	actionConfirm        = "confirm"
+	actionEdit           = "edit"
+	actionTestCopy       = "test"
+)
+
+// maxDownloadSize is the size of files which bots can download with the Bot API.
+const maxDownloadSize = 20 << 20
+
+// postForm is the steps of the post wizards: title, content, media, tags,
+// sources, telegram channels if a telegram source is selected, category if there are categories,
+// publish date and the preview. The post is saved after the preview is confirmed.
+type postForm struct {
+	files               fileDownloader
+	mediaRepo           CreatePostMediaRepository
+	mediaStorage        CreatePostMediaStorage
+	tagRepo             CreatePostTagRepository
+	sourceRepo          CreatePostSourceRepository
+	telegramChannelRepo CreatePostTelegramChannelRepository
+	categoryRepo        CreatePostCategoryRepository
+	newPreviewer        CreatePostPreviewerFactory
+	loc                 *time.Location
+}
+
+func newPostForm(
+	files fileDownloader,
+	mediaRepo CreatePostMediaRepository,
+	mediaStorage CreatePostMediaStorage,
+	tagRepo CreatePostTagRepository,
+	sourceRepo CreatePostSourceRepository,
+	telegramChannelRepo CreatePostTelegramChannelRepository,
+	categoryRepo CreatePostCategoryRepository,
+	newPreviewer CreatePostPreviewerFactory,
+	loc *time.Location,
+) *postForm {
+	return &postForm{
+		files:               files,
+		mediaRepo:           mediaRepo,
+		mediaStorage:        mediaStorage,
+		tagRepo:             tagRepo,
+		sourceRepo:          sourceRepo,
+		telegramChannelRepo: telegramChannelRepo,
+		categoryRepo:        categoryRepo,
+		newPreviewer:        newPreviewer,
+		loc:                 loc,
+	}
+}
+
+func (f *postForm) steps() []WizardStep[CreatePostState] {
+	return []WizardStep[CreatePostState]{
+		{
+			Name:   StepAwaitingTitle,
+			Prompt: textPrompt[CreatePostState]("Введите заголовок:"),
+			Text: func(_ context.Context, dto *CreatePostState, text string) error {
+				if text == "" {
+					return NewValidationError("Заголовок не может быть пустым!")
+				}
+
+				dto.Title = text
+				return nil
+			},
+		},
+		{
+			Name:   StepAwaitingContent,
+			Prompt: textPrompt[CreatePostState]("Введите содержание:"),
+			Text: func(_ context.Context, dto *CreatePostState, text string) error {
+				if text == "" {
+					return NewValidationError("Содержание не может быть пустым!")
+				}
+
+				dto.Content = text
+				return nil
+			},
+		},
+		{
+			Name:    StepAwaitingMedia,
+			Prompt:  f.mediaPrompt,
+			Buttons: []string{NextStepButton},
+			Text:    nextStepText[CreatePostState]("Отправьте фото, видео или файл. Когда медиафайлы готовы, нажмите кнопку «Продолжить»"),
+			File:    f.uploadMedia,
+			Callbacks: map[string]WizardCallback[CreatePostState]{
+				actionToggle: func(_ context.Context, _ telebot.Context, dto *CreatePostState, value string) (bool, error) {
+					toggleCheckbox(dto.CheckboxMedia, value)
+					return false, nil
+				},
+			},
+		},
+		{
+			Name:    StepAwaitingTags,
+			Prompt:  f.tagsPrompt,
+			Buttons: []string{NextStepButton},
+			Text: func(_ context.Context, dto *CreatePostState, text string) error {
+				if text == NextStepButton {
+					return nil
+				}
+
+				dto.Tags = splitTags(text)
+				return nil
+			},
+			Callbacks: map[string]WizardCallback[CreatePostState]{
+				actionToggle: func(_ context.Context, _ telebot.Context, dto *CreatePostState, value string) (bool, error) {
+					toggleCheckbox(dto.CheckboxTags, value)
+					return false, nil
+				},
+			},
+		},
+		{
+			Name:    StepAwaitingSources,
+			Prompt:  f.sourcesPrompt,
+			Buttons: []string{NextStepButton},
+			Text:    nextStepText[CreatePostState]("Источники можно выбрать только из кнопок. После выбора нажмите кнопку «Продолжить»"),
+			Callbacks: map[string]WizardCallback[CreatePostState]{
+				actionToggle: func(_ context.Context, _ telebot.Context, dto *CreatePostState, value string) (bool, error) {
+					toggleCheckbox(dto.CheckboxSources, value)
+					return false, nil
+				},
+			},
+			Next: f.afterSources,
+		},
+		{
+			Name:    StepAwaitingTelegramChannels,
+			Prompt:  f.telegramChannelsPrompt,
+			Buttons: []string{NextStepButton},
+			Text:    nextStepText[CreatePostState]("Каналы можно выбрать только из кнопок. После выбора нажмите кнопку «Продолжить»"),
+			Callbacks: map[string]WizardCallback[CreatePostState]{
+				actionToggle: func(_ context.Context, _ telebot.Context, dto *CreatePostState, value string) (bool, error) {
+					toggleCheckbox(dto.CheckboxTelegramChannels, value)
+					return false, nil
+				},
+			},
+		},
+		{
+			Name:   StepAwaitingCategory,
+			Prompt: f.categoriesPrompt,
+			Callbacks: map[string]WizardCallback[CreatePostState]{
+				actionSelectCategory: func(_ context.Context, _ telebot.Context, dto *CreatePostState, value string) (bool, error) {
+					dto.Category = value
+					return true, nil
+				},
+			},
+		},
+		{
+			Name:   StepAwaitingPublishDate,
+			Prompt: textPrompt[CreatePostState]("Введите дату публикации в формате 2006-01-02 15:04"),
+			Text: func(_ context.Context, dto *CreatePostState, text string) error {
+				publishDate, err := time.ParseInLocation("2006-01-02 15:04", text, f.loc)
+				if err != nil {
+					return NewValidationError("Не удалось разобрать дату публикации!")
+				}
+
+				dto.PublishDate = publishDate
+				return nil
+			},
+		},
+		{
+			Name:   StepAwaitingConfirmation,
+			Prompt: f.confirmationPrompt,
+			Callbacks: map[string]WizardCallback[CreatePostState]{
+				actionConfirm: func(context.Context, telebot.Context, *CreatePostState, string) (bool, error) {
+					return true, nil
+				},
+				actionEdit: func(_ context.Context, _ telebot.Context, _ *CreatePostState, value string) (bool, error) {
+					return false, EditStep(value)
+				},
+				actionTestCopy: func(ctx context.Context, c telebot.Context, dto *CreatePostState, _ string) (bool, error) {
+					msg, err := f.preview(ctx, *dto)
+					if err != nil {
+						return false, err
+					}
+
+					// The copy is sent to the chat of the editor with the bot, without buttons.
+					return false, SendPostMessage(c, msg)
+				},
+			},
+		},
+	}
+}
+
+func (f *postForm) tagsPrompt(ctx context.Context, dto *CreatePostState) (WizardPrompt, error) {
+	// Tags are loaded once, so the selection is kept when the user comes back.
+	if dto.CheckboxTags == nil {
+		tags, err := f.tagRepo.FindAll(ctx)
+		if err != nil && !errors.Is(err, models.ErrTagNotFound) {
+			return WizardPrompt{}, err
+		}
+
+		dto.CheckboxTags = make([]CheckboxKeyboardItem, len(tags))
+		for i, tag := range tags {
+			dto.CheckboxTags[i] = CheckboxKeyboardItem{Value: string(tag), Label: string(tag)}
+		}
+	}
+
+	return WizardPrompt{
+		Hint:    "Напишите теги через запятую, если не хватает в списке. Затем нажмите кнопку «Продолжить»",
+		Text:    "Добавьте уже существующие теги:",
+		Buttons: CheckboxWizardButtons(actionToggle, dto.CheckboxTags),
+	}, nil
+}
+
+func (f *postForm) sourcesPrompt(ctx context.Context, dto *CreatePostState) (WizardPrompt, error) {
+	if dto.CheckboxSources == nil {
+		sources, err := f.sourceRepo.FindAll(ctx)
+		if err != nil && !errors.Is(err, models.ErrSourceNotFound) {
+			return WizardPrompt{}, err
+		}
+
+		dto.CheckboxSources = make([]CheckboxKeyboardItem, len(sources))
+		for i, s := range sources {
+			dto.CheckboxSources[i] = CheckboxKeyboardItem{Value: string(s), Label: string(s)}
+		}
+	}
+
+	return WizardPrompt{
+		Hint:    "Источники можно выбрать только из кнопок. После выбора нажмите кнопку «Продолжить»",
+		Text:    "Выберите источники:",
+		Buttons: CheckboxWizardButtons(actionToggle, dto.CheckboxSources),
+	}, nil
+}
+
+// afterSources asks for telegram channels only if a telegram source is selected.
+func (f *postForm) afterSources(ctx context.Context, dto *CreatePostState) (string, error) {
+	_, ok, err := f.telegramSource(ctx, *dto)
+	if err != nil {
+		return "", err
+	}
+
+	if ok {
+		return StepAwaitingTelegramChannels, nil
+	}
+
+	return StepAwaitingCategory, nil
+}
+
+// telegramSource returns the first selected telegram source.
+func (f *postForm) telegramSource(ctx context.Context, dto CreatePostState) (models.SourceConfig, bool, error) {
+	sources, err := f.sourceRepo.FindAllConfigs(ctx)
+	if err != nil && !errors.Is(err, models.ErrSourceNotFound) {
+		return models.SourceConfig{}, false, err
+	}
+
+	for _, s := range sources {
+		if s.Type != models.SourceTypeTelegram {
+			continue
+		}
+
+		for _, cs := range dto.CheckboxSources {
+			if cs.IsSelected && cs.Value == string(s.Source) {
+				return s, true, nil
+			}
+		}
+	}
+
+	return models.SourceConfig{}, false, nil
+}
+
+func (f *postForm) telegramChannelsPrompt(ctx context.Context, dto *CreatePostState) (WizardPrompt, error) {
+	if dto.CheckboxTelegramChannels == nil {
+		channels, err := f.telegramChannelRepo.FindAll(ctx)
+		if err != nil && !errors.Is(err, models.ErrTelegramChannelNotFound) {
+			return WizardPrompt{}, err
+		}
+
+		dto.CheckboxTelegramChannels = make([]CheckboxKeyboardItem, len(channels))
+		for i, tc := range channels {
+			dto.CheckboxTelegramChannels[i] = CheckboxKeyboardItem{Value: string(tc.ID), Label: tc.Title}
+		}
+	}
+
+	if len(dto.CheckboxTelegramChannels) == 0 {
+		return WizardPrompt{}, ErrSkipStep
+	}
+
+	return WizardPrompt{
+		Hint:    "Каналы можно выбрать только из кнопок. Если не выбрать ни одного, пост уйдёт в основной канал. После выбора нажмите кнопку «Продолжить»",
+		Text:    "Выберите каналы в Telegram:",
+		Buttons: CheckboxWizardButtons(actionToggle, dto.CheckboxTelegramChannels),
+	}, nil
+}
+
+func (f *postForm) categoriesPrompt(ctx context.Context, _ *CreatePostState) (WizardPrompt, error) {
+	categories, err := f.categoryRepo.FindAll(ctx)
+	if err != nil && !errors.Is(err, models.ErrCategoryNotFound) {
+		return WizardPrompt{}, err
+	}
+
+	if len(categories) == 0 {
+		return WizardPrompt{}, ErrSkipStep
+	}
+
+	buttons := make([]WizardButton, 0, len(categories)+1)
+	for _, category := range categories {
+		label := strings.Repeat("— ", categoryDepth(categories, category)) + category.Title
+		buttons = append(buttons, WizardButton{Label: label, Action: actionSelectCategory, Value: string(category.ID)})
+	}
+	buttons = append(buttons, WizardButton{Label: "Без категории", Action: actionSelectCategory})
+
+	return WizardPrompt{
+		Hint:    "Категорию можно выбрать только из кнопок.",
+		Text:    "Выберите категорию:",
+		Buttons: buttons,
+	}, nil
+}
+
+func (f *postForm) confirmationPrompt(ctx context.Context, dto *CreatePostState) (WizardPrompt, error) {
+	msg, err := f.preview(ctx, *dto)
+	if err != nil {
+		return WizardPrompt{}, err
+	}
+
+	buttons := []WizardButton{
+		{Label: "Сохранить", Action: actionConfirm},
+		{Label: "Отправить тестовую копию", Action: actionTestCopy},
+		{Label: "Изменить заголовок", Action: actionEdit, Value: StepAwaitingTitle},
+		{Label: "Изменить содержание", Action: actionEdit, Value: StepAwaitingContent},
+	}
+
+	buttons = append(buttons,
+		WizardButton{Label: "Изменить медиа", Action: actionEdit, Value: StepAwaitingMedia},
+		WizardButton{Label: "Изменить теги", Action: actionEdit, Value: StepAwaitingTags},
+		WizardButton{Label: "Изменить источники", Action: actionEdit, Value: StepAwaitingSources},
+	)
+
+	if len(dto.CheckboxTelegramChannels) != 0 {
+		buttons = append(buttons, WizardButton{Label: "Изменить каналы", Action: actionEdit, Value: StepAwaitingTelegramChannels})
+	}
+
+	categories, err := f.categoryRepo.FindAll(ctx)
+	if err != nil && !errors.Is(err, models.ErrCategoryNotFound) {
+		return WizardPrompt{}, err
+	}
+
+	if len(categories) != 0 {
+		buttons = append(buttons, WizardButton{Label: "Изменить категорию", Action: actionEdit, Value: StepAwaitingCategory})
+	}
+
+	buttons = append(buttons, WizardButton{Label: "Изменить дату публикации", Action: actionEdit, Value: StepAwaitingPublishDate})
+
+	return WizardPrompt{
+		Hint:    "Пост будет опубликован " + dto.PublishDate.In(f.loc).Format("2006-01-02 15:04") + ". Так он будет выглядеть в Telegram:",
+		Text:    msg.Text,
+		Media:   msg.Media,
+		Buttons: buttons,
+	}, nil
+}
+
+// preview renders the post with the template, the tags, the footer and the media of the telegram publisher.
+// The default telegram source is used if no telegram source is selected.
+func (f *postForm) preview(ctx context.Context, dto CreatePostState) (PostMessage, error) {
+	source, ok, err := f.telegramSource(ctx, dto)
+	if err != nil {
+		return PostMessage{}, err
+	}
+
+	if !ok {
+		source = models.SourceConfig{Source: models.SourceTG, Type: models.SourceTypeTelegram}
+	}
+
+	previewer, err := f.newPreviewer(source)
+	if err != nil {
+		return PostMessage{}, err
+	}
+
+	post := createPostDTO(dto)
+
+	data := events.PublishedPostData{
+		Title:            post.Title,
+		Content:          post.Content,
+		PublishDate:      post.PublishDate,
+		Tags:             make([]string, len(post.Tags)),
+		Sources:          make([]string, len(post.Sources)),
+		TelegramChannels: make([]string, len(post.TelegramChannels)),
+	}
+
+	for i, tag := range post.Tags {
+		data.Tags[i] = string(tag)
+	}
+
+	for i, s := range post.Sources {
+		data.Sources[i] = string(s)
+	}
+
+	for i, tc := range post.TelegramChannels {
+		data.TelegramChannels[i] = string(tc)
+	}
+
+	for _, m := range dto.Media {
+		if slices.Contains(post.Media, m.ID) {
+			data.Media = append(data.Media, events.PublishedPostMedia{ID: string(m.ID), Filetype: m.Filetype, URI: m.URI})
+		}
+	}
+
+	if post.Category != nil {
+		categories, err := f.categoryRepo.FindAll(ctx)
+		if err != nil && !errors.Is(err, models.ErrCategoryNotFound) {
+			return PostMessage{}, err
+		}
+
+		for _, category := range categories {
+			if category.ID == *post.Category {
+				data.Category = &events.PublishedPostCategory{ID: string(category.ID), Slug: category.Slug, Title: category.Title}
+				break
+			}
+		}
+	}
+
+	return previewer.Preview(ctx, events.PublishedPost{Data: data})
+}
+
+func (f *postForm) mediaPrompt(_ context.Context, dto *CreatePostState) (WizardPrompt, error) {
+	hint := "Отправьте фото, видео или файлы по одному или альбомом. Снимите отметку с медиафайлов, которые нужно убрать из поста. Затем нажмите кнопку «Продолжить»"
+
+	if len(dto.CheckboxMedia) == 0 {
+		return WizardPrompt{Hint: hint, Text: "Медиафайлов пока нет."}, nil
+	}
+
+	return WizardPrompt{
+		Hint:    hint,
+		Text:    "Медиафайлы поста:",
+		Buttons: CheckboxWizardButtons(actionToggle, dto.CheckboxMedia),
+	}, nil
+}
+
+// uploadMedia downloads the photo, the video or the document of the message into the storage
+// and adds it to the selected media of the post.
+func (f *postForm) uploadMedia(ctx context.Context, c telebot.Context, dto *CreatePostState) error {
+	file, filename, filetype := messageFile(c.Message())
+	if file == nil {
+		return NewValidationError("Отправьте фото, видео или файл.")
+	}
+
+	if file.FileSize > maxDownloadSize {
+		return NewValidationError("Файл больше 20 МБ, бот не может его скачать.")
+	}
+
+	r, err := f.files.File(file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	uri, err := f.mediaStorage.Save(ctx, path.Join("bot", file.UniqueID, filename), r)
+	if err != nil {
+		return err
+	}
+
+	media, err := f.mediaRepo.Create(ctx, filetype, uri)
+	if err != nil {
+		return err
+	}
+
+	dto.Media = append(dto.Media, media)
+	dto.CheckboxMedia = append(dto.CheckboxMedia, CheckboxKeyboardItem{Value: string(media.ID), Label: filename, IsSelected: true})
+
+	return nil
+}
+
+// messageFile returns the file of the message with its name and MIME type, nil if the message has no file.
+// Photos are sent by Telegram in JPEG.
+func messageFile(msg *telebot.Message) (*telebot.File, string, string) {
+	switch {
+	case msg.Photo != nil:
+		return &msg.Photo.File, "photo.jpg", "image/jpeg"
+	case msg.Video != nil:
+		return &msg.Video.File, fileName(msg.Video.FileName, "video.mp4"), fileType(msg.Video.MIME, msg.Video.FileName, "video/mp4")
+	case msg.Document != nil:
+		return &msg.Document.File, fileName(msg.Document.FileName, "file"), fileType(msg.Document.MIME, msg.Document.FileName, "application/octet-stream")
+	default:
+		return nil, "", ""
+	}
+}
+
+func fileName(name, fallback string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == ".." {
+		return fallback
+	}
+
+	return name
+}
+
+func fileType(mimeType, name, fallback string) string {
+	if mimeType != "" {
+		return mimeType
+	}
+
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+
+	return fallback
+}
+
+// state fills the form with the post to edit it. Tags, sources and channels of the post are selected
+// in the lists of all tags, sources and channels.
+func (f *postForm) state(ctx context.Context, post models.Post) (CreatePostState, error) {
+	dto := CreatePostState{
+		ID:          string(post.ID),
+		Title:       post.Title,
+		Content:     post.Content,
+		PublishDate: post.PublishDate,
+		Media:       post.Media,
+	}
+
+	if post.Category != nil {
+		dto.Category = string(post.Category.ID)
+	}
+
+	tags, err := f.tagRepo.FindAll(ctx)
+	if err != nil && !errors.Is(err, models.ErrTagNotFound) {
+		return CreatePostState{}, err
+	}
+
+	allTags := make([]string, len(tags))
+	for i, tag := range tags {
+		allTags[i] = string(tag)
+	}
+
+	postTags := make([]string, len(post.Tags))
+	for i, tag := range post.Tags {
+		postTags[i] = string(tag)
+	}
+
+	dto.CheckboxTags = selectedCheckboxItems(allTags, postTags)
+
+	sources, err := f.sourceRepo.FindAll(ctx)
+	if err != nil && !errors.Is(err, models.ErrSourceNotFound) {
+		return CreatePostState{}, err
+	}
+
+	allSources := make([]string, len(sources))
+	for i, s := range sources {
+		allSources[i] = string(s)
+	}
+
+	postSources := make([]string, len(post.Sources))
+	for i, s := range post.Sources {
+		postSources[i] = string(s)
+	}
+
+	dto.CheckboxSources = selectedCheckboxItems(allSources, postSources)
+
+	channels, err := f.telegramChannelRepo.FindAll(ctx)
+	if err != nil && !errors.Is(err, models.ErrTelegramChannelNotFound) {
+		return CreatePostState{}, err
+	}
+
+	dto.CheckboxTelegramChannels = make([]CheckboxKeyboardItem, len(channels))
+	for i, tc := range channels {
+		dto.CheckboxTelegramChannels[i] = CheckboxKeyboardItem{
+			Value:      string(tc.ID),
+			Label:      tc.Title,
+			IsSelected: slices.Contains(post.TelegramChannels, tc.ID),
+		}
+	}
+
+	dto.CheckboxMedia = make([]CheckboxKeyboardItem, len(post.Media))
+	for i, m := range post.Media {
+		dto.CheckboxMedia[i] = CheckboxKeyboardItem{Value: string(m.ID), Label: path.Base(m.URI), IsSelected: true}
+	}
+
+	return dto, nil
+}
+
+// selectedCheckboxItems returns items of all values, the selected values are checked.
+// Selected values missing in all values are added to the end.
+func selectedCheckboxItems(all, selected []string) []CheckboxKeyboardItem {
+	items := make([]CheckboxKeyboardItem, 0, len(all))
+	for _, v := range all {
+		items = append(items, CheckboxKeyboardItem{Value: v, Label: v, IsSelected: slices.Contains(selected, v)})
+	}
+
+	for _, v := range selected {
+		if !slices.Contains(all, v) {
+			items = append(items, CheckboxKeyboardItem{Value: v, Label: v, IsSelected: true})
+		}
+	}
+
+	return items
+}
+
+func updatePostDTO(dto CreatePostState) models.UpdatePostDTO {
+	post := createPostDTO(dto)
+
+	return models.UpdatePostDTO{
+		ID:               models.PostID(dto.ID),
+		Title:            post.Title,
+		Content:          post.Content,
+		PublishDate:      post.PublishDate,
+		Tags:             post.Tags,
+		Sources:          post.Sources,
+		Media:            post.Media,
+		Category:         post.Category,
+		TelegramChannels: post.TelegramChannels,
+	}
+}
+
+func createPostDTO(dto CreatePostState) models.CreatePostDTO {
+	tags := make([]models.Tag, 0, len(dto.Tags)+len(dto.CheckboxTags))
+	for _, tag := range dto.Tags {
+		tags = append(tags, models.Tag(tag))
+	}
+
+	for _, tag := range dto.CheckboxTags {
+		// A tag may be written again while it is selected in the list.
+		if !tag.IsSelected || tag.Value == "" || slices.Contains(tags, models.Tag(tag.Value)) {
+			continue
+		}
+
+		tags = append(tags, models.Tag(tag.Value))
+	}
+
+	sources := make([]models.Source, 0, len(dto.CheckboxSources))
+	for _, s := range dto.CheckboxSources {
+		if !s.IsSelected || s.Value == "" {
+			continue
+		}
+
+		sources = append(sources, models.Source(s.Value))
+	}
+
+	var category *models.CategoryID
+	if dto.Category != "" {
+		categoryID := models.CategoryID(dto.Category)
+		category = &categoryID
+	}
+
+	telegramChannels := make([]models.TelegramChannelID, 0, len(dto.CheckboxTelegramChannels))
+	for _, tc := range dto.CheckboxTelegramChannels {
+		if !tc.IsSelected {
+			continue
+		}
+
+		telegramChannels = append(telegramChannels, models.TelegramChannelID(tc.Value))
+	}
+
+	media := make([]models.MediaID, 0, len(dto.CheckboxMedia))
+	for _, m := range dto.CheckboxMedia {
+		if !m.IsSelected {
+			continue
+		}
+
+		media = append(media, models.MediaID(m.Value))
+	}
+
+	return models.CreatePostDTO{
+		Title:            dto.Title,
+		Content:          dto.Content,
+		PublishDate:      dto.PublishDate,
+		Tags:             tags,
+		Sources:          sources,
+		Media:            media,
+		Category:         category,
+		TelegramChannels: telegramChannels,
+	}
+}
+
+func splitTags(text string) []string {
+	tagsRaw := strings.Split(text, ",")
+	tags := make([]string, 0, len(tagsRaw))
+	for _, tag := range tagsRaw {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+func categoryDepth(categories []models.Category, category models.Category) int {
+	parents := make(map[models.CategoryID]*models.CategoryID, len(categories))
+	for _, c := range categories {
+		parents[c.ID] = c.ParentID
+	}
+
+	depth := 0
+	for parentID := category.ParentID; parentID != nil && depth < len(categories); parentID = parents[*parentID] {
+		depth++
+	}
+
+	return depth
+}
@@ -0,0 +1,327 @@
+package tgbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kostromin59/poster/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+type PostsRepository interface {
+	Find(ctx context.Context, filters models.PostSearchFilters, offset, limit uint64) ([]models.Post, error)
+	FindByID(ctx context.Context, id models.PostID) (models.Post, error)
+	Update(ctx context.Context, dto models.UpdatePostDTO) (models.Post, error)
+	Delete(ctx context.Context, id models.PostID) error
+}
+
+const (
+	postsPageSize    = 10
+	postsTitleLength = 40
+)
+
+const (
+	actionPostsPage         = "actionPostsPage"
+	actionPostOpen          = "actionPostOpen"
+	actionPostEdit          = "actionPostEdit"
+	actionPostReschedule    = "actionPostReschedule"
+	actionPostDelete        = "actionPostDelete"
+	actionPostDeleteConfirm = "actionPostDeleteConfirm"
+)
+
+// Posts lists upcoming and recent posts. A post can be edited with the post form, rescheduled or deleted.
+type Posts struct {
+	bot    *telebot.Bot
+	wizard *Wizard[CreatePostState]
+	form   *postForm
+	repo   PostsRepository
+	loc    *time.Location
+	now    func() time.Time
+}
+
+func NewPosts(
+	bot *telebot.Bot,
+	step Step,
+	state State[WizardSession[CreatePostState]],
+	repo PostsRepository,
+	tagRepo CreatePostTagRepository,
+	sourceRepo CreatePostSourceRepository,
+	telegramChannelRepo CreatePostTelegramChannelRepository,
+	categoryRepo CreatePostCategoryRepository,
+	mediaRepo CreatePostMediaRepository,
+	mediaStorage CreatePostMediaStorage,
+	newPreviewer CreatePostPreviewerFactory,
+	loc *time.Location,
+) *Posts {
+	p := &Posts{
+		bot:  bot,
+		form: newPostForm(bot, mediaRepo, mediaStorage, tagRepo, sourceRepo, telegramChannelRepo, categoryRepo, newPreviewer, loc),
+		repo: repo,
+		loc:  loc,
+		now:  time.Now,
+	}
+
+	p.wizard = NewWizard(bot, "edit_post", step, state, p.update, p.form.steps()...)
+
+	return p
+}
+
+func (p *Posts) Name() string {
+	return p.wizard.Name()
+}
+
+func (p *Posts) HandleText(c telebot.Context) error {
+	return p.wizard.HandleText(c)
+}
+
+func (p *Posts) HandleFile(c telebot.Context) error {
+	return p.wizard.HandleFile(c)
+}
+
+func (p *Posts) Handler() telebot.HandlerFunc {
+	p.wizard.Register()
+
+	p.bot.Handle("\f"+actionPostsPage, p.page)
+	p.bot.Handle("\f"+actionPostOpen, p.open)
+	p.bot.Handle("\f"+actionPostEdit, p.edit)
+	p.bot.Handle("\f"+actionPostReschedule, p.reschedule)
+	p.bot.Handle("\f"+actionPostDelete, p.delete)
+	p.bot.Handle("\f"+actionPostDeleteConfirm, p.deleteConfirm)
+
+	return func(c telebot.Context) error {
+		ctx := c.Get(ContextKey).(context.Context)
+
+		text, kb, err := p.listMessage(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		return c.Send(text, kb)
+	}
+}
+
+func (p *Posts) page(c telebot.Context) error {
+	_ = c.Respond()
+
+	ctx := c.Get(ContextKey).(context.Context)
+
+	offset, err := strconv.ParseUint(c.Data(), 10, 64)
+	if err != nil {
+		offset = 0
+	}
+
+	text, kb, err := p.listMessage(ctx, offset)
+	if err != nil {
+		return err
+	}
+
+	return c.Edit(text, kb)
+}
+
+func (p *Posts) open(c telebot.Context) error {
+	_ = c.Respond()
+
+	ctx := c.Get(ContextKey).(context.Context)
+
+	post, err := p.repo.FindByID(ctx, models.PostID(c.Data()))
+	if err != nil {
+		if errors.Is(err, models.ErrPostNotFound) {
+			return c.Edit("Пост не найден!")
+		}
+
+		return err
+	}
+
+	msg := &strings.Builder{}
+	fmt.Fprintf(msg, "<b>%s</b>\n\n", html.EscapeString(post.Title))
+
+	status := "опубликован"
+	if post.PublishDate.After(p.now()) {
+		status = "запланирован"
+	}
+	fmt.Fprintf(msg, "Дата публикации: %s (%s)\n", post.PublishDate.In(p.loc).Format("2006-01-02 15:04"), status)
+
+	tags := make([]string, len(post.Tags))
+	for i, tag := range post.Tags {
+		tags[i] = string(tag)
+	}
+	fmt.Fprintf(msg, "Теги: %s\n", html.EscapeString(strings.Join(tags, ", ")))
+
+	sources := make([]string, len(post.Sources))
+	for i, s := range post.Sources {
+		sources[i] = string(s)
+	}
+	fmt.Fprintf(msg, "Источники: %s\n", html.EscapeString(strings.Join(sources, ", ")))
+
+	if post.Category != nil {
+		fmt.Fprintf(msg, "Категория: %s\n", html.EscapeString(post.Category.Title))
+	}
+
+	fmt.Fprintf(msg, "Медиафайлов: %d", len(post.Media))
+
+	id := string(post.ID)
+
+	kb := p.bot.NewMarkup()
+	kb.Inline(
+		kb.Row(kb.Data("Изменить", actionPostEdit, id)),
+		kb.Row(kb.Data("Перенести публикацию", actionPostReschedule, id)),
+		kb.Row(kb.Data("Удалить", actionPostDelete, id)),
+		kb.Row(kb.Data("« Назад", actionPostsPage, "0")),
+	)
+
+	return c.Edit(msg.String(), kb)
+}
+
+// edit opens the post form on the preview, every field can be changed from there.
+func (p *Posts) edit(c telebot.Context) error {
+	return p.startWizard(c, StepAwaitingConfirmation)
+}
+
+// reschedule asks for the new publish date, the preview is shown after it.
+func (p *Posts) reschedule(c telebot.Context) error {
+	return p.startWizard(c, StepAwaitingPublishDate)
+}
+
+func (p *Posts) startWizard(c telebot.Context, step string) error {
+	_ = c.Respond()
+
+	ctx := c.Get(ContextKey).(context.Context)
+
+	post, err := p.repo.FindByID(ctx, models.PostID(c.Data()))
+	if err != nil {
+		if errors.Is(err, models.ErrPostNotFound) {
+			return c.Edit("Пост не найден!")
+		}
+
+		return err
+	}
+
+	dto, err := p.form.state(ctx, post)
+	if err != nil {
+		return err
+	}
+
+	return p.wizard.Start(c, dto, step)
+}
+
+func (p *Posts) delete(c telebot.Context) error {
+	_ = c.Respond()
+
+	id := c.Data()
+
+	kb := p.bot.NewMarkup()
+	kb.Inline(
+		kb.Row(kb.Data("Да, удалить", actionPostDeleteConfirm, id)),
+		kb.Row(kb.Data("« Назад", actionPostOpen, id)),
+	)
+
+	return c.Edit("Удалить пост?", kb)
+}
+
+func (p *Posts) deleteConfirm(c telebot.Context) error {
+	_ = c.Respond()
+
+	ctx := c.Get(ContextKey).(context.Context)
+
+	if err := p.repo.Delete(ctx, models.PostID(c.Data())); err != nil {
+		if errors.Is(err, models.ErrPostNotFound) {
+			return c.Edit("Пост не найден!")
+		}
+
+		if errors.Is(err, models.ErrPostPublished) {
+			kb := p.bot.NewMarkup()
+			kb.Inline(kb.Row(kb.Data("« Назад", actionPostOpen, c.Data())))
+
+			return c.Edit("Пост уже опубликован, его нельзя удалить.", kb)
+		}
+
+		return err
+	}
+
+	kb := p.bot.NewMarkup()
+	kb.Inline(kb.Row(kb.Data("« К списку постов", actionPostsPage, "0")))
+
+	return c.Edit("Пост удалён!", kb)
+}
+
+func (p *Posts) update(ctx context.Context, c telebot.Context, dto CreatePostState) error {
+	if _, err := p.repo.Update(ctx, updatePostDTO(dto)); err != nil {
+		if errors.Is(err, models.ErrPostNotFound) {
+			return c.Send("Пост не найден!", &telebot.ReplyMarkup{RemoveKeyboard: true})
+		}
+
+		return err
+	}
+
+	return c.Send("Пост сохранён!", &telebot.ReplyMarkup{RemoveKeyboard: true})
+}
+
+// listMessage returns the page of posts, the latest publish date first: scheduled posts
+// come before published ones.
+func (p *Posts) listMessage(ctx context.Context, offset uint64) (string, *telebot.ReplyMarkup, error) {
+	// One more post is requested to know if there is the next page.
+	posts, err := p.repo.Find(ctx, models.PostSearchFilters{}, offset, postsPageSize+1)
+	if err != nil && !errors.Is(err, models.ErrPostNotFound) {
+		return "", nil, err
+	}
+
+	if len(posts) == 0 {
+		if offset == 0 {
+			return "Постов пока нет!", nil, nil
+		}
+
+		// The page may become empty after deletion.
+		return p.listMessage(ctx, 0)
+	}
+
+	hasNext := len(posts) > postsPageSize
+	if hasNext {
+		posts = posts[:postsPageSize]
+	}
+
+	now := p.now()
+
+	kb := p.bot.NewMarkup()
+	rows := make([]telebot.Row, 0, len(posts)+1)
+	for _, post := range posts {
+		mark := "✅"
+		if post.PublishDate.After(now) {
+			mark = "🕒"
+		}
+
+		label := fmt.Sprintf("%s %s %s", mark, post.PublishDate.In(p.loc).Format("02.01 15:04"), truncate(post.Title, postsTitleLength))
+		rows = append(rows, kb.Row(kb.Data(label, actionPostOpen, string(post.ID))))
+	}
+
+	var nav telebot.Row
+	if offset > 0 {
+		nav = append(nav, kb.Data("« Назад", actionPostsPage, strconv.FormatUint(offset-min(offset, postsPageSize), 10)))
+	}
+
+	if hasNext {
+		nav = append(nav, kb.Data("Вперёд »", actionPostsPage, strconv.FormatUint(offset+postsPageSize, 10)))
+	}
+
+	if len(nav) != 0 {
+		rows = append(rows, nav)
+	}
+
+	kb.Inline(rows...)
+
+	return fmt.Sprintf("Посты (страница %d), 🕒 — запланированные:", offset/postsPageSize+1), kb, nil
+}
+
+// truncate cuts the text to the length in runes.
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	return string(runes[:length-1]) + "…"
+}
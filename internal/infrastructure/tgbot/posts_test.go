@@ -0,0 +1,325 @@
+package tgbot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kostromin59/poster/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+type postsRepoStub struct {
+	posts   []models.Post
+	updated []models.UpdatePostDTO
+	deleted []models.PostID
+	// published posts are not deleted, as the repository does.
+	published []models.PostID
+}
+
+func (r *postsRepoStub) Find(_ context.Context, _ models.PostSearchFilters, offset, limit uint64) ([]models.Post, error) {
+	if offset >= uint64(len(r.posts)) {
+		return nil, models.ErrPostNotFound
+	}
+
+	return r.posts[offset:min(offset+limit, uint64(len(r.posts)))], nil
+}
+
+func (r *postsRepoStub) FindByID(_ context.Context, id models.PostID) (models.Post, error) {
+	for _, p := range r.posts {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+
+	return models.Post{}, models.ErrPostNotFound
+}
+
+func (r *postsRepoStub) Update(_ context.Context, dto models.UpdatePostDTO) (models.Post, error) {
+	r.updated = append(r.updated, dto)
+	return models.Post{ID: dto.ID}, nil
+}
+
+func (r *postsRepoStub) Delete(_ context.Context, id models.PostID) error {
+	if slices.Contains(r.published, id) {
+		return models.ErrPostPublished
+	}
+
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+type postsCategoryRepoStub struct{}
+
+func (postsCategoryRepoStub) FindAll(context.Context) ([]models.Category, error) {
+	return []models.Category{{ID: "019b0000-0000-7000-8000-00000000c001", Slug: "news", Title: "Новости"}}, nil
+}
+
+func TestPosts(t *testing.T) {
+	bot, err := telebot.NewBot(telebot.Settings{Offline: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	now := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	repo := &postsRepoStub{}
+	for i := range postsPageSize + 2 {
+		repo.posts = append(repo.posts, models.Post{
+			ID:          models.PostID(fmt.Sprintf("019b0000-0000-7000-8000-%012d", i)),
+			Title:       "Пост",
+			PublishDate: now.Add(time.Duration(1-i) * time.Hour),
+		})
+	}
+
+	repo.posts[0].Tags = []models.Tag{"go"}
+	repo.posts[0].Sources = []models.Source{"website"}
+	repo.posts[0].Media = []models.Media{
+		{ID: "019b0000-0000-7000-8000-00000000a001", Filetype: "image/jpeg", URI: "https://example.com/media/a.jpg"},
+		{ID: "019b0000-0000-7000-8000-00000000a002", Filetype: "image/jpeg", URI: "https://example.com/media/b.jpg"},
+	}
+
+	postA, postB := string(repo.posts[0].ID), string(repo.posts[1].ID)
+
+	media := &createPostMediaRepoStub{}
+	publisher := NewPublisher(bot, 1, "footer", nil, createPostChannelRepoStub{}, nil)
+	step := NewLocalState[string](time.Hour)
+	p := NewPosts(bot, step, NewLocalState[WizardSession[CreatePostState]](time.Hour),
+		repo, createPostTagRepoStub{}, createPostSourceRepoStub{}, createPostChannelRepoStub{}, postsCategoryRepoStub{},
+		media, createPostMediaStorageStub{},
+		func(source models.SourceConfig) (CreatePostPreviewer, error) {
+			return publisher.WithSource(source)
+		}, time.UTC)
+	p.now = func() time.Time { return now }
+	p.form.files = filesStub{}
+
+	conversations := NewConversations(step)
+	conversations.Add(p)
+	text := conversations.Handler()
+	file := conversations.FileHandler()
+
+	call := func(handler telebot.HandlerFunc, data string) *fakeContext {
+		t.Helper()
+
+		c := &fakeContext{data: data}
+		if err := handler(c); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		return c
+	}
+
+	send := func(msg string) *fakeContext {
+		t.Helper()
+
+		c := &fakeContext{text: msg}
+		if err := text(c); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		return c
+	}
+
+	press := func(step, action, value string) *fakeContext {
+		t.Helper()
+
+		return call(p.wizard.handleCallback, pressData(t, p.wizard, step, action, value))
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		_, kb, err := p.listMessage(t.Context(), 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if len(kb.InlineKeyboard) != postsPageSize+1 {
+			t.Fatalf("expected %d posts and navigation, got %d rows", postsPageSize, len(kb.InlineKeyboard))
+		}
+
+		if label := kb.InlineKeyboard[0][0].Text; !strings.HasPrefix(label, "🕒") {
+			t.Errorf("expected the scheduled post to be marked, got %q", label)
+		}
+
+		if label := kb.InlineKeyboard[2][0].Text; !strings.HasPrefix(label, "✅") {
+			t.Errorf("expected the published post to be marked, got %q", label)
+		}
+
+		_, kb, err = p.listMessage(t.Context(), postsPageSize)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		nav := kb.InlineKeyboard[len(kb.InlineKeyboard)-1]
+		if len(kb.InlineKeyboard) != 3 || len(nav) != 1 || nav[0].Text != "« Назад" {
+			t.Errorf("expected the last page with the back button, got %+v", kb.InlineKeyboard)
+		}
+	})
+
+	t.Run("reschedule", func(t *testing.T) {
+		if c := call(p.reschedule, postA); !strings.HasPrefix(c.last(), "Введите дату") {
+			t.Fatalf("expected the publish date step, got %q", c.sent)
+		}
+
+		if c := send("2025-06-01 12:00"); !strings.HasPrefix(c.last(), "<b>Пост</b>") {
+			t.Fatalf("expected the preview, got %q", c.sent)
+		}
+
+		if c := press(StepAwaitingConfirmation, actionConfirm, ""); c.last() != "Пост сохранён!" {
+			t.Fatalf("expected the post to be saved, got %q", c.sent)
+		}
+
+		dto := repo.updated[len(repo.updated)-1]
+		if dto.ID != models.PostID(postA) || dto.Title != "Пост" || !dto.PublishDate.Equal(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected update: %+v", dto)
+		}
+
+		if !slices.Equal(dto.Tags, []models.Tag{"go"}) || !slices.Equal(dto.Sources, []models.Source{"website"}) {
+			t.Errorf("expected tags and sources to be kept, got %+v", dto)
+		}
+
+		if !slices.Equal(dto.Media, []models.MediaID{repo.posts[0].Media[0].ID, repo.posts[0].Media[1].ID}) {
+			t.Errorf("expected media to be kept, got %v", dto.Media)
+		}
+	})
+
+	t.Run("edit media", func(t *testing.T) {
+		c := call(p.edit, postA)
+		if !strings.HasPrefix(c.last(), "<b>Пост</b>") {
+			t.Fatalf("expected the preview, got %q", c.sent)
+		}
+		checkCallbacks(t, c)
+
+		if len(c.media) != 1 {
+			t.Fatalf("expected the media before the preview, got %+v", c.media)
+		}
+
+		if album, ok := c.media[0].(telebot.Album); !ok || len(album) != 2 {
+			t.Errorf("expected the album of the post media, got %+v", c.media[0])
+		}
+
+		if c := press(StepAwaitingConfirmation, actionTestCopy, ""); len(c.media) != 1 || c.last() == "" {
+			t.Errorf("expected the test copy with the media, got %q and %+v", c.sent, c.media)
+		}
+
+		if c := press(StepAwaitingConfirmation, actionEdit, StepAwaitingCategory); c.last() != "Выберите категорию:" {
+			t.Fatalf("expected the category step, got %q", c.sent)
+		} else {
+			checkCallbacks(t, c)
+		}
+
+		press(StepAwaitingCategory, actionSelectCategory, "019b0000-0000-7000-8000-00000000c001")
+
+		if c := press(StepAwaitingConfirmation, actionEdit, StepAwaitingMedia); c.last() != "Медиафайлы поста:" {
+			t.Fatalf("expected the media step, got %q", c.sent)
+		} else {
+			checkCallbacks(t, c)
+		}
+
+		checkCallbacks(t, press(StepAwaitingMedia, actionToggle, string(repo.posts[0].Media[0].ID)))
+
+		if c := send(NextStepButton); !strings.HasPrefix(c.last(), "<b>Пост</b>") {
+			t.Fatalf("expected the preview after the edited step, got %q", c.sent)
+		} else if photo, ok := c.media[0].(*telebot.Photo); len(c.media) != 1 || !ok || photo.FileURL != "https://example.com/media/b.jpg" {
+			t.Errorf("expected only the kept media in the preview, got %+v", c.media)
+		}
+
+		press(StepAwaitingConfirmation, actionConfirm, "")
+
+		dto := repo.updated[len(repo.updated)-1]
+		if !slices.Equal(dto.Media, []models.MediaID{repo.posts[0].Media[1].ID}) {
+			t.Errorf("expected the media to be removed, got %v", dto.Media)
+		}
+
+		if dto.Category == nil || *dto.Category != "019b0000-0000-7000-8000-00000000c001" {
+			t.Errorf("expected the category to be selected, got %v", dto.Category)
+		}
+	})
+
+	t.Run("upload media", func(t *testing.T) {
+		call(p.edit, postB)
+		press(StepAwaitingConfirmation, actionEdit, StepAwaitingMedia)
+
+		upload := func(msg *telebot.Message) *fakeContext {
+			t.Helper()
+
+			c := &fakeContext{message: msg}
+			if err := file(c); err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+
+			return c
+		}
+
+		upload(&telebot.Message{Photo: &telebot.Photo{File: telebot.File{FileID: "photo", UniqueID: "p1"}}})
+
+		c := upload(&telebot.Message{Document: &telebot.Document{File: telebot.File{FileID: "doc", UniqueID: "d1"}, FileName: "../report.pdf"}})
+		if c.last() != "Медиафайлы поста:" {
+			t.Fatalf("expected the media step with the uploaded files, got %q", c.sent)
+		}
+		checkCallbacks(t, c)
+
+		if c := upload(&telebot.Message{Document: &telebot.Document{File: telebot.File{FileSize: maxDownloadSize + 1}}}); c.last() != "Файл больше 20 МБ, бот не может его скачать." {
+			t.Errorf("expected the size validation, got %q", c.sent)
+		}
+
+		if len(media.created) != 2 {
+			t.Fatalf("expected 2 uploaded media, got %+v", media.created)
+		}
+
+		if m := media.created[0]; m.Filetype != "image/jpeg" || m.URI != "https://example.com/media/bot/p1/photo.jpg" {
+			t.Errorf("unexpected photo: %+v", m)
+		}
+
+		if m := media.created[1]; m.Filetype != "application/pdf" || m.URI != "https://example.com/media/bot/d1/report.pdf" {
+			t.Errorf("unexpected document: %+v", m)
+		}
+
+		// The photo is removed before saving, only the document is kept.
+		press(StepAwaitingMedia, actionToggle, string(media.created[0].ID))
+		send(NextStepButton)
+		press(StepAwaitingConfirmation, actionConfirm, "")
+
+		dto := repo.updated[len(repo.updated)-1]
+		if !slices.Equal(dto.Media, []models.MediaID{media.created[1].ID}) {
+			t.Errorf("expected the uploaded document, got %v", dto.Media)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		c := call(p.delete, postB)
+		if len(repo.deleted) != 0 || len(c.edits) != 1 {
+			t.Fatalf("expected the confirmation, got %q", c.edits)
+		}
+		checkCallbacks(t, c)
+
+		call(p.deleteConfirm, postB)
+
+		if !slices.Equal(repo.deleted, []models.PostID{models.PostID(postB)}) {
+			t.Errorf("unexpected deleted posts: %v", repo.deleted)
+		}
+	})
+	t.Run("published post is not deleted", func(t *testing.T) {
+		repo.published = []models.PostID{models.PostID(postA)}
+		deleted := len(repo.deleted)
+
+		c := call(p.deleteConfirm, postA)
+		if len(repo.deleted) != deleted {
+			t.Errorf("expected the post to be kept, got %v", repo.deleted)
+		}
+
+		if len(c.edits) != 1 || !strings.Contains(c.edits[0], "нельзя удалить") {
+			t.Errorf("unexpected message: %q", c.edits)
+		}
+		checkCallbacks(t, c)
+	})
+}
+
+type filesStub struct{}
+
+func (filesStub) File(*telebot.File) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("file")), nil
+}
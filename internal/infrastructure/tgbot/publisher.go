@@ -3,12 +3,18 @@ package tgbot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"path"
 	"slices"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/models"
+	"github.com/kostromin59/poster/pkg/feed"
 	"gopkg.in/telebot.v4"
 )
 
@@ -17,38 +23,195 @@ type PublisherCache interface {
 	Get(ctx context.Context, key string) ([]byte, error)
 }
 
+type PublisherChannelRepository interface {
+	FindByIDs(ctx context.Context, ids []models.TelegramChannelID) ([]models.TelegramChannel, error)
+}
+
+type PublisherPublicationRepository interface {
+	Find(ctx context.Context, postID models.PostID, source models.Source, target string) (models.Publication, error)
+	Save(ctx context.Context, publication models.Publication) error
+}
+
 const PublisherAlreadyPublishedKey = "tgAlreadyPublished"
 
 var PublisherCacheExpiration = 7 * 24 * time.Hour // Kafka data expiration
 
+// PublisherDefaultTemplate is used for channels without their own template.
+const PublisherDefaultTemplate = `<b>{{.Title}}</b>
+
+{{.Content}}
+
+{{join .Tags " "}}
+
+{{.Footer}}`
+
+var publisherTemplateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// PublisherMessage is the data available in channel templates.
+type PublisherMessage struct {
+	Title    string
+	Content  string
+	Tags     []string
+	Footer   string
+	Category string
+}
+
+// PostMessage is the post as it is sent to a chat: the media and then the text.
+type PostMessage struct {
+	Text  string
+	Media []telebot.Inputtable
+}
+
+// maxAlbumSize is the limit of media in one album of Telegram.
+const maxAlbumSize = 10
+
+// PublisherConfig is a config of the telegram source. Empty fields are taken from defaults.
+type PublisherConfig struct {
+	ChatID int64  `json:"chat_id"`
+	Footer string `json:"footer"`
+}
+
 type Publisher struct {
-	bot    *telebot.Bot
-	chatID int64
-	footer string
-	cache  PublisherCache
+	bot             *telebot.Bot
+	source          models.Source
+	chatID          int64
+	footer          string
+	cache           PublisherCache
+	channelRepo     PublisherChannelRepository
+	publicationRepo PublisherPublicationRepository
 }
 
-func NewPublisher(bot *telebot.Bot, chatID int64, footer string, cache PublisherCache) *Publisher {
+func NewPublisher(
+	bot *telebot.Bot,
+	chatID int64,
+	footer string,
+	cache PublisherCache,
+	channelRepo PublisherChannelRepository,
+	publicationRepo PublisherPublicationRepository,
+) *Publisher {
 	return &Publisher{
-		bot:    bot,
-		chatID: chatID,
-		footer: footer,
-		cache:  cache,
+		bot:             bot,
+		source:          models.SourceTG,
+		chatID:          chatID,
+		footer:          footer,
+		cache:           cache,
+		channelRepo:     channelRepo,
+		publicationRepo: publicationRepo,
+	}
+}
+
+// WithSource returns a copy of the publisher configured by the source.
+func (p *Publisher) WithSource(source models.SourceConfig) (*Publisher, error) {
+	const op = "tgbot.Publisher.WithSource"
+
+	cfg := PublisherConfig{
+		ChatID: p.chatID,
+		Footer: p.footer,
+	}
+
+	if len(source.Config) != 0 {
+		if err := json.Unmarshal(source.Config, &cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
 	}
+
+	if cfg.ChatID == 0 {
+		cfg.ChatID = p.chatID
+	}
+
+	if cfg.Footer == "" {
+		cfg.Footer = p.footer
+	}
+
+	publisher := *p
+	publisher.source = source.Source
+	publisher.chatID = cfg.ChatID
+	publisher.footer = cfg.Footer
+
+	return &publisher, nil
 }
 
+// Publish sends the post to every target channel of the post.
+// A post without channels is sent to the default chat.
 func (p *Publisher) Publish(ctx context.Context, post events.PublishedPost) error {
 	const op = "tgbot.Publisher.Publish"
 
-	alreadyPublishedRaw, err := p.cache.Get(ctx, PublisherAlreadyPublishedKey)
+	channels, err := p.channels(ctx, post)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	var errs []error
+	for _, channel := range channels {
+		if err := p.publishToChannel(ctx, post, channel); err != nil {
+			errs = append(errs, fmt.Errorf("chat %d: %w", channel.ChatID, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Preview renders the post as it is sent to the first target channel of the post.
+// The preview is sent with SendPostMessage, the same way the post is published.
+func (p *Publisher) Preview(ctx context.Context, post events.PublishedPost) (PostMessage, error) {
+	const op = "tgbot.Publisher.Preview"
+
+	channels, err := p.channels(ctx, post)
+	if err != nil {
+		return PostMessage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(channels) == 0 {
+		return PostMessage{}, fmt.Errorf("%s: %w", op, models.ErrTelegramChannelNotFound)
+	}
+
+	text, err := p.message(post, channels[0])
+	if err != nil {
+		return PostMessage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return PostMessage{Text: text, Media: postMedia(post.Data.Media)}, nil
+}
+
+// channels returns the target channels of the post, the default chat for a post without channels.
+func (p *Publisher) channels(ctx context.Context, post events.PublishedPost) ([]models.TelegramChannel, error) {
+	if len(post.Data.TelegramChannels) == 0 {
+		return []models.TelegramChannel{{
+			ChatID: p.chatID,
+			Footer: p.footer,
+		}}, nil
+	}
+
+	ids := make([]models.TelegramChannelID, len(post.Data.TelegramChannels))
+	for i, id := range post.Data.TelegramChannels {
+		ids[i] = models.TelegramChannelID(id)
+	}
+
+	return p.channelRepo.FindByIDs(ctx, ids)
+}
+
+func (p *Publisher) publishToChannel(ctx context.Context, post events.PublishedPost, channel models.TelegramChannel) error {
+	// The default chat keeps the original key to not publish posts again after the update.
+	cacheKey := PublisherAlreadyPublishedKey
+	if channel.ID != "" {
+		cacheKey += ":" + string(channel.ID)
+	}
+
+	alreadyPublishedRaw, err := p.cache.Get(ctx, cacheKey)
+	if err != nil {
+		return err
+	}
+
 	var alreadyPublished []string
 	if len(alreadyPublishedRaw) != 0 {
 		if err := json.Unmarshal(alreadyPublishedRaw, &alreadyPublished); err != nil {
-			return fmt.Errorf("%s: %w", op, err)
+			return err
 		}
 	}
 
@@ -56,58 +219,199 @@ func (p *Publisher) Publish(ctx context.Context, post events.PublishedPost) erro
 		return nil
 	}
 
-	msg := &strings.Builder{}
-	msg.Grow(len(post.Data.Title) + len(post.Data.Title) + len(p.footer))
+	target := strconv.FormatInt(channel.ChatID, 10)
 
-	if _, err := msg.WriteString("<b>"); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	_, err = p.publicationRepo.Find(ctx, models.PostID(post.Data.ID), p.source, target)
+	if err == nil {
+		return nil
 	}
 
-	if _, err := msg.WriteString(post.Data.Title); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	if !errors.Is(err, models.ErrPublicationNotFound) {
+		return err
 	}
 
-	if _, err := msg.WriteString("</b>\n\n"); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	text, err := p.message(post, channel)
+	if err != nil {
+		return err
 	}
 
-	if _, err := msg.WriteString(post.Data.Content); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	opts := []any{telebot.ModeHTML}
+	if channel.Silent {
+		opts = append(opts, telebot.Silent)
 	}
 
-	if _, err := msg.WriteString("\n\n"); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	chat := &chatSender{bot: p.bot, chat: &telebot.Chat{ID: channel.ChatID}}
+	if err := SendPostMessage(chat, PostMessage{Text: text, Media: postMedia(post.Data.Media)}, opts...); err != nil {
+		return err
 	}
 
-	tags := strings.Join(post.Data.Tags, " ")
+	// The publication is the text message, the media are sent before it.
+	if err := p.publicationRepo.Save(ctx, models.Publication{
+		PostID:      models.PostID(post.Data.ID),
+		Source:      p.source,
+		Target:      target,
+		ExternalID:  strconv.Itoa(chat.last.ID),
+		PublishedAt: time.Now(),
+	}); err != nil {
+		return err
+	}
 
-	if _, err := msg.WriteString(tags); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	alreadyPublished = append(alreadyPublished, post.Data.ID)
+	alreadyPublishedBytes, err := json.Marshal(alreadyPublished)
+	if err != nil {
+		return err
 	}
 
-	if _, err := msg.WriteString("\n\n"); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	return p.cache.SetWithExpiration(ctx, cacheKey, alreadyPublishedBytes, PublisherCacheExpiration)
+}
+
+// ParsePublisherTemplate parses the template of the channel with the functions available to it.
+func ParsePublisherTemplate(text string) (*template.Template, error) {
+	return template.New("post").Funcs(publisherTemplateFuncs).Parse(text)
+}
+
+func (p *Publisher) message(post events.PublishedPost, channel models.TelegramChannel) (string, error) {
+	tmplText := channel.Template
+	if tmplText == "" {
+		tmplText = PublisherDefaultTemplate
 	}
 
-	if _, err := msg.WriteString(p.footer); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	tmpl, err := ParsePublisherTemplate(tmplText)
+	if err != nil {
+		return "", err
 	}
 
-	if _, err := p.bot.Send(&telebot.Chat{
-		ID: p.chatID,
-	}, msg.String(), telebot.ModeHTML); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	tags := slices.Clone(post.Data.Tags)
+	for _, t := range channel.DefaultTags {
+		if !slices.Contains(tags, string(t)) {
+			tags = append(tags, string(t))
+		}
 	}
 
-	alreadyPublished = append(alreadyPublished, post.Data.ID)
-	alreadyPublishedBytes, err := json.Marshal(alreadyPublished)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	footer := channel.Footer
+	if footer == "" {
+		footer = p.footer
 	}
 
-	if err := p.cache.SetWithExpiration(ctx, PublisherAlreadyPublishedKey, alreadyPublishedBytes, PublisherCacheExpiration); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	data := PublisherMessage{
+		Title:   post.Data.Title,
+		Content: post.Data.Content,
+		Tags:    tags,
+		Footer:  footer,
 	}
 
+	if post.Data.Category != nil {
+		data.Category = post.Data.Category.Title
+	}
+
+	msg := &strings.Builder{}
+	msg.Grow(len(post.Data.Title) + len(post.Data.Content) + len(footer))
+
+	if err := tmpl.Execute(msg, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(msg.String()), nil
+}
+
+// MessageSender sends messages to a chat, telebot.Context is a sender to the current chat.
+type MessageSender interface {
+	Send(what any, opts ...any) error
+	SendAlbum(a telebot.Album, opts ...any) error
+}
+
+// chatSender sends messages to the chat with the bot and keeps the last sent message.
+type chatSender struct {
+	bot  *telebot.Bot
+	chat *telebot.Chat
+	last *telebot.Message
+}
+
+func (s *chatSender) Send(what any, opts ...any) error {
+	msg, err := s.bot.Send(s.chat, what, opts...)
+	if err != nil {
+		return err
+	}
+
+	s.last = msg
+
 	return nil
 }
+
+func (s *chatSender) SendAlbum(a telebot.Album, opts ...any) error {
+	_, err := s.bot.SendAlbum(s.chat, a, opts...)
+	return err
+}
+
+// SendPostMessage sends the media and then the text. Publishing, the preview and the test copy
+// use it, so they look the same.
+func SendPostMessage(s MessageSender, msg PostMessage, opts ...any) error {
+	if err := sendMedia(s, msg.Media, opts...); err != nil {
+		return err
+	}
+
+	return s.Send(msg.Text, opts...)
+}
+
+// sendMedia sends photos and videos in albums, other files are sent one by one.
+func sendMedia(s MessageSender, media []telebot.Inputtable, opts ...any) error {
+	var album telebot.Album
+
+	flush := func() error {
+		defer func() {
+			album = nil
+		}()
+
+		switch len(album) {
+		case 0:
+			return nil
+		case 1:
+			// An album must have at least two media.
+			return s.Send(album[0], opts...)
+		default:
+			return s.SendAlbum(album, opts...)
+		}
+	}
+
+	for _, m := range media {
+		switch m.(type) {
+		case *telebot.Photo, *telebot.Video:
+			album = append(album, m)
+			if len(album) == maxAlbumSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := flush(); err != nil {
+				return err
+			}
+
+			if err := s.Send(m, opts...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
+
+// postMedia returns the media of the post sent by their URIs. The type is taken from the filetype
+// or the extension of the URI.
+func postMedia(media []events.PublishedPostMedia) []telebot.Inputtable {
+	result := make([]telebot.Inputtable, len(media))
+	for i, m := range media {
+		file := telebot.FromURL(m.URI)
+
+		switch mediaType := feed.MediaType(m.Filetype, m.URI); {
+		case strings.HasPrefix(mediaType, "image/"):
+			result[i] = &telebot.Photo{File: file}
+		case strings.HasPrefix(mediaType, "video/"):
+			result[i] = &telebot.Video{File: file}
+		default:
+			result[i] = &telebot.Document{File: file, FileName: path.Base(m.URI)}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,96 @@
+package tgbot
+
+import (
+	"testing"
+
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+func TestPublisherMessage(t *testing.T) {
+	p := &Publisher{footer: "default footer"}
+
+	post := events.PublishedPost{
+		Data: events.PublishedPostData{
+			Title:   "title",
+			Content: "content",
+			Tags:    []string{"#one", "#two"},
+			Category: &events.PublishedPostCategory{
+				Title: "Новости",
+			},
+		},
+	}
+
+	t.Run("default template", func(t *testing.T) {
+		msg, err := p.message(post, models.TelegramChannel{
+			DefaultTags: []models.Tag{"#two", "#three"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		expected := "<b>title</b>\n\ncontent\n\n#one #two #three\n\ndefault footer"
+		if msg != expected {
+			t.Errorf("expected message %q but got %q", expected, msg)
+		}
+	})
+
+	t.Run("channel template", func(t *testing.T) {
+		msg, err := p.message(post, models.TelegramChannel{
+			Footer:   "channel footer",
+			Template: "{{.Category}}: {{.Title}}\n{{.Footer}}",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		expected := "Новости: title\nchannel footer"
+		if msg != expected {
+			t.Errorf("expected message %q but got %q", expected, msg)
+		}
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		_, err := p.message(post, models.TelegramChannel{
+			Template: "{{.Unknown",
+		})
+		if err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+}
+
+func TestSendPostMessage(t *testing.T) {
+	media := postMedia([]events.PublishedPostMedia{
+		{Filetype: "image/jpeg", URI: "https://example.com/1.jpg"},
+		{Filetype: "png", URI: "https://example.com/2"},
+		{Filetype: "application/pdf", URI: "https://example.com/doc.pdf"},
+		{URI: "https://example.com/3.mp4"},
+	})
+
+	c := &fakeContext{}
+	if err := SendPostMessage(c, PostMessage{Text: "text", Media: media}); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if len(c.media) != 3 {
+		t.Fatalf("expected the album, the document and the video, got %+v", c.media)
+	}
+
+	if album, ok := c.media[0].(telebot.Album); !ok || len(album) != 2 {
+		t.Errorf("expected the album of photos, got %+v", c.media[0])
+	}
+
+	if doc, ok := c.media[1].(*telebot.Document); !ok || doc.FileName != "doc.pdf" {
+		t.Errorf("expected the document, got %+v", c.media[1])
+	}
+
+	if _, ok := c.media[2].(*telebot.Video); !ok {
+		t.Errorf("expected the video, got %+v", c.media[2])
+	}
+
+	if len(c.sent) != 1 || c.sent[0] != "text" {
+		t.Errorf("expected the text after the media, got %q", c.sent)
+	}
+}
@@ -0,0 +1,92 @@
+package tgbot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisStateTimeout limits Redis calls, State has no context.
+const redisStateTimeout = 3 * time.Second
+
+// RedisState keeps the state in Redis as JSON, so conversations survive restarts.
+// Every access extends the idle ttl of the entry. State has no errors, so Redis errors
+// are logged and the user gets an empty state, as if the conversation had expired.
+type RedisState[T any] struct {
+	client  redis.Cmdable
+	prefix  string
+	idleTTL time.Duration
+}
+
+// NewRedisState creates the state. The name separates states of different handlers.
+func NewRedisState[T any](client redis.Cmdable, name string, idleTTL time.Duration) *RedisState[T] {
+	return &RedisState[T]{
+		client:  client,
+		prefix:  "poster:tgbot:" + name + ":",
+		idleTTL: idleTTL,
+	}
+}
+
+func (rs *RedisState[T]) Get(userID int64) T {
+	const op = "tgbot.RedisState.Get"
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisStateTimeout)
+	defer cancel()
+
+	var data T
+
+	raw, err := rs.client.GetEx(ctx, rs.key(userID), rs.idleTTL).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			slog.Error("unable to get state", slog.String("op", op), slog.String("err", err.Error()))
+		}
+
+		return data
+	}
+
+	if err := json.Unmarshal(raw, &data); err != nil {
+		slog.Error("unable to decode state", slog.String("op", op), slog.String("err", err.Error()))
+
+		var zero T
+		return zero
+	}
+
+	return data
+}
+
+func (rs *RedisState[T]) Set(userID int64, data T) {
+	const op = "tgbot.RedisState.Set"
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisStateTimeout)
+	defer cancel()
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		slog.Error("unable to encode state", slog.String("op", op), slog.String("err", err.Error()))
+		return
+	}
+
+	if err := rs.client.Set(ctx, rs.key(userID), raw, rs.idleTTL).Err(); err != nil {
+		slog.Error("unable to set state", slog.String("op", op), slog.String("err", err.Error()))
+	}
+}
+
+func (rs *RedisState[T]) Delete(userID int64) {
+	const op = "tgbot.RedisState.Delete"
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisStateTimeout)
+	defer cancel()
+
+	if err := rs.client.Del(ctx, rs.key(userID)).Err(); err != nil {
+		slog.Error("unable to delete state", slog.String("op", op), slog.String("err", err.Error()))
+	}
+}
+
+func (rs *RedisState[T]) key(userID int64) string {
+	return rs.prefix + strconv.FormatInt(userID, 10)
+}
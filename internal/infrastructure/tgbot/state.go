@@ -1,6 +1,9 @@
 package tgbot
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 type State[T any] interface {
 	Set(userID int64, data T)
@@ -8,29 +11,62 @@ type State[T any] interface {
 	Delete(userID int64)
 }
 
+// LocalState keeps the state in memory. An entry which has not been accessed for the idle ttl
+// is removed, so abandoned conversations don't stay forever.
 type LocalState[T any] struct {
-	m  map[int64]T
-	mu sync.RWMutex
+	m       map[int64]localEntry[T]
+	mu      sync.Mutex
+	idleTTL time.Duration
+	now     func() time.Time
 }
 
-func NewLocalState[T any]() *LocalState[T] {
+type localEntry[T any] struct {
+	data      T
+	expiresAt time.Time
+}
+
+func NewLocalState[T any](idleTTL time.Duration) *LocalState[T] {
 	return &LocalState[T]{
-		m: make(map[int64]T),
+		m:       make(map[int64]localEntry[T]),
+		idleTTL: idleTTL,
+		now:     time.Now,
 	}
 }
 
 func (ls *LocalState[T]) Get(userID int64) T {
-	ls.mu.RLock()
-	step := ls.m[userID]
-	ls.mu.RUnlock()
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	now := ls.now()
+
+	entry, ok := ls.m[userID]
+	if !ok || !now.Before(entry.expiresAt) {
+		delete(ls.m, userID)
+
+		var zero T
+		return zero
+	}
 
-	return step
+	entry.expiresAt = now.Add(ls.idleTTL)
+	ls.m[userID] = entry
+
+	return entry.data
 }
 
 func (ls *LocalState[T]) Set(userID int64, data T) {
 	ls.mu.Lock()
-	ls.m[userID] = data
-	ls.mu.Unlock()
+	defer ls.mu.Unlock()
+
+	now := ls.now()
+
+	// Entries of other users are checked here, as they may be never read again.
+	for id, entry := range ls.m {
+		if !now.Before(entry.expiresAt) {
+			delete(ls.m, id)
+		}
+	}
+
+	ls.m[userID] = localEntry[T]{data: data, expiresAt: now.Add(ls.idleTTL)}
 }
 
 func (ls *LocalState[T]) Delete(userID int64) {
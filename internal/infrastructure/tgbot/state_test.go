@@ -0,0 +1,93 @@
+package tgbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/kostromin59/poster/pkg/fakeclock"
+	"github.com/redis/go-redis/v9"
+)
+
+// stateFactory returns a new state with the idle ttl and a function moving its clock forward.
+type stateFactory func(t *testing.T, idleTTL time.Duration) (State[CreatePostState], func(time.Duration))
+
+func TestLocalState(t *testing.T) {
+	testState(t, func(t *testing.T, idleTTL time.Duration) (State[CreatePostState], func(time.Duration)) {
+		state := NewLocalState[CreatePostState](idleTTL)
+		clock := fakeclock.New()
+		state.now = clock.Now
+
+		return state, clock.Advance
+	})
+}
+
+func TestRedisState(t *testing.T) {
+	testState(t, func(t *testing.T, idleTTL time.Duration) (State[CreatePostState], func(time.Duration)) {
+		server := miniredis.RunT(t)
+
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+
+		return NewRedisState[CreatePostState](client, "create_post", idleTTL), server.FastForward
+	})
+}
+
+func testState(t *testing.T, newState stateFactory) {
+	t.Run("set, get and delete", func(t *testing.T) {
+		state, _ := newState(t, time.Hour)
+
+		publishDate := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+		state.Set(1, CreatePostState{
+			Title:        "Заголовок",
+			CheckboxTags: []CheckboxKeyboardItem{{Value: "go", Label: "go", IsSelected: true}},
+			Tags:         []string{"go"},
+			PublishDate:  publishDate,
+		})
+		state.Set(2, CreatePostState{Title: "Другой"})
+
+		got := state.Get(1)
+		if got.Title != "Заголовок" || len(got.CheckboxTags) != 1 || !got.CheckboxTags[0].IsSelected || !got.PublishDate.Equal(publishDate) {
+			t.Errorf("unexpected state: %+v", got)
+		}
+
+		state.Delete(1)
+		if got := state.Get(1); got.Title != "" {
+			t.Errorf("expected empty state after delete, got %+v", got)
+		}
+
+		if got := state.Get(2); got.Title != "Другой" {
+			t.Errorf("expected state of other user to stay, got %+v", got)
+		}
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		state, _ := newState(t, time.Hour)
+
+		if got := state.Get(42); got.Title != "" || got.Tags != nil {
+			t.Errorf("expected empty state, got %+v", got)
+		}
+	})
+
+	t.Run("idle ttl", func(t *testing.T) {
+		state, advance := newState(t, time.Hour)
+
+		state.Set(1, CreatePostState{Title: "Активный"})
+		state.Set(2, CreatePostState{Title: "Брошенный"})
+
+		// Reading extends the ttl of the active conversation.
+		advance(45 * time.Minute)
+		if got := state.Get(1); got.Title != "Активный" {
+			t.Fatalf("expected state to live, got %+v", got)
+		}
+
+		advance(45 * time.Minute)
+		if got := state.Get(1); got.Title != "Активный" {
+			t.Errorf("expected read state to live, got %+v", got)
+		}
+
+		if got := state.Get(2); got.Title != "" {
+			t.Errorf("expected abandoned state to expire, got %+v", got)
+		}
+	})
+}
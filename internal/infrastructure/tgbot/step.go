@@ -1,11 +1,16 @@
 package tgbot
 
 const (
-	StepAwaitingTitle       = "awaitingTitle"
-	StepAwaitingContent     = "awaitingContent"
-	StepAwaitingTags        = "awaitingTags"
-	StepAwaitingSources     = "awaitingSources"
-	StepAwaitingPublishDate = "awaitingPublishDate"
+	StepAwaitingTitle            = "awaitingTitle"
+	StepAwaitingContent          = "awaitingContent"
+	StepAwaitingMedia            = "awaitingMedia"
+	StepAwaitingTags             = "awaitingTags"
+	StepAwaitingSources          = "awaitingSources"
+	StepAwaitingTelegramChannels = "awaitingTelegramChannels"
+	StepAwaitingCategory         = "awaitingCategory"
+	StepAwaitingPublishDate      = "awaitingPublishDate"
+	StepAwaitingConfirmation     = "awaitingConfirmation"
+	StepAwaitingTagName          = "awaitingTagName"
 )
 
 const NextStepButton = "Продолжить"
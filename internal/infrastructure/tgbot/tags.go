@@ -0,0 +1,344 @@
+package tgbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/kostromin59/poster/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+type TagsRepository interface {
+	FindAllWithCount(ctx context.Context) ([]models.TagUsage, error)
+	Rename(ctx context.Context, tag, newTag models.Tag) error
+	Merge(ctx context.Context, tag, target models.Tag) error
+	Delete(ctx context.Context, tag models.Tag) error
+}
+
+// TagsState is the selected tag and the shown lists of tags. Callback data has only indexes
+// of the lists, tag names may not fit into 64 bytes of the callback data or contain the separator.
+type TagsState struct {
+	Tag  string
+	Tags []string
+	// Targets are the tags the selected tag can be merged into.
+	Targets []string
+}
+
+type Tags struct {
+	bot   *telebot.Bot
+	step  Step
+	state State[TagsState]
+	repo  TagsRepository
+}
+
+func NewTags(bot *telebot.Bot, step Step, state State[TagsState], repo TagsRepository) *Tags {
+	return &Tags{
+		bot:   bot,
+		step:  step,
+		state: state,
+		repo:  repo,
+	}
+}
+
+const tagsStaleText = "Список тегов устарел! Откройте его заново: /tags"
+
+const (
+	actionTagsList         = "actionTagsList"
+	actionTagOpen          = "actionTagOpen"
+	actionTagShow          = "actionTagShow"
+	actionTagRename        = "actionTagRename"
+	actionTagMerge         = "actionTagMerge"
+	actionTagMergeInto     = "actionTagMergeInto"
+	actionTagDelete        = "actionTagDelete"
+	actionTagDeleteConfirm = "actionTagDeleteConfirm"
+)
+
+func (t *Tags) Handler() telebot.HandlerFunc {
+	t.bot.Handle("\f"+actionTagsList, t.list)
+	t.bot.Handle("\f"+actionTagOpen, t.open)
+	t.bot.Handle("\f"+actionTagShow, t.card)
+	t.bot.Handle("\f"+actionTagRename, t.rename)
+	t.bot.Handle("\f"+actionTagMerge, t.merge)
+	t.bot.Handle("\f"+actionTagMergeInto, t.mergeInto)
+	t.bot.Handle("\f"+actionTagDelete, t.delete)
+	t.bot.Handle("\f"+actionTagDeleteConfirm, t.deleteConfirm)
+
+	return func(c telebot.Context) error {
+		ctx := c.Get(ContextKey).(context.Context)
+
+		text, kb, err := t.listMessage(ctx, c.Sender().ID)
+		if err != nil {
+			return err
+		}
+
+		return c.Send(text, kb)
+	}
+}
+
+func (t *Tags) list(c telebot.Context) error {
+	_ = c.Respond()
+
+	ctx := c.Get(ContextKey).(context.Context)
+
+	text, kb, err := t.listMessage(ctx, c.Sender().ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Edit(text, kb)
+}
+
+func (t *Tags) open(c telebot.Context) error {
+	_ = c.Respond()
+
+	state := t.state.Get(c.Sender().ID)
+
+	tag, ok := selectedTag(state.Tags, c.Data())
+	if !ok {
+		return c.Edit(tagsStaleText)
+	}
+
+	state.Tag = tag
+	t.state.Set(c.Sender().ID, state)
+
+	return t.show(c, tag)
+}
+
+// card shows the selected tag again, e.g. by the back button.
+func (t *Tags) card(c telebot.Context) error {
+	_ = c.Respond()
+
+	state := t.state.Get(c.Sender().ID)
+	if state.Tag == "" {
+		return c.Edit(tagsStaleText)
+	}
+
+	return t.show(c, state.Tag)
+}
+
+func (t *Tags) rename(c telebot.Context) error {
+	_ = c.Respond()
+
+	state := t.state.Get(c.Sender().ID)
+	if state.Tag == "" {
+		return c.Edit(tagsStaleText)
+	}
+
+	t.step.Set(c.Sender().ID, StepAwaitingTagName)
+
+	return c.Send(fmt.Sprintf("Введите новое название тега <b>%s</b>:", html.EscapeString(state.Tag)), CancelKeyboard())
+}
+
+func (t *Tags) merge(c telebot.Context) error {
+	_ = c.Respond()
+
+	ctx := c.Get(ContextKey).(context.Context)
+
+	state := t.state.Get(c.Sender().ID)
+	if state.Tag == "" {
+		return c.Edit(tagsStaleText)
+	}
+
+	tags, err := t.repo.FindAllWithCount(ctx)
+	if err != nil && !errors.Is(err, models.ErrTagNotFound) {
+		return err
+	}
+
+	state.Targets = state.Targets[:0]
+
+	kb := t.bot.NewMarkup()
+	rows := make([]telebot.Row, 0, len(tags))
+	for _, tu := range tags {
+		if string(tu.Tag) == state.Tag {
+			continue
+		}
+
+		rows = append(rows, kb.Row(kb.Data(string(tu.Tag), actionTagMergeInto, strconv.Itoa(len(state.Targets)))))
+		state.Targets = append(state.Targets, string(tu.Tag))
+	}
+	rows = append(rows, kb.Row(kb.Data("« Назад", actionTagShow)))
+	kb.Inline(rows...)
+
+	t.state.Set(c.Sender().ID, state)
+
+	return c.Edit(fmt.Sprintf("Выберите тег, с которым нужно объединить <b>%s</b>:", html.EscapeString(state.Tag)), kb)
+}
+
+func (t *Tags) mergeInto(c telebot.Context) error {
+	_ = c.Respond()
+
+	ctx := c.Get(ContextKey).(context.Context)
+
+	state := t.state.Get(c.Sender().ID)
+	target, ok := selectedTag(state.Targets, c.Data())
+	if state.Tag == "" || !ok {
+		return c.Edit(tagsStaleText)
+	}
+
+	if err := t.repo.Merge(ctx, models.Tag(state.Tag), models.Tag(target)); err != nil {
+		if errors.Is(err, models.ErrTagNotFound) {
+			return c.Edit("Тег не найден!")
+		}
+
+		return err
+	}
+
+	t.state.Delete(c.Sender().ID)
+
+	return c.Edit(fmt.Sprintf("Тег <b>%s</b> объединён с <b>%s</b>!", html.EscapeString(state.Tag), html.EscapeString(target)))
+}
+
+func (t *Tags) delete(c telebot.Context) error {
+	_ = c.Respond()
+
+	state := t.state.Get(c.Sender().ID)
+	if state.Tag == "" {
+		return c.Edit(tagsStaleText)
+	}
+
+	kb := t.bot.NewMarkup()
+	kb.Inline(
+		kb.Row(kb.Data("Да, удалить", actionTagDeleteConfirm)),
+		kb.Row(kb.Data("« Назад", actionTagShow)),
+	)
+
+	return c.Edit(fmt.Sprintf("Удалить тег <b>%s</b>?", html.EscapeString(state.Tag)), kb)
+}
+
+func (t *Tags) deleteConfirm(c telebot.Context) error {
+	_ = c.Respond()
+
+	ctx := c.Get(ContextKey).(context.Context)
+
+	state := t.state.Get(c.Sender().ID)
+	if state.Tag == "" {
+		return c.Edit(tagsStaleText)
+	}
+
+	if err := t.repo.Delete(ctx, models.Tag(state.Tag)); err != nil {
+		if errors.Is(err, models.ErrTagInUse) {
+			kb := t.bot.NewMarkup()
+			kb.Inline(kb.Row(kb.Data("« Назад", actionTagShow)))
+
+			return c.Edit("Тег используется в постах, удалить можно только неиспользуемый тег. Объедините его с другим тегом.", kb)
+		}
+
+		if errors.Is(err, models.ErrTagNotFound) {
+			return c.Edit("Тег не найден!")
+		}
+
+		return err
+	}
+
+	t.state.Delete(c.Sender().ID)
+
+	return c.Edit(fmt.Sprintf("Тег <b>%s</b> удалён!", html.EscapeString(state.Tag)))
+}
+
+func (t *Tags) TextAwaitingTagNameHandler() telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		if t.step.Get(c.Sender().ID) != StepAwaitingTagName {
+			return nil
+		}
+
+		ctx := c.Get(ContextKey).(context.Context)
+
+		newTag := strings.TrimSpace(c.Message().Text)
+		if newTag == "" {
+			return c.Reply("Название тега не может быть пустым!")
+		}
+
+		state := t.state.Get(c.Sender().ID)
+		if err := t.repo.Rename(ctx, models.Tag(state.Tag), models.Tag(newTag)); err != nil {
+			if errors.Is(err, models.ErrTagAlreadyExists) {
+				return c.Reply("Такой тег уже существует! Введите другое название или объедините теги.")
+			}
+
+			if !errors.Is(err, models.ErrTagNotFound) {
+				return err
+			}
+
+			t.step.Delete(c.Sender().ID)
+			t.state.Delete(c.Sender().ID)
+
+			return c.Send("Тег не найден!", &telebot.ReplyMarkup{RemoveKeyboard: true})
+		}
+
+		t.step.Delete(c.Sender().ID)
+		t.state.Delete(c.Sender().ID)
+
+		kb := &telebot.ReplyMarkup{RemoveKeyboard: true}
+
+		return c.Send(fmt.Sprintf("Тег <b>%s</b> переименован в <b>%s</b>!", html.EscapeString(state.Tag), html.EscapeString(newTag)), kb)
+	}
+}
+
+// show edits the message to the tag with the number of posts and actions.
+func (t *Tags) show(c telebot.Context, tag string) error {
+	ctx := c.Get(ContextKey).(context.Context)
+
+	tags, err := t.repo.FindAllWithCount(ctx)
+	if err != nil && !errors.Is(err, models.ErrTagNotFound) {
+		return err
+	}
+
+	for _, tu := range tags {
+		if string(tu.Tag) != tag {
+			continue
+		}
+
+		kb := t.bot.NewMarkup()
+		kb.Inline(
+			kb.Row(kb.Data("Переименовать", actionTagRename)),
+			kb.Row(kb.Data("Объединить с другим тегом", actionTagMerge)),
+			kb.Row(kb.Data("Удалить", actionTagDelete)),
+			kb.Row(kb.Data("« Назад", actionTagsList)),
+		)
+
+		return c.Edit(fmt.Sprintf("Тег <b>%s</b>\nПостов: %d", html.EscapeString(tag), tu.PostsCount), kb)
+	}
+
+	return c.Edit("Тег не найден!")
+}
+
+// selectedTag returns the tag of the shown list by the index from the callback data.
+func selectedTag(tags []string, data string) (string, bool) {
+	i, err := strconv.Atoi(data)
+	if err != nil || i < 0 || i >= len(tags) {
+		return "", false
+	}
+
+	return tags[i], true
+}
+
+// listMessage returns all tags, the tags are kept in the state of the user for the callback data.
+func (t *Tags) listMessage(ctx context.Context, userID int64) (string, *telebot.ReplyMarkup, error) {
+	tags, err := t.repo.FindAllWithCount(ctx)
+	if err != nil {
+		if errors.Is(err, models.ErrTagNotFound) {
+			return "Тегов пока нет!", nil, nil
+		}
+
+		return "", nil, err
+	}
+
+	state := TagsState{Tags: make([]string, len(tags))}
+
+	kb := t.bot.NewMarkup()
+	rows := make([]telebot.Row, len(tags))
+	for i, tu := range tags {
+		state.Tags[i] = string(tu.Tag)
+
+		label := fmt.Sprintf("%s (%d)", tu.Tag, tu.PostsCount)
+		rows[i] = kb.Row(kb.Data(label, actionTagOpen, strconv.Itoa(i)))
+	}
+	kb.Inline(rows...)
+
+	t.state.Set(userID, state)
+
+	return "Теги (в скобках количество постов):", kb, nil
+}
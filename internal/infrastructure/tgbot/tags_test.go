@@ -0,0 +1,79 @@
+package tgbot
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kostromin59/poster/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+type tagsRepoStub struct {
+	tags   []models.TagUsage
+	merged [][2]models.Tag
+}
+
+func (r *tagsRepoStub) FindAllWithCount(context.Context) ([]models.TagUsage, error) {
+	return r.tags, nil
+}
+
+func (r *tagsRepoStub) Rename(context.Context, models.Tag, models.Tag) error { return nil }
+
+func (r *tagsRepoStub) Merge(_ context.Context, tag, target models.Tag) error {
+	r.merged = append(r.merged, [2]models.Tag{tag, target})
+	return nil
+}
+
+func (r *tagsRepoStub) Delete(context.Context, models.Tag) error { return nil }
+
+func TestTags(t *testing.T) {
+	bot, err := telebot.NewBot(telebot.Settings{Offline: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	long := models.Tag(strings.Repeat("очень длинный тег|", 5))
+
+	repo := &tagsRepoStub{tags: []models.TagUsage{
+		{Tag: long, PostsCount: 2},
+		{Tag: "<go>", PostsCount: 1},
+	}}
+
+	tags := NewTags(bot, NewLocalState[string](time.Hour), NewLocalState[TagsState](time.Hour), repo)
+	handler := tags.Handler()
+
+	call := func(handler telebot.HandlerFunc, data string) *fakeContext {
+		t.Helper()
+
+		c := &fakeContext{data: data}
+		if err := handler(c); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+		checkCallbacks(t, c)
+
+		return c
+	}
+
+	call(handler, "")
+
+	if c := call(tags.open, "1"); len(c.edits) != 1 || !strings.HasPrefix(c.edits[0], "Тег <b>&lt;go&gt;</b>") {
+		t.Fatalf("expected the escaped tag, got %q", c.edits)
+	}
+
+	call(tags.merge, "")
+
+	if c := call(tags.mergeInto, "0"); len(c.edits) != 1 || strings.Contains(c.edits[0], "<go>") {
+		t.Errorf("expected the escaped tags, got %q", c.edits)
+	}
+
+	if !slices.Equal(repo.merged, [][2]models.Tag{{"<go>", long}}) {
+		t.Errorf("unexpected merged tags: %v", repo.merged)
+	}
+
+	if c := call(tags.open, "1"); len(c.edits) != 1 || !strings.HasPrefix(c.edits[0], "Список тегов устарел") {
+		t.Errorf("expected the stale list after merge, got %q", c.edits)
+	}
+}
@@ -0,0 +1,148 @@
+package tgbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/kostromin59/poster/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+type WebhooksSourceRepository interface {
+	FindAllConfigs(ctx context.Context) ([]models.SourceConfig, error)
+}
+
+type WebhooksDeliveryRepository interface {
+	FindLast(ctx context.Context, source models.Source, limit uint64) ([]models.WebhookDelivery, error)
+}
+
+type WebhookTester interface {
+	Test(ctx context.Context) error
+}
+
+// WebhookTesterFactory creates a tester of the webhook source.
+type WebhookTesterFactory func(source models.SourceConfig) (WebhookTester, error)
+
+const webhooksDeliveriesLimit = 10
+
+const (
+	actionWebhookOpen = "actionWebhookOpen"
+	actionWebhookTest = "actionWebhookTest"
+)
+
+type Webhooks struct {
+	bot          *telebot.Bot
+	sourceRepo   WebhooksSourceRepository
+	deliveryRepo WebhooksDeliveryRepository
+	newTester    WebhookTesterFactory
+}
+
+func NewWebhooks(bot *telebot.Bot, sourceRepo WebhooksSourceRepository, deliveryRepo WebhooksDeliveryRepository, newTester WebhookTesterFactory) *Webhooks {
+	return &Webhooks{
+		bot:          bot,
+		sourceRepo:   sourceRepo,
+		deliveryRepo: deliveryRepo,
+		newTester:    newTester,
+	}
+}
+
+func (w *Webhooks) Handler() telebot.HandlerFunc {
+	w.bot.Handle("\f"+actionWebhookOpen, func(c telebot.Context) error {
+		_ = c.Respond()
+
+		ctx := c.Get(ContextKey).(context.Context)
+
+		source := models.Source(c.Data())
+
+		deliveries, err := w.deliveryRepo.FindLast(ctx, source, webhooksDeliveriesLimit)
+		if err != nil && !errors.Is(err, models.ErrWebhookDeliveryNotFound) {
+			return err
+		}
+
+		msg := &strings.Builder{}
+		fmt.Fprintf(msg, "Вебхук <b>%s</b>\n\n", html.EscapeString(string(source)))
+
+		if len(deliveries) == 0 {
+			msg.WriteString("Доставок пока не было.")
+		}
+
+		for _, d := range deliveries {
+			status := "✅"
+			if d.Error != "" {
+				status = "❌"
+			}
+
+			fmt.Fprintf(msg, "%s %s, попытка %d, код %d, %d мс\n",
+				status, d.CreatedAt.Format("2006-01-02 15:04:05"), d.Attempt, d.StatusCode, d.Duration.Milliseconds())
+
+			if d.Error != "" {
+				fmt.Fprintf(msg, "<code>%s</code>\n", html.EscapeString(d.Error))
+			}
+		}
+
+		kb := w.bot.NewMarkup()
+		kb.Inline(kb.Row(kb.Data("Отправить тестовый запрос", actionWebhookTest, string(source))))
+
+		return c.Edit(msg.String(), kb)
+	})
+
+	w.bot.Handle("\f"+actionWebhookTest, func(c telebot.Context) error {
+		_ = c.Respond()
+
+		ctx := c.Get(ContextKey).(context.Context)
+
+		sources, err := w.sourceRepo.FindAllConfigs(ctx)
+		if err != nil && !errors.Is(err, models.ErrSourceNotFound) {
+			return err
+		}
+
+		for _, s := range sources {
+			if string(s.Source) != c.Data() || s.Type != models.SourceTypeWebhook {
+				continue
+			}
+
+			tester, err := w.newTester(s)
+			if err != nil {
+				return c.Send(fmt.Sprintf("Вебхук настроен неверно: <code>%s</code>", html.EscapeString(err.Error())))
+			}
+
+			if err := tester.Test(ctx); err != nil {
+				return c.Send(fmt.Sprintf("Тестовый запрос не доставлен: <code>%s</code>", html.EscapeString(err.Error())))
+			}
+
+			return c.Send("Тестовый запрос доставлен!")
+		}
+
+		return c.Send("Вебхук не найден!")
+	})
+
+	return func(c telebot.Context) error {
+		ctx := c.Get(ContextKey).(context.Context)
+
+		sources, err := w.sourceRepo.FindAllConfigs(ctx)
+		if err != nil && !errors.Is(err, models.ErrSourceNotFound) {
+			return err
+		}
+
+		kb := w.bot.NewMarkup()
+		rows := make([]telebot.Row, 0, len(sources))
+		for _, s := range sources {
+			if s.Type != models.SourceTypeWebhook {
+				continue
+			}
+
+			rows = append(rows, kb.Row(kb.Data(string(s.Source), actionWebhookOpen, string(s.Source))))
+		}
+
+		if len(rows) == 0 {
+			return c.Send("Вебхуки не настроены!")
+		}
+
+		kb.Inline(rows...)
+
+		return c.Send("Вебхуки:", kb)
+	}
+}
@@ -0,0 +1,495 @@
+package tgbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/telebot.v4"
+)
+
+const BackText = "« Назад"
+
+const (
+	wizardUseButtonsText = "Ответ можно выбрать только из кнопок!"
+	wizardStaleText      = "Эта кнопка уже не действует"
+	wizardNoFilesText    = "На этом шаге файлы не принимаются!"
+)
+
+// ErrSkipStep is returned by the prompt of the step which is not needed for the data,
+// the wizard goes to the next step then.
+var ErrSkipStep = errors.New("step is skipped")
+
+// editStepError is returned by a callback to edit another step.
+type editStepError struct {
+	step string
+}
+
+func (e *editStepError) Error() string {
+	return "edit step " + e.step
+}
+
+// EditStep is returned by the callback to go to the step, the wizard comes back
+// to the current step after it instead of passing the following steps.
+func EditStep(name string) error {
+	return &editStepError{step: name}
+}
+
+// ValidationError is an answer which is not accepted by the step. The message is replied
+// to the user and the step stays the same.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func NewValidationError(message string) error {
+	return &ValidationError{Message: message}
+}
+
+// WizardSession is the stored conversation of the wizard.
+type WizardSession[T any] struct {
+	Data T
+	// History is a stack of the passed steps for the back navigation.
+	History []string
+	// Return is the step to come back to after the step edited with EditStep.
+	Return string
+	// Buttons are inline buttons of the last prompt. Callback data has only indexes of the step
+	// and of the button, values such as ids don't fit into 64 bytes of the callback data.
+	Buttons []WizardButton
+}
+
+// WizardPrompt is a question of the step.
+type WizardPrompt struct {
+	Text string
+	// Hint is sent before the text with the reply keyboard, so the text can have inline buttons.
+	Hint string
+	// Buttons are inline buttons of the text, one per row.
+	Buttons []WizardButton
+	// Media are sent before the hint when the step is entered, callbacks edit only the text.
+	Media []telebot.Inputtable
+}
+
+// WizardButton is an inline button. Action is a key of WizardStep.Callbacks.
+type WizardButton struct {
+	Label  string
+	Action string
+	Value  string
+}
+
+// WizardCallback handles an inline button of the step. The prompt is rendered again
+// unless the callback moves the wizard to the next step or returns EditStep.
+type WizardCallback[T any] func(ctx context.Context, c telebot.Context, data *T, value string) (next bool, err error)
+
+type WizardStep[T any] struct {
+	Name string
+	// Prompt returns the question of the step. ErrSkipStep skips the step.
+	Prompt func(ctx context.Context, data *T) (WizardPrompt, error)
+	// Buttons are reply keyboard buttons shown above the back and cancel buttons.
+	Buttons []string
+	// Text stores the answer into data, a ValidationError keeps the step.
+	// Steps without Text are answered only with inline buttons.
+	Text func(ctx context.Context, data *T, text string) error
+	// File stores the file of the message sent at the step, a ValidationError keeps the step.
+	// The prompt is sent again after every file, so files can be sent one by one.
+	// Steps without File don't accept files.
+	File func(ctx context.Context, c telebot.Context, data *T) error
+	// Callbacks handle inline buttons by actions. Buttons of other steps are not accepted.
+	Callbacks map[string]WizardCallback[T]
+	// Next returns the name of the next step, an empty name finishes the wizard.
+	// Without Next the following step is used.
+	Next func(ctx context.Context, data *T) (string, error)
+}
+
+// WizardFinish is called with the collected data after the last step.
+type WizardFinish[T any] func(ctx context.Context, c telebot.Context, data T) error
+
+// Wizard is a conversation of steps. The current step is kept in Step as "<wizard>:<step>",
+// the data and the passed steps are kept in State.
+type Wizard[T any] struct {
+	bot    *telebot.Bot
+	name   string
+	step   Step
+	state  State[WizardSession[T]]
+	steps  []WizardStep[T]
+	finish WizardFinish[T]
+	// files are locks of users: photos of an album come in separate updates which are handled
+	// concurrently, and every file changes the data of the session.
+	files sync.Map
+}
+
+func NewWizard[T any](bot *telebot.Bot, name string, step Step, state State[WizardSession[T]], finish WizardFinish[T], steps ...WizardStep[T]) *Wizard[T] {
+	return &Wizard[T]{
+		bot:    bot,
+		name:   name,
+		step:   step,
+		state:  state,
+		steps:  steps,
+		finish: finish,
+	}
+}
+
+func (w *Wizard[T]) Name() string {
+	return w.name
+}
+
+// Register registers inline buttons of the wizard.
+func (w *Wizard[T]) Register() {
+	w.bot.Handle("\f"+w.unique(), w.handleCallback)
+}
+
+// Handler registers inline buttons of the wizard and returns the handler starting it.
+func (w *Wizard[T]) Handler() telebot.HandlerFunc {
+	w.Register()
+
+	return func(c telebot.Context) error {
+		var data T
+
+		return w.Start(c, data, w.steps[0].Name)
+	}
+}
+
+// Start starts the wizard with the data from the step, e.g. to edit existing data.
+// The wizard must be registered.
+func (w *Wizard[T]) Start(c telebot.Context, data T, step string) error {
+	ctx := c.Get(ContextKey).(context.Context)
+
+	session := WizardSession[T]{Data: data}
+
+	return w.enter(ctx, c, &session, step)
+}
+
+// HandleText handles the answer to the current step of the wizard.
+func (w *Wizard[T]) HandleText(c telebot.Context) error {
+	ctx := c.Get(ContextKey).(context.Context)
+
+	current, ok := w.current(c)
+	if !ok {
+		return nil
+	}
+
+	session := w.state.Get(c.Sender().ID)
+	text := strings.TrimSpace(c.Message().Text)
+
+	if text == BackText {
+		if len(session.History) == 0 {
+			return w.enter(ctx, c, &session, current.Name)
+		}
+
+		previous := session.History[len(session.History)-1]
+		session.History = session.History[:len(session.History)-1]
+		session.Return = ""
+
+		return w.enter(ctx, c, &session, previous)
+	}
+
+	if current.Text == nil {
+		return c.Reply(wizardUseButtonsText)
+	}
+
+	if err := current.Text(ctx, &session.Data, text); err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			return c.Reply(validationErr.Message)
+		}
+
+		return err
+	}
+
+	return w.advance(ctx, c, &session, current)
+}
+
+// HandleFile handles the file sent at the current step of the wizard.
+func (w *Wizard[T]) HandleFile(c telebot.Context) error {
+	ctx := c.Get(ContextKey).(context.Context)
+
+	current, ok := w.current(c)
+	if !ok {
+		return nil
+	}
+
+	if current.File == nil {
+		return c.Reply(wizardNoFilesText)
+	}
+
+	mu, _ := w.files.LoadOrStore(c.Sender().ID, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+
+	session := w.state.Get(c.Sender().ID)
+
+	if err := current.File(ctx, c, &session.Data); err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			return c.Reply(validationErr.Message)
+		}
+
+		return err
+	}
+
+	prompt, err := current.Prompt(ctx, &session.Data)
+	if err != nil {
+		return err
+	}
+
+	session.Buttons = prompt.Buttons
+	w.state.Set(c.Sender().ID, session)
+
+	if len(prompt.Buttons) == 0 {
+		return c.Send(prompt.Text)
+	}
+
+	return c.Send(prompt.Text, w.inlineMarkup(current.Name, prompt.Buttons))
+}
+
+func (w *Wizard[T]) handleCallback(c telebot.Context) error {
+	ctx := c.Get(ContextKey).(context.Context)
+
+	current, ok := w.current(c)
+	if !ok {
+		return c.Respond(&telebot.CallbackResponse{Text: wizardStaleText})
+	}
+
+	stepArg, buttonArg, _ := strings.Cut(c.Data(), "|")
+	if stepArg != strconv.Itoa(w.index(current.Name)) {
+		return c.Respond(&telebot.CallbackResponse{Text: wizardStaleText})
+	}
+
+	session := w.state.Get(c.Sender().ID)
+
+	i, err := strconv.Atoi(buttonArg)
+	if err != nil || i < 0 || i >= len(session.Buttons) {
+		return c.Respond(&telebot.CallbackResponse{Text: wizardStaleText})
+	}
+	button := session.Buttons[i]
+
+	callback, ok := current.Callbacks[button.Action]
+	if !ok {
+		return c.Respond(&telebot.CallbackResponse{Text: wizardStaleText})
+	}
+
+	_ = c.Respond()
+
+	next, callbackErr := callback(ctx, c, &session.Data, button.Value)
+
+	var editErr *editStepError
+	if callbackErr != nil && !errors.As(callbackErr, &editErr) {
+		return callbackErr
+	}
+
+	prompt, err := current.Prompt(ctx, &session.Data)
+	if err != nil {
+		return err
+	}
+
+	if editErr != nil {
+		if err := c.Edit(prompt.Text); err != nil {
+			return err
+		}
+
+		session.History = append(session.History, current.Name)
+		session.Return = current.Name
+
+		return w.enter(ctx, c, &session, editErr.step)
+	}
+
+	if next {
+		// Buttons of the passed step are removed.
+		if err := c.Edit(prompt.Text); err != nil {
+			return err
+		}
+
+		return w.advance(ctx, c, &session, current)
+	}
+
+	session.Buttons = prompt.Buttons
+	w.state.Set(c.Sender().ID, session)
+
+	err = c.Edit(prompt.Text, w.inlineMarkup(current.Name, prompt.Buttons))
+	// Callbacks which don't change the data leave the message as it is.
+	if errors.Is(err, telebot.ErrSameMessageContent) || errors.Is(err, telebot.ErrMessageNotModified) {
+		return nil
+	}
+
+	return err
+}
+
+// advance leaves the current step for the next one or finishes the wizard.
+func (w *Wizard[T]) advance(ctx context.Context, c telebot.Context, session *WizardSession[T], current WizardStep[T]) error {
+	next, err := w.next(ctx, session, current)
+	if err != nil {
+		return err
+	}
+
+	if next == "" {
+		return w.complete(ctx, c, session)
+	}
+
+	session.History = append(session.History, current.Name)
+
+	return w.enter(ctx, c, session, next)
+}
+
+// enter asks the question of the step, skipped steps are passed through.
+func (w *Wizard[T]) enter(ctx context.Context, c telebot.Context, session *WizardSession[T], name string) error {
+	const op = "tgbot.Wizard.enter"
+
+	for {
+		st, ok := w.find(name)
+		if !ok {
+			return fmt.Errorf("%s: unknown step %q", op, name)
+		}
+
+		prompt, err := st.Prompt(ctx, &session.Data)
+		if errors.Is(err, ErrSkipStep) {
+			name, err = w.next(ctx, session, st)
+			if err != nil {
+				return err
+			}
+
+			if name == "" {
+				return w.complete(ctx, c, session)
+			}
+
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		session.Buttons = prompt.Buttons
+		w.state.Set(c.Sender().ID, *session)
+		w.step.Set(c.Sender().ID, w.name+":"+st.Name)
+
+		buttons := st.Buttons
+		if len(session.History) > 0 {
+			buttons = append(buttons[:len(buttons):len(buttons)], BackText)
+		}
+		replyMarkup := CancelKeyboardWithButtons(buttons...)
+
+		if err := sendMedia(c, prompt.Media); err != nil {
+			return err
+		}
+
+		if prompt.Hint == "" && len(prompt.Buttons) == 0 {
+			return c.Send(prompt.Text, replyMarkup)
+		}
+
+		if prompt.Hint != "" {
+			if err := c.Send(prompt.Hint, replyMarkup); err != nil {
+				return err
+			}
+		}
+
+		if len(prompt.Buttons) == 0 {
+			return c.Send(prompt.Text)
+		}
+
+		return c.Send(prompt.Text, w.inlineMarkup(st.Name, prompt.Buttons))
+	}
+}
+
+func (w *Wizard[T]) complete(ctx context.Context, c telebot.Context, session *WizardSession[T]) error {
+	if err := w.finish(ctx, c, session.Data); err != nil {
+		return err
+	}
+
+	w.step.Delete(c.Sender().ID)
+	w.state.Delete(c.Sender().ID)
+
+	return nil
+}
+
+func (w *Wizard[T]) next(ctx context.Context, session *WizardSession[T], current WizardStep[T]) (string, error) {
+	if session.Return != "" {
+		name := session.Return
+		session.Return = ""
+
+		return name, nil
+	}
+
+	if current.Next != nil {
+		return current.Next(ctx, &session.Data)
+	}
+
+	for i, st := range w.steps {
+		if st.Name == current.Name && i+1 < len(w.steps) {
+			return w.steps[i+1].Name, nil
+		}
+	}
+
+	return "", nil
+}
+
+// current returns the step of the user if the user is in this wizard.
+func (w *Wizard[T]) current(c telebot.Context) (WizardStep[T], bool) {
+	wizard, name, ok := strings.Cut(w.step.Get(c.Sender().ID), ":")
+	if !ok || wizard != w.name {
+		return WizardStep[T]{}, false
+	}
+
+	return w.find(name)
+}
+
+func (w *Wizard[T]) find(name string) (WizardStep[T], bool) {
+	for _, st := range w.steps {
+		if st.Name == name {
+			return st, true
+		}
+	}
+
+	return WizardStep[T]{}, false
+}
+
+// index returns the index of the step, it is used in callback data instead of the name.
+func (w *Wizard[T]) index(name string) int {
+	for i, st := range w.steps {
+		if st.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// inlineMarkup returns buttons with data "<step index>|<button index>", buttons are kept in the session.
+func (w *Wizard[T]) inlineMarkup(step string, buttons []WizardButton) *telebot.ReplyMarkup {
+	kb := &telebot.ReplyMarkup{}
+
+	stepIndex := strconv.Itoa(w.index(step))
+
+	rows := make([]telebot.Row, len(buttons))
+	for i, b := range buttons {
+		rows[i] = kb.Row(kb.Data(b.Label, w.unique(), stepIndex, strconv.Itoa(i)))
+	}
+	kb.Inline(rows...)
+
+	return kb
+}
+
+func (w *Wizard[T]) unique() string {
+	return "wizard_" + w.name
+}
+
+// textPrompt returns the prompt of the step answered with text.
+func textPrompt[T any](text string) func(context.Context, *T) (WizardPrompt, error) {
+	return func(context.Context, *T) (WizardPrompt, error) {
+		return WizardPrompt{Text: text}, nil
+	}
+}
+
+// nextStepText accepts only the NextStepButton, hint is replied to other text.
+func nextStepText[T any](hint string) func(context.Context, *T, string) error {
+	return func(_ context.Context, _ *T, text string) error {
+		if text != NextStepButton {
+			return NewValidationError(hint)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,531 @@
+package tgbot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kostromin59/poster/internal/models"
+	"gopkg.in/telebot.v4"
+)
+
+// fakeContext records answers of the bot. Methods which are not overridden panic.
+type fakeContext struct {
+	telebot.Context
+
+	text  string
+	data  string
+	sent  []string
+	edits []string
+	// responses are texts of callback responses.
+	responses []string
+	// callbacks are data of the sent inline buttons as Telegram gets them.
+	callbacks []string
+	// media are sent media, an album is recorded as one item.
+	media []any
+	// message overrides the message with the text, e.g. to send a file.
+	message *telebot.Message
+}
+
+func (c *fakeContext) Sender() *telebot.User { return &telebot.User{ID: 1} }
+
+func (c *fakeContext) Message() *telebot.Message {
+	if c.message != nil {
+		return c.message
+	}
+
+	return &telebot.Message{Text: c.text}
+}
+
+func (c *fakeContext) Data() string { return c.data }
+
+func (c *fakeContext) Get(key string) any {
+	if key == ContextKey {
+		return context.Background()
+	}
+
+	return nil
+}
+
+func (c *fakeContext) Send(what any, opts ...any) error {
+	text, ok := what.(string)
+	if !ok {
+		c.media = append(c.media, what)
+		return nil
+	}
+
+	c.sent = append(c.sent, text)
+	c.record(opts)
+	return nil
+}
+
+func (c *fakeContext) SendAlbum(a telebot.Album, _ ...any) error {
+	c.media = append(c.media, a)
+	return nil
+}
+
+func (c *fakeContext) Reply(what any, _ ...any) error {
+	return c.Send(what)
+}
+
+func (c *fakeContext) Edit(what any, opts ...any) error {
+	c.edits = append(c.edits, what.(string))
+	c.record(opts)
+	return nil
+}
+
+// record keeps callback data of inline buttons in the format of telebot: "\f<unique>|<data>".
+func (c *fakeContext) record(opts []any) {
+	for _, opt := range opts {
+		kb, ok := opt.(*telebot.ReplyMarkup)
+		if !ok || kb == nil {
+			continue
+		}
+
+		for _, row := range kb.InlineKeyboard {
+			for _, b := range row {
+				c.callbacks = append(c.callbacks, "\f"+b.Unique+"|"+b.Data)
+			}
+		}
+	}
+}
+
+// checkCallbacks checks that callback data fits into 64 bytes, Telegram rejects the keyboard otherwise.
+func checkCallbacks(t *testing.T, c *fakeContext) {
+	t.Helper()
+
+	for _, data := range c.callbacks {
+		if len(data) > 64 {
+			t.Errorf("callback data %q is %d bytes, the limit is 64", data, len(data))
+		}
+	}
+}
+
+// pressData returns callback data of the shown button of the step with the action and the value.
+func pressData[T any](t *testing.T, w *Wizard[T], step, action, value string) string {
+	t.Helper()
+
+	for i, b := range w.state.Get(1).Buttons {
+		if b.Action == action && b.Value == value {
+			return strconv.Itoa(w.index(step)) + "|" + strconv.Itoa(i)
+		}
+	}
+
+	t.Fatalf("button %s|%s is not shown", action, value)
+	return ""
+}
+
+func (c *fakeContext) Respond(resp ...*telebot.CallbackResponse) error {
+	if len(resp) > 0 {
+		c.responses = append(c.responses, resp[0].Text)
+	}
+
+	return nil
+}
+
+func (c *fakeContext) last() string {
+	if len(c.sent) == 0 {
+		return ""
+	}
+
+	return c.sent[len(c.sent)-1]
+}
+
+type wizardData struct {
+	Name   string
+	Colors []CheckboxKeyboardItem
+	Pet    string
+}
+
+type wizardHarness struct {
+	t      *testing.T
+	wizard *Wizard[wizardData]
+	start  telebot.HandlerFunc
+	step   Step
+	text   telebot.HandlerFunc
+}
+
+func newWizardHarness(t *testing.T, finish WizardFinish[wizardData], steps ...WizardStep[wizardData]) *wizardHarness {
+	bot, err := telebot.NewBot(telebot.Settings{Offline: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	step := NewLocalState[string](time.Hour)
+	wizard := NewWizard(bot, "test", step, NewLocalState[WizardSession[wizardData]](time.Hour), finish, steps...)
+
+	conversations := NewConversations(step)
+	conversations.Add(wizard)
+
+	return &wizardHarness{
+		t:      t,
+		wizard: wizard,
+		start:  wizard.Handler(),
+		step:   step,
+		text:   conversations.Handler(),
+	}
+}
+
+func (h *wizardHarness) send(text string) *fakeContext {
+	h.t.Helper()
+
+	c := &fakeContext{text: text}
+	if err := h.text(c); err != nil {
+		h.t.Fatalf("unexpected error: %q", err)
+	}
+
+	return c
+}
+
+func (h *wizardHarness) press(step, action, value string) *fakeContext {
+	h.t.Helper()
+
+	c := &fakeContext{data: pressData(h.t, h.wizard, step, action, value)}
+	if err := h.wizard.handleCallback(c); err != nil {
+		h.t.Fatalf("unexpected error: %q", err)
+	}
+
+	return c
+}
+
+func TestWizard(t *testing.T) {
+	var finished []wizardData
+
+	h := newWizardHarness(t,
+		func(_ context.Context, c telebot.Context, data wizardData) error {
+			finished = append(finished, data)
+			return c.Send("done")
+		},
+		WizardStep[wizardData]{
+			Name:   "name",
+			Prompt: textPrompt[wizardData]("name?"),
+			Text: func(_ context.Context, data *wizardData, text string) error {
+				if text == "" {
+					return NewValidationError("empty name")
+				}
+
+				data.Name = text
+				return nil
+			},
+		},
+		WizardStep[wizardData]{
+			Name: "colors",
+			Prompt: func(_ context.Context, data *wizardData) (WizardPrompt, error) {
+				if data.Colors == nil {
+					data.Colors = []CheckboxKeyboardItem{{Value: "red", Label: "red"}, {Value: "blue", Label: "blue"}}
+				}
+
+				return WizardPrompt{Hint: "pick colors", Text: "colors?", Buttons: CheckboxWizardButtons(actionToggle, data.Colors)}, nil
+			},
+			Buttons: []string{NextStepButton},
+			Text:    nextStepText[wizardData]("use buttons"),
+			Callbacks: map[string]WizardCallback[wizardData]{
+				actionToggle: func(_ context.Context, _ telebot.Context, data *wizardData, value string) (bool, error) {
+					toggleCheckbox(data.Colors, value)
+					return false, nil
+				},
+			},
+			Next: func(_ context.Context, data *wizardData) (string, error) {
+				if data.Name == "skip" {
+					return "", nil
+				}
+
+				return "skipped", nil
+			},
+		},
+		WizardStep[wizardData]{
+			Name: "skipped",
+			Prompt: func(context.Context, *wizardData) (WizardPrompt, error) {
+				return WizardPrompt{}, ErrSkipStep
+			},
+		},
+		WizardStep[wizardData]{
+			Name: "pet",
+			Prompt: func(context.Context, *wizardData) (WizardPrompt, error) {
+				return WizardPrompt{Text: "pet?", Buttons: []WizardButton{{Label: "cat", Action: "select", Value: "cat"}}}, nil
+			},
+			Callbacks: map[string]WizardCallback[wizardData]{
+				"select": func(_ context.Context, _ telebot.Context, data *wizardData, value string) (bool, error) {
+					data.Pet = value
+					return true, nil
+				},
+			},
+		},
+	)
+
+	start := &fakeContext{}
+	if err := h.start(start); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if start.last() != "name?" {
+		t.Errorf("expected the first prompt but got %q", start.last())
+	}
+
+	if c := h.send(""); c.last() != "empty name" {
+		t.Errorf("expected validation message but got %q", c.last())
+	}
+
+	if c := h.send("Bob"); !slices.Equal(c.sent, []string{"pick colors", "colors?"}) {
+		t.Errorf("expected hint and prompt but got %q", c.sent)
+	}
+
+	if c := h.press("colors", actionToggle, "blue"); !slices.Equal(c.edits, []string{"colors?"}) {
+		t.Errorf("expected the prompt to be edited but got %q", c.edits)
+	}
+
+	if c := h.press("name", actionToggle, "red"); len(c.responses) != 1 || c.responses[0] != wizardStaleText {
+		t.Errorf("expected buttons of other steps to be stale, got %q", c.responses)
+	}
+
+	if c := h.send("text"); c.last() != "use buttons" {
+		t.Errorf("expected hint but got %q", c.last())
+	}
+
+	// Back navigation keeps the data and returns to the previous step.
+	if c := h.send(NextStepButton); c.last() != "pet?" {
+		t.Errorf("expected the skipped step to be passed, got %q", c.last())
+	}
+
+	if c := h.send(BackText); c.last() != "colors?" {
+		t.Errorf("expected the previous step but got %q", c.last())
+	}
+
+	if step := h.step.Get(1); step != "test:colors" {
+		t.Errorf("expected step %q but got %q", "test:colors", step)
+	}
+
+	if c := h.send(NextStepButton); c.last() != "pet?" {
+		t.Errorf("expected the pet step but got %q", c.last())
+	}
+
+	if c := h.send("cat"); c.last() != wizardUseButtonsText {
+		t.Errorf("expected buttons hint but got %q", c.last())
+	}
+
+	if c := h.press("pet", "select", "cat"); c.last() != "done" {
+		t.Errorf("expected the wizard to finish, got %q", c.sent)
+	}
+
+	if len(finished) != 1 {
+		t.Fatalf("expected the wizard to finish once, got %d", len(finished))
+	}
+
+	data := finished[0]
+	if data.Name != "Bob" || data.Pet != "cat" || data.Colors[0].IsSelected || !data.Colors[1].IsSelected {
+		t.Errorf("unexpected data: %+v", data)
+	}
+
+	if step := h.step.Get(1); step != "" {
+		t.Errorf("expected the step to be deleted, got %q", step)
+	}
+
+	if c := h.send("after"); len(c.sent) != 0 {
+		t.Errorf("expected text after the wizard to be ignored, got %q", c.sent)
+	}
+}
+
+type createPostRepoStub struct {
+	created []models.CreatePostDTO
+}
+
+func (r *createPostRepoStub) Create(_ context.Context, dto models.CreatePostDTO) (models.Post, error) {
+	r.created = append(r.created, dto)
+	return models.Post{ID: "post"}, nil
+}
+
+type createPostTagRepoStub struct{}
+
+func (createPostTagRepoStub) FindAll(context.Context) ([]models.Tag, error) {
+	return []models.Tag{"go", "kafka"}, nil
+}
+
+type createPostSourceRepoStub struct{}
+
+func (createPostSourceRepoStub) FindAll(context.Context) ([]models.Source, error) {
+	return []models.Source{"telegram", "website"}, nil
+}
+
+func (createPostSourceRepoStub) FindAllConfigs(context.Context) ([]models.SourceConfig, error) {
+	return []models.SourceConfig{
+		{Source: "telegram", Type: models.SourceTypeTelegram},
+		{Source: "website", Type: models.SourceTypeWebsite},
+	}, nil
+}
+
+// testChannelID is shaped as real ids, they take most of the callback data limit.
+const testChannelID = "019b0000-0000-7000-8000-000000000001"
+
+type createPostChannelRepoStub struct{}
+
+func (createPostChannelRepoStub) FindAll(context.Context) ([]models.TelegramChannel, error) {
+	return []models.TelegramChannel{{ID: testChannelID, Title: "Основной"}}, nil
+}
+
+func (createPostChannelRepoStub) FindByIDs(context.Context, []models.TelegramChannelID) ([]models.TelegramChannel, error) {
+	return []models.TelegramChannel{{ID: testChannelID, Title: "Основной", Footer: "Подписывайтесь!", DefaultTags: []models.Tag{"#news"}}}, nil
+}
+
+type createPostMediaRepoStub struct {
+	created []models.Media
+}
+
+func (r *createPostMediaRepoStub) Create(_ context.Context, filetype, uri string) (models.Media, error) {
+	media := models.Media{
+		ID:       models.MediaID(fmt.Sprintf("019b0000-0000-7000-8000-00000000b%03d", len(r.created)+1)),
+		Filetype: filetype,
+		URI:      uri,
+	}
+	r.created = append(r.created, media)
+
+	return media, nil
+}
+
+type createPostMediaStorageStub struct{}
+
+func (createPostMediaStorageStub) Save(_ context.Context, name string, r io.Reader) (string, error) {
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return "", err
+	}
+
+	return "https://example.com/media/" + name, nil
+}
+
+type createPostCategoryRepoStub struct{}
+
+func (createPostCategoryRepoStub) FindAll(context.Context) ([]models.Category, error) {
+	return nil, models.ErrCategoryNotFound
+}
+
+func TestCreatePost(t *testing.T) {
+	bot, err := telebot.NewBot(telebot.Settings{Offline: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	repo := &createPostRepoStub{}
+	publisher := NewPublisher(bot, 1, "default footer", nil, createPostChannelRepoStub{}, nil)
+	step := NewLocalState[string](time.Hour)
+	cp := NewCreatePost(bot, step, NewLocalState[WizardSession[CreatePostState]](time.Hour),
+		repo, createPostTagRepoStub{}, createPostSourceRepoStub{}, createPostChannelRepoStub{}, createPostCategoryRepoStub{},
+		&createPostMediaRepoStub{}, createPostMediaStorageStub{},
+		func(source models.SourceConfig) (CreatePostPreviewer, error) {
+			return publisher.WithSource(source)
+		}, time.UTC)
+
+	start := cp.Handler()
+	conversations := NewConversations(step)
+	conversations.Add(cp)
+	text := conversations.Handler()
+
+	send := func(msg string) *fakeContext {
+		t.Helper()
+
+		c := &fakeContext{text: msg}
+		if err := text(c); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		return c
+	}
+
+	press := func(step, action, value string) *fakeContext {
+		t.Helper()
+
+		c := &fakeContext{data: pressData(t, cp.wizard, step, action, value)}
+		if err := cp.wizard.handleCallback(c); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		return c
+	}
+
+	if err := start(&fakeContext{}); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	send("Заголовок")
+
+	if c := send("Содержание"); c.last() != "Медиафайлов пока нет." {
+		t.Errorf("expected the media step, got %q", c.sent)
+	}
+
+	send(NextStepButton)
+	press(StepAwaitingTags, actionToggle, "kafka")
+	send("новый, , тег ")
+	press(StepAwaitingSources, actionToggle, "telegram")
+
+	if c := send(NextStepButton); !strings.HasPrefix(c.last(), "Выберите каналы") {
+		t.Errorf("expected telegram channels for the telegram source, got %q", c.sent)
+	} else {
+		checkCallbacks(t, c)
+	}
+
+	checkCallbacks(t, press(StepAwaitingTelegramChannels, actionToggle, testChannelID))
+
+	// There are no categories, so the publish date is asked next.
+	if c := send(NextStepButton); !strings.HasPrefix(c.last(), "Введите дату") {
+		t.Errorf("expected the publish date step, got %q", c.sent)
+	}
+
+	if c := send("завтра"); c.last() != "Не удалось разобрать дату публикации!" {
+		t.Errorf("expected date validation, got %q", c.sent)
+	}
+
+	// The preview is rendered with the template, the footer and the default tags of the channel.
+	preview := "<b>Заголовок</b>\n\nСодержание\n\nновый тег kafka #news\n\nПодписывайтесь!"
+	if c := send("2025-05-01 10:00"); c.last() != preview {
+		t.Errorf("expected the preview, got %q", c.sent)
+	}
+
+	if len(repo.created) != 0 {
+		t.Fatalf("expected the post to be created only after the confirmation")
+	}
+
+	if c := press(StepAwaitingConfirmation, actionTestCopy, ""); !slices.Equal(c.sent, []string{preview}) {
+		t.Errorf("expected the test copy, got %q", c.sent)
+	}
+
+	if c := press(StepAwaitingConfirmation, actionEdit, StepAwaitingTitle); c.last() != "Введите заголовок:" {
+		t.Errorf("expected the title step, got %q", c.sent)
+	}
+
+	// The wizard comes back to the preview after the edited step.
+	if c := send("Новый заголовок"); !strings.HasPrefix(c.last(), "<b>Новый заголовок</b>") {
+		t.Errorf("expected the preview, got %q", c.sent)
+	}
+
+	if c := press(StepAwaitingConfirmation, actionConfirm, ""); c.last() != "Пост создан!" {
+		t.Errorf("expected the post to be created, got %q", c.sent)
+	}
+
+	if len(repo.created) != 1 {
+		t.Fatalf("expected 1 created post but got %d", len(repo.created))
+	}
+
+	dto := repo.created[0]
+	if dto.Title != "Новый заголовок" || dto.Content != "Содержание" {
+		t.Errorf("unexpected post: %+v", dto)
+	}
+
+	if !slices.Equal(dto.Tags, []models.Tag{"новый", "тег", "kafka"}) {
+		t.Errorf("unexpected tags: %v", dto.Tags)
+	}
+
+	if !slices.Equal(dto.Sources, []models.Source{"telegram"}) {
+		t.Errorf("unexpected sources: %v", dto.Sources)
+	}
+
+	if !slices.Equal(dto.TelegramChannels, []models.TelegramChannelID{testChannelID}) {
+		t.Errorf("unexpected telegram channels: %v", dto.TelegramChannels)
+	}
+
+	if !dto.PublishDate.Equal(time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected publish date: %v", dto.PublishDate)
+	}
+}
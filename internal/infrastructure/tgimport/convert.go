@@ -0,0 +1,134 @@
+package tgimport
+
+import (
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kostromin59/poster/internal/models"
+)
+
+// maxTitleLength limits the title taken from the first line of the message.
+const maxTitleLength = 100
+
+var (
+	trailingSpaceRe = regexp.MustCompile(`[ \t]+\n`)
+	manyBreakRe     = regexp.MustCompile(`\n{3,}`)
+)
+
+var entityTags = map[string]string{
+	"bold":          "b",
+	"italic":        "i",
+	"underline":     "u",
+	"strikethrough": "s",
+	"code":          "code",
+	"pre":           "pre",
+	"spoiler":       "tg-spoiler",
+	"blockquote":    "blockquote",
+}
+
+// convert splits the message into the title and the content in the Telegram HTML.
+// The first line becomes the title, hashtags become tags and are removed from the content.
+func convert(entities []Entity) (title, content string, tags []models.Tag) {
+	text := make([]Entity, 0, len(entities))
+	for _, e := range entities {
+		if e.Type == "hashtag" {
+			if !slices.Contains(tags, models.Tag(e.Text)) {
+				tags = append(tags, models.Tag(e.Text))
+			}
+
+			continue
+		}
+
+		text = append(text, e)
+	}
+
+	text = trimLeft(text)
+
+	var head, body []Entity
+	for i, e := range text {
+		n := strings.Index(e.Text, "\n")
+		if n == -1 {
+			head = append(head, e)
+			continue
+		}
+
+		head = append(head, Entity{Type: e.Type, Text: e.Text[:n], Href: e.Href})
+		body = append(body, Entity{Type: e.Type, Text: e.Text[n+1:], Href: e.Href})
+		body = append(body, text[i+1:]...)
+
+		break
+	}
+
+	title = strings.TrimSpace(plain(head))
+	if utf8.RuneCountInString(title) <= maxTitleLength {
+		return title, clean(render(body)), tags
+	}
+
+	runes := []rune(title)
+	title = strings.TrimSpace(string(runes[:maxTitleLength-1])) + "…"
+
+	return title, clean(render(text)), tags
+}
+
+// trimLeft removes leading whitespace of the text.
+func trimLeft(entities []Entity) []Entity {
+	for i, e := range entities {
+		trimmed := strings.TrimLeft(e.Text, " \t\n")
+		if trimmed == "" {
+			continue
+		}
+
+		rest := slices.Clone(entities[i:])
+		rest[0].Text = trimmed
+
+		return rest
+	}
+
+	return nil
+}
+
+func plain(entities []Entity) string {
+	b := &strings.Builder{}
+	for _, e := range entities {
+		b.WriteString(e.Text)
+	}
+
+	return b.String()
+}
+
+func render(entities []Entity) string {
+	b := &strings.Builder{}
+	for _, e := range entities {
+		if e.Text == "" {
+			continue
+		}
+
+		text := html.EscapeString(e.Text)
+
+		if e.Type == "text_link" && e.Href != "" {
+			b.WriteString(`<a href="` + html.EscapeString(e.Href) + `">` + text + "</a>")
+			continue
+		}
+
+		tag, ok := entityTags[e.Type]
+		if !ok {
+			b.WriteString(text)
+			continue
+		}
+
+		b.WriteString("<" + tag + ">" + text + "</" + tag + ">")
+	}
+
+	return b.String()
+}
+
+// clean removes whitespace left after hashtags.
+func clean(content string) string {
+	content = trailingSpaceRe.ReplaceAllString(content, "\n")
+	content = manyBreakRe.ReplaceAllString(content, "\n\n")
+
+	return strings.TrimSpace(content)
+}
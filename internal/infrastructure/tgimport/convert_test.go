@@ -0,0 +1,73 @@
+package tgimport
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kostromin59/poster/internal/models"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		entities []Entity
+		title    string
+		content  string
+		tags     []models.Tag
+	}{
+		{
+			name: "title and content",
+			entities: []Entity{
+				{Type: "bold", Text: "Заголовок"},
+				{Type: "plain", Text: "\nПервый "},
+				{Type: "italic", Text: "абзац"},
+				{Type: "plain", Text: "\n\n"},
+				{Type: "hashtag", Text: "#новости"},
+				{Type: "plain", Text: " "},
+				{Type: "hashtag", Text: "#новости"},
+			},
+			title:   "Заголовок",
+			content: "Первый <i>абзац</i>",
+			tags:    []models.Tag{"#новости"},
+		},
+		{
+			name: "link",
+			entities: []Entity{
+				{Type: "plain", Text: "\n Ссылка\n"},
+				{Type: "text_link", Text: "сюда", Href: "https://example.com/?a=1&b=2"},
+			},
+			title:   "Ссылка",
+			content: `<a href="https://example.com/?a=1&amp;b=2">сюда</a>`,
+		},
+		{
+			name:     "single line",
+			entities: []Entity{{Type: "plain", Text: "Только <заголовок>"}},
+			title:    "Только <заголовок>",
+		},
+		{
+			name:     "long line",
+			entities: []Entity{{Type: "plain", Text: strings.Repeat("а", 150)}},
+			title:    strings.Repeat("а", 99) + "…",
+			content:  strings.Repeat("а", 150),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, content, tags := convert(tt.entities)
+
+			if title != tt.title {
+				t.Errorf("expected title %q, got %q", tt.title, title)
+			}
+
+			if content != tt.content {
+				t.Errorf("expected content %q, got %q", tt.content, content)
+			}
+
+			if !slices.Equal(tags, tt.tags) {
+				t.Errorf("expected tags %v, got %v", tt.tags, tags)
+			}
+		})
+	}
+}
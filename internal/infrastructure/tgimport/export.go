@@ -0,0 +1,136 @@
+// Package tgimport imports channel history from the Telegram Desktop export (result.json).
+package tgimport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// notIncludedPrefix is written instead of the file path when the file was not exported.
+const notIncludedPrefix = "(File not included."
+
+// Export is the content of result.json.
+type Export struct {
+	Name     string    `json:"name"`
+	Type     string    `json:"type"`
+	ID       int64     `json:"id"`
+	Messages []Message `json:"messages"`
+}
+
+// ChatID returns the chat id of the channel in Bot API format.
+func (e Export) ChatID() int64 {
+	return -1_000_000_000_000 - e.ID
+}
+
+type Message struct {
+	ID           int64           `json:"id"`
+	Type         string          `json:"type"`
+	Date         string          `json:"date"`
+	DateUnixtime string          `json:"date_unixtime"`
+	Text         json.RawMessage `json:"text"`
+	TextEntities []Entity        `json:"text_entities"`
+	Photo        string          `json:"photo"`
+	File         string          `json:"file"`
+	MimeType     string          `json:"mime_type"`
+}
+
+// Entity is a part of the message text with its formatting.
+type Entity struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	Href string `json:"href,omitempty"`
+}
+
+func ReadExport(filename string) (Export, error) {
+	const op = "tgimport.ReadExport"
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Export{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var export Export
+	if err := json.Unmarshal(data, &export); err != nil {
+		return Export{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return export, nil
+}
+
+// Entities returns the text of the message split into entities.
+// Old exports have no text_entities and keep them in text as a string or an array of strings and entities.
+func (m Message) Entities() ([]Entity, error) {
+	if len(m.TextEntities) != 0 {
+		return m.TextEntities, nil
+	}
+
+	if len(m.Text) == 0 {
+		return nil, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(m.Text, &text); err == nil {
+		if text == "" {
+			return nil, nil
+		}
+
+		return []Entity{{Type: "plain", Text: text}}, nil
+	}
+
+	var parts []json.RawMessage
+	if err := json.Unmarshal(m.Text, &parts); err != nil {
+		return nil, err
+	}
+
+	entities := make([]Entity, 0, len(parts))
+	for _, p := range parts {
+		var s string
+		if err := json.Unmarshal(p, &s); err == nil {
+			entities = append(entities, Entity{Type: "plain", Text: s})
+			continue
+		}
+
+		var e Entity
+		if err := json.Unmarshal(p, &e); err != nil {
+			return nil, err
+		}
+
+		entities = append(entities, e)
+	}
+
+	return entities, nil
+}
+
+// PublishDate returns the date of the message. Exports without unixtime have the date in the local time of the exporter.
+func (m Message) PublishDate(loc *time.Location) (time.Time, error) {
+	if m.DateUnixtime != "" {
+		sec, err := strconv.ParseInt(m.DateUnixtime, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return time.Unix(sec, 0), nil
+	}
+
+	if m.Date == "" {
+		return time.Time{}, errors.New("message has no date")
+	}
+
+	return time.ParseInLocation("2006-01-02T15:04:05", m.Date, loc)
+}
+
+// MediaPath returns the path of the exported media relative to the export directory.
+func (m Message) MediaPath() string {
+	for _, p := range []string{m.Photo, m.File} {
+		if p != "" && !strings.HasPrefix(p, notIncludedPrefix) {
+			return p
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,269 @@
+package tgimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type PostRepository interface {
+	Create(ctx context.Context, dto models.CreatePostDTO) (models.Post, error)
+	FindByID(ctx context.Context, id models.PostID) (models.Post, error)
+}
+
+type PublicationRepository interface {
+	FindByExternalID(ctx context.Context, source models.Source, target, externalID string) (models.Publication, error)
+	Save(ctx context.Context, publication models.Publication) error
+}
+
+type MediaStorage interface {
+	Save(ctx context.Context, name string, r io.Reader) (string, error)
+}
+
+// postNamespace is the namespace of ids of imported posts. The id is derived from the chat and the message,
+// so the message is imported into the same post on every run.
+var postNamespace = uuid.MustParse("45a6081c-9df6-4a16-a731-5cebf3983230")
+
+type Config struct {
+	// Sources of imported posts. The telegram source is always added.
+	Sources []models.Source
+	// ChatID overrides the chat id of the channel from the export.
+	ChatID   int64
+	Location *time.Location
+}
+
+// Stats is a result of the import.
+type Stats struct {
+	Imported int
+	Skipped  int
+}
+
+type Importer struct {
+	postRepo        PostRepository
+	publicationRepo PublicationRepository
+	storage         MediaStorage
+}
+
+func NewImporter(postRepo PostRepository, publicationRepo PublicationRepository, storage MediaStorage) *Importer {
+	return &Importer{
+		postRepo:        postRepo,
+		publicationRepo: publicationRepo,
+		storage:         storage,
+	}
+}
+
+// Import creates posts from the export in the directory. Every post is marked as published to the channel,
+// so messages imported before are skipped on the next run. Posts have ids derived from the messages:
+// a post created before a failure is only marked as published on the next run, its media are not copied again.
+func (i *Importer) Import(ctx context.Context, dir string, cfg Config) (Stats, error) {
+	const op = "tgimport.Importer.Import"
+
+	log := logging.FromContext(ctx).With(slog.String("op", op))
+
+	export, err := ReadExport(filepath.Join(dir, "result.json"))
+	if err != nil {
+		return Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+
+	chatID := cfg.ChatID
+	if chatID == 0 {
+		chatID = export.ChatID()
+	}
+
+	target := strconv.FormatInt(chatID, 10)
+
+	sources := []models.Source{models.SourceTG}
+	for _, s := range cfg.Sources {
+		if s != models.SourceTG {
+			sources = append(sources, s)
+		}
+	}
+
+	var stats Stats
+	for _, group := range groupAlbums(export.Messages) {
+		externalID := strconv.FormatInt(group[0].ID, 10)
+
+		_, err := i.publicationRepo.FindByExternalID(ctx, models.SourceTG, target, externalID)
+		if err == nil {
+			stats.Skipped++
+			continue
+		}
+
+		if !errors.Is(err, models.ErrPublicationNotFound) {
+			return stats, fmt.Errorf("%s: %w", op, err)
+		}
+
+		postID := models.PostID(uuid.NewSHA1(postNamespace, []byte(target+":"+externalID)).String())
+
+		post, err := i.postRepo.FindByID(ctx, postID)
+		if err == nil {
+			if err := i.savePublication(ctx, post, target, externalID); err != nil {
+				return stats, fmt.Errorf("%s: message %s: %w", op, externalID, err)
+			}
+
+			stats.Skipped++
+			continue
+		}
+
+		if !errors.Is(err, models.ErrPostNotFound) {
+			return stats, fmt.Errorf("%s: %w", op, err)
+		}
+
+		dto, err := i.dto(ctx, dir, export.ID, group, cfg.Location)
+		if err != nil {
+			return stats, fmt.Errorf("%s: message %s: %w", op, externalID, err)
+		}
+
+		// Messages without text and exported media, e.g. stickers without files.
+		if dto.Content == "" && dto.Title == "" && len(dto.NewMedia) == 0 {
+			stats.Skipped++
+			continue
+		}
+
+		if dto.Title == "" {
+			dto.Title = "Пост от " + dto.PublishDate.In(cfg.Location).Format("02.01.2006")
+		}
+
+		dto.ID = &postID
+		dto.Sources = sources
+
+		post, err = i.postRepo.Create(ctx, dto)
+		if err != nil {
+			return stats, fmt.Errorf("%s: message %s: %w", op, externalID, err)
+		}
+
+		if err := i.savePublication(ctx, post, target, externalID); err != nil {
+			return stats, fmt.Errorf("%s: message %s: %w", op, externalID, err)
+		}
+
+		log.Debug("message has been imported", slog.String("message_id", externalID), slog.String("post_id", string(post.ID)))
+		stats.Imported++
+	}
+
+	return stats, nil
+}
+
+func (i *Importer) savePublication(ctx context.Context, post models.Post, target, externalID string) error {
+	return i.publicationRepo.Save(ctx, models.Publication{
+		PostID:      post.ID,
+		Source:      models.SourceTG,
+		Target:      target,
+		ExternalID:  externalID,
+		PublishedAt: post.PublishDate,
+	})
+}
+
+func (i *Importer) dto(ctx context.Context, dir string, channelID int64, group []Message, loc *time.Location) (models.CreatePostDTO, error) {
+	var dto models.CreatePostDTO
+
+	publishDate, err := group[0].PublishDate(loc)
+	if err != nil {
+		return dto, err
+	}
+
+	dto.PublishDate = publishDate
+
+	for _, m := range group {
+		entities, err := m.Entities()
+		if err != nil {
+			return dto, err
+		}
+
+		if len(entities) != 0 && dto.Title == "" && dto.Content == "" {
+			dto.Title, dto.Content, dto.Tags = convert(entities)
+		}
+
+		mediaPath := m.MediaPath()
+		if mediaPath == "" {
+			continue
+		}
+
+		media, err := i.copyMedia(ctx, dir, channelID, m, mediaPath)
+		if err != nil {
+			return dto, err
+		}
+
+		dto.NewMedia = append(dto.NewMedia, media)
+	}
+
+	return dto, nil
+}
+
+// copyMedia copies the file of the message into the storage. The media row is created with the post,
+// a file copied before a failure is overwritten by the next run. Paths of result.json are not trusted:
+// files outside of the export directory are not opened.
+func (i *Importer) copyMedia(ctx context.Context, dir string, channelID int64, m Message, mediaPath string) (models.CreateMediaDTO, error) {
+	f, err := os.OpenInRoot(dir, filepath.FromSlash(mediaPath))
+	if err != nil {
+		return models.CreateMediaDTO{}, err
+	}
+	defer f.Close()
+
+	name := path.Join("telegram", strconv.FormatInt(channelID, 10), strconv.FormatInt(m.ID, 10), path.Base(mediaPath))
+
+	uri, err := i.storage.Save(ctx, name, f)
+	if err != nil {
+		return models.CreateMediaDTO{}, err
+	}
+
+	filetype := m.MimeType
+	if filetype == "" {
+		filetype = mime.TypeByExtension(path.Ext(mediaPath))
+	}
+
+	if filetype == "" {
+		filetype = "application/octet-stream"
+	}
+
+	return models.CreateMediaDTO{Filetype: filetype, URI: uri}, nil
+}
+
+// groupAlbums groups messages of albums: the export has a message for every photo of the album
+// and all of them have the same date. Service messages are skipped.
+func groupAlbums(messages []Message) [][]Message {
+	var groups [][]Message
+	for _, m := range messages {
+		if m.Type != "message" {
+			continue
+		}
+
+		if n := len(groups); n != 0 {
+			last := groups[n-1]
+			if m.MediaPath() != "" && last[0].MediaPath() != "" && last[0].Date == m.Date && !(hasText(m) && hasText(last...)) {
+				groups[n-1] = append(last, m)
+				continue
+			}
+		}
+
+		groups = append(groups, []Message{m})
+	}
+
+	return groups
+}
+
+func hasText(messages ...Message) bool {
+	for _, m := range messages {
+		entities, err := m.Entities()
+		if err == nil && plain(entities) != "" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,254 @@
+package tgimport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type postRepoStub struct {
+	created []models.CreatePostDTO
+}
+
+func (r *postRepoStub) Create(_ context.Context, dto models.CreatePostDTO) (models.Post, error) {
+	r.created = append(r.created, dto)
+	return models.Post{ID: *dto.ID, PublishDate: dto.PublishDate}, nil
+}
+
+func (r *postRepoStub) FindByID(_ context.Context, id models.PostID) (models.Post, error) {
+	for _, dto := range r.created {
+		if *dto.ID == id {
+			return models.Post{ID: id, PublishDate: dto.PublishDate}, nil
+		}
+	}
+
+	return models.Post{}, models.ErrPostNotFound
+}
+
+type publicationRepoStub struct {
+	publications []models.Publication
+	// fail is the number of the next saves which fail.
+	fail int
+}
+
+func (r *publicationRepoStub) FindByExternalID(_ context.Context, source models.Source, target, externalID string) (models.Publication, error) {
+	for _, p := range r.publications {
+		if p.Source == source && p.Target == target && p.ExternalID == externalID {
+			return p, nil
+		}
+	}
+
+	return models.Publication{}, models.ErrPublicationNotFound
+}
+
+func (r *publicationRepoStub) Save(_ context.Context, publication models.Publication) error {
+	if r.fail > 0 {
+		r.fail--
+		return errors.New("connection reset")
+	}
+
+	r.publications = append(r.publications, publication)
+	return nil
+}
+
+type storageStub struct {
+	files map[string]string
+}
+
+func (s *storageStub) Save(_ context.Context, name string, r io.Reader) (string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	s.files[name] = string(b)
+
+	return "https://example.com/media/" + name, nil
+}
+
+const testExport = `{
+  "name": "Канал",
+  "type": "public_channel",
+  "id": 1234567890,
+  "messages": [
+    {"id": 1, "type": "service", "date": "2025-01-01T10:00:00", "date_unixtime": "1735725600", "action": "create_channel", "text": ""},
+    {
+      "id": 2, "type": "message", "date": "2025-01-02T10:00:00", "date_unixtime": "1735812000",
+      "text": ["Привет\nмир ", {"type": "hashtag", "text": "#первый"}],
+      "text_entities": [{"type": "plain", "text": "Привет\nмир "}, {"type": "hashtag", "text": "#первый"}]
+    },
+    {
+      "id": 3, "type": "message", "date": "2025-01-03T10:00:00", "date_unixtime": "1735898400",
+      "photo": "photos/photo_1.jpg", "text": "Альбом"
+    },
+    {
+      "id": 4, "type": "message", "date": "2025-01-03T10:00:00", "date_unixtime": "1735898400",
+      "photo": "photos/photo_2.jpg", "text": ""
+    },
+    {
+      "id": 5, "type": "message", "date": "2025-01-04T10:00:00", "date_unixtime": "1735984800",
+      "photo": "(File not included. Change data exporting settings to download.)", "text": ""
+    }
+  ]
+}`
+
+func writeExport(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"result.json":        testExport,
+		"photos/photo_1.jpg": "photo 1",
+		"photos/photo_2.jpg": "photo 2",
+	}
+
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestImporterImport(t *testing.T) {
+	dir := writeExport(t)
+
+	postRepo := &postRepoStub{}
+	publicationRepo := &publicationRepoStub{}
+	storage := &storageStub{files: make(map[string]string)}
+
+	importer := NewImporter(postRepo, publicationRepo, storage)
+
+	cfg := Config{Sources: []models.Source{models.SourceTG, models.SourceWebsite}}
+
+	stats, err := importer.Import(t.Context(), dir, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if stats.Imported != 2 || stats.Skipped != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	if len(postRepo.created) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(postRepo.created))
+	}
+
+	first := postRepo.created[0]
+	if first.Title != "Привет" || first.Content != "мир" || !slices.Equal(first.Tags, []models.Tag{"#первый"}) {
+		t.Errorf("unexpected post %+v", first)
+	}
+
+	if first.PublishDate.Unix() != 1735812000 {
+		t.Errorf("unexpected publish date %s", first.PublishDate)
+	}
+
+	if !slices.Equal(first.Sources, []models.Source{models.SourceTG, models.SourceWebsite}) {
+		t.Errorf("unexpected sources %v", first.Sources)
+	}
+
+	album := postRepo.created[1]
+	expectedMedia := []models.CreateMediaDTO{
+		{Filetype: "image/jpeg", URI: "https://example.com/media/telegram/1234567890/3/photo_1.jpg"},
+		{Filetype: "image/jpeg", URI: "https://example.com/media/telegram/1234567890/4/photo_2.jpg"},
+	}
+	if album.Title != "Альбом" || !slices.Equal(album.NewMedia, expectedMedia) {
+		t.Errorf("unexpected album %+v", album)
+	}
+
+	if storage.files["telegram/1234567890/4/photo_2.jpg"] != "photo 2" {
+		t.Errorf("media has not been copied: %v", storage.files)
+	}
+
+	publication := publicationRepo.publications[0]
+	if publication.Source != models.SourceTG || publication.Target != "-1001234567890" || publication.ExternalID != "2" {
+		t.Errorf("unexpected publication %+v", publication)
+	}
+
+	stats, err = importer.Import(t.Context(), dir, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if stats.Imported != 0 || len(postRepo.created) != 2 {
+		t.Errorf("posts must not be imported twice: %+v", stats)
+	}
+}
+
+func TestImporterImportAfterFailure(t *testing.T) {
+	dir := writeExport(t)
+
+	postRepo := &postRepoStub{}
+	publicationRepo := &publicationRepoStub{fail: 1}
+
+	importer := NewImporter(postRepo, publicationRepo, &storageStub{files: make(map[string]string)})
+
+	if _, err := importer.Import(t.Context(), dir, Config{}); err == nil {
+		t.Fatal("expected error but got nil")
+	}
+
+	if len(postRepo.created) != 1 || len(publicationRepo.publications) != 0 {
+		t.Fatalf("expected the post without the publication, got %d posts and %d publications", len(postRepo.created), len(publicationRepo.publications))
+	}
+
+	stats, err := importer.Import(t.Context(), dir, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if stats.Imported != 1 || len(postRepo.created) != 2 {
+		t.Errorf("the created post must not be imported again: %+v, %d posts", stats, len(postRepo.created))
+	}
+
+	if media := postRepo.created[1].NewMedia; len(media) != 2 {
+		t.Errorf("media must be created once, got %v", media)
+	}
+
+	if len(publicationRepo.publications) != 2 || publicationRepo.publications[0].PostID != *postRepo.created[0].ID {
+		t.Errorf("expected the publication of the created post, got %+v", publicationRepo.publications)
+	}
+}
+
+func TestImporterImportMediaOutsideExport(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "export")
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	export := `{"name": "Канал", "type": "public_channel", "id": 1, "messages": [
+		{"id": 1, "type": "message", "date": "2025-01-02T10:00:00", "date_unixtime": "1735812000", "file": "../secret.txt", "text": "Пост"}
+	]}`
+	if err := os.WriteFile(filepath.Join(dir, "result.json"), []byte(export), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	postRepo := &postRepoStub{}
+	storage := &storageStub{files: make(map[string]string)}
+
+	if _, err := NewImporter(postRepo, &publicationRepoStub{}, storage).Import(t.Context(), dir, Config{}); err == nil {
+		t.Fatal("expected error but got nil")
+	}
+
+	if len(postRepo.created) != 0 || len(storage.files) != 0 {
+		t.Errorf("file outside of the export must not be imported: %d posts, files %v", len(postRepo.created), storage.files)
+	}
+}
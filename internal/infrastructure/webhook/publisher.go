@@ -0,0 +1,272 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/logging"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type DeliveryRepository interface {
+	Create(ctx context.Context, delivery models.WebhookDelivery) error
+}
+
+type PublicationRepository interface {
+	Find(ctx context.Context, postID models.PostID, source models.Source, target string) (models.Publication, error)
+	Save(ctx context.Context, publication models.Publication) error
+}
+
+const (
+	DefaultMaxAttempts = 5
+	DefaultBackoff     = time.Second
+
+	// maxDrainBytes limits the response body read before closing it.
+	maxDrainBytes = 64 << 10
+)
+
+var (
+	ErrEmptyURL    = errors.New("webhook url is empty")
+	ErrEmptySecret = errors.New("webhook secret is empty")
+)
+
+// Config is a config of the webhook source.
+type Config struct {
+	URL         string `json:"url"`
+	Secret      string `json:"secret"`
+	MaxAttempts int    `json:"max_attempts"`
+}
+
+// TestPayload is sent by Test to check the endpoint.
+type TestPayload struct {
+	EventID   string    `json:"event_id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return "unexpected status code " + strconv.Itoa(e.code)
+}
+
+type Publisher struct {
+	client          *http.Client
+	deliveryRepo    DeliveryRepository
+	publicationRepo PublicationRepository
+	source          models.Source
+	cfg             Config
+	backoff         time.Duration
+}
+
+func NewPublisher(client *http.Client, deliveryRepo DeliveryRepository, publicationRepo PublicationRepository) *Publisher {
+	return &Publisher{
+		client:          client,
+		deliveryRepo:    deliveryRepo,
+		publicationRepo: publicationRepo,
+		backoff:         DefaultBackoff,
+	}
+}
+
+// WithSource returns a copy of the publisher configured by the source.
+func (p *Publisher) WithSource(source models.SourceConfig) (*Publisher, error) {
+	const op = "webhook.Publisher.WithSource"
+
+	cfg := Config{
+		MaxAttempts: DefaultMaxAttempts,
+	}
+
+	if len(source.Config) != 0 {
+		if err := json.Unmarshal(source.Config, &cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("%s: %w", op, ErrEmptyURL)
+	}
+
+	// Receivers verify the signature, a signature made with an empty secret can be forged by anyone.
+	if cfg.Secret == "" {
+		return nil, fmt.Errorf("%s: %w", op, ErrEmptySecret)
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+
+	publisher := *p
+	publisher.source = source.Source
+	publisher.cfg = cfg
+
+	return &publisher, nil
+}
+
+func (p *Publisher) Publish(ctx context.Context, post events.PublishedPost) error {
+	const op = "webhook.Publisher.Publish"
+
+	postID := models.PostID(post.Data.ID)
+
+	_, err := p.publicationRepo.Find(ctx, postID, p.source, p.cfg.URL)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, models.ErrPublicationNotFound) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	body, err := json.Marshal(post)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := p.deliver(ctx, post.EventID, &postID, body); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := p.publicationRepo.Save(ctx, models.Publication{
+		PostID:      postID,
+		Source:      p.source,
+		Target:      p.cfg.URL,
+		ExternalID:  post.EventID,
+		PublishedAt: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Test sends the signed ping payload to the endpoint.
+func (p *Publisher) Test(ctx context.Context) error {
+	const op = "webhook.Publisher.Test"
+
+	eventID, err := uuid.NewRandom()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	body, err := json.Marshal(TestPayload{
+		EventID:   eventID.String(),
+		Type:      "ping",
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := p.deliver(ctx, eventID.String(), nil, body); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (p *Publisher) deliver(ctx context.Context, eventID string, postID *models.PostID, body []byte) error {
+	const op = "webhook.Publisher.deliver"
+
+	log := logging.FromContext(ctx).With(slog.String("op", op), slog.String("url", p.cfg.URL))
+
+	var err error
+	for attempt := 1; attempt <= p.cfg.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			timer := time.NewTimer(p.backoff << (attempt - 2))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		startedAt := time.Now()
+		var code int
+		code, err = p.send(ctx, eventID, body)
+
+		delivery := models.WebhookDelivery{
+			Source:     p.source,
+			URL:        p.cfg.URL,
+			EventID:    eventID,
+			PostID:     postID,
+			Attempt:    attempt,
+			StatusCode: code,
+			Duration:   time.Since(startedAt),
+			CreatedAt:  startedAt,
+		}
+
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+
+		if err := p.deliveryRepo.Create(ctx, delivery); err != nil {
+			log.Error("unable to save delivery", slog.String("err", err.Error()))
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		if !isRetryable(err) {
+			return err
+		}
+
+		log.Warn("webhook delivery failed", slog.Int("attempt", attempt), slog.String("err", err.Error()))
+	}
+
+	return err
+}
+
+// send posts the body and returns the status code of the response, zero if there is no response.
+func (p *Publisher) send(ctx context.Context, eventID string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, eventID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(p.cfg.Secret, timestamp, body))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		// The body is drained so the connection can be reused.
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, &statusError{code: resp.StatusCode}
+	}
+
+	return resp.StatusCode, nil
+}
+
+// isRetryable reports whether the request may succeed later:
+// network errors, server errors and rate limiting are retried, other client errors are not.
+func isRetryable(err error) bool {
+	var statusErr *statusError
+	if !errors.As(err, &statusErr) {
+		return true
+	}
+
+	return statusErr.code >= http.StatusInternalServerError || statusErr.code == http.StatusTooManyRequests
+}
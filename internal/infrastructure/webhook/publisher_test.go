@@ -0,0 +1,285 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+type deliveryRepoStub struct {
+	mu         sync.Mutex
+	deliveries []models.WebhookDelivery
+}
+
+func (r *deliveryRepoStub) Create(_ context.Context, delivery models.WebhookDelivery) error {
+	r.mu.Lock()
+	r.deliveries = append(r.deliveries, delivery)
+	r.mu.Unlock()
+
+	return nil
+}
+
+type publicationRepoStub struct {
+	publications map[string]models.Publication
+}
+
+func (r *publicationRepoStub) Find(_ context.Context, postID models.PostID, source models.Source, target string) (models.Publication, error) {
+	p, ok := r.publications[string(postID)+string(source)+target]
+	if !ok {
+		return models.Publication{}, models.ErrPublicationNotFound
+	}
+
+	return p, nil
+}
+
+func (r *publicationRepoStub) Save(_ context.Context, p models.Publication) error {
+	r.publications[string(p.PostID)+string(p.Source)+p.Target] = p
+	return nil
+}
+
+func newTestPublisher(t *testing.T, url string) (*Publisher, *deliveryRepoStub, *publicationRepoStub) {
+	t.Helper()
+
+	deliveryRepo := &deliveryRepoStub{}
+	publicationRepo := &publicationRepoStub{publications: make(map[string]models.Publication)}
+
+	config, err := json.Marshal(Config{URL: url, Secret: "secret", MaxAttempts: 3})
+	if err != nil {
+		t.Fatalf("unable to marshal config: %q", err)
+	}
+
+	publisher, err := NewPublisher(http.DefaultClient, deliveryRepo, publicationRepo).WithSource(models.SourceConfig{
+		Source: "partner",
+		Type:   models.SourceTypeWebhook,
+		Config: config,
+	})
+	if err != nil {
+		t.Fatalf("unable to create publisher: %q", err)
+	}
+
+	publisher.backoff = time.Millisecond
+
+	return publisher, deliveryRepo, publicationRepo
+}
+
+func TestPublisherPublish(t *testing.T) {
+	post := events.PublishedPost{
+		EventID: "event",
+		Data: events.PublishedPostData{
+			ID:    "post",
+			Title: "title",
+		},
+	}
+
+	t.Run("successful", func(t *testing.T) {
+		var received events.PublishedPost
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unable to read body: %q", err)
+			}
+
+			if err := Verify("secret", r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, time.Minute, time.Now()); err != nil {
+				t.Errorf("unexpected signature error: %q", err)
+			}
+
+			if err := json.Unmarshal(body, &received); err != nil {
+				t.Errorf("unable to unmarshal body: %q", err)
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		publisher, deliveryRepo, publicationRepo := newTestPublisher(t, server.URL)
+
+		if err := publisher.Publish(t.Context(), post); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if received.Data.ID != post.Data.ID {
+			t.Errorf("expected post id %q but got %q", post.Data.ID, received.Data.ID)
+		}
+
+		if len(deliveryRepo.deliveries) != 1 {
+			t.Errorf("expected deliveries len %d but got %d", 1, len(deliveryRepo.deliveries))
+		}
+
+		if _, err := publicationRepo.Find(t.Context(), "post", "partner", server.URL); err != nil {
+			t.Errorf("expected publication but got %q", err)
+		}
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		var mu sync.Mutex
+		requests := 0
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			requests++
+			if requests < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		publisher, deliveryRepo, _ := newTestPublisher(t, server.URL)
+
+		if err := publisher.Publish(t.Context(), post); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if len(deliveryRepo.deliveries) != 3 {
+			t.Fatalf("expected deliveries len %d but got %d", 3, len(deliveryRepo.deliveries))
+		}
+
+		if deliveryRepo.deliveries[0].StatusCode != http.StatusBadGateway {
+			t.Errorf("expected status code %d but got %d", http.StatusBadGateway, deliveryRepo.deliveries[0].StatusCode)
+		}
+
+		if deliveryRepo.deliveries[2].StatusCode != http.StatusAccepted {
+			t.Errorf("expected status code %d but got %d", http.StatusAccepted, deliveryRepo.deliveries[2].StatusCode)
+		}
+	})
+
+	t.Run("client error is not retried", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		publisher, deliveryRepo, publicationRepo := newTestPublisher(t, server.URL)
+
+		if err := publisher.Publish(t.Context(), post); err == nil {
+			t.Fatal("expected error but got nil")
+		}
+
+		if len(deliveryRepo.deliveries) != 1 {
+			t.Errorf("expected deliveries len %d but got %d", 1, len(deliveryRepo.deliveries))
+		}
+
+		if _, err := publicationRepo.Find(t.Context(), "post", "partner", server.URL); !errors.Is(err, models.ErrPublicationNotFound) {
+			t.Errorf("expected error %+v but got %+v", models.ErrPublicationNotFound, err)
+		}
+	})
+
+	t.Run("already published", func(t *testing.T) {
+		requests := 0
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+		}))
+		defer server.Close()
+
+		publisher, _, publicationRepo := newTestPublisher(t, server.URL)
+		_ = publicationRepo.Save(t.Context(), models.Publication{PostID: "post", Source: "partner", Target: server.URL})
+
+		if err := publisher.Publish(t.Context(), post); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if requests != 0 {
+			t.Errorf("expected requests %d but got %d", 0, requests)
+		}
+	})
+}
+
+func TestPublisherWithSource(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		err  error
+	}{
+		{name: "empty url", cfg: Config{Secret: "secret"}, err: ErrEmptyURL},
+		{name: "empty secret", cfg: Config{URL: "https://example.com/webhook"}, err: ErrEmptySecret},
+		{name: "successful", cfg: Config{URL: "https://example.com/webhook", Secret: "secret"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := json.Marshal(tt.cfg)
+			if err != nil {
+				t.Fatalf("unable to marshal config: %q", err)
+			}
+
+			_, err = NewPublisher(http.DefaultClient, &deliveryRepoStub{}, &publicationRepoStub{}).WithSource(models.SourceConfig{
+				Source: "partner",
+				Type:   models.SourceTypeWebhook,
+				Config: config,
+			})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected error %+v but got %+v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestPublisherTest(t *testing.T) {
+	var payload TestPayload
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("unable to decode body: %q", err)
+		}
+	}))
+	defer server.Close()
+
+	publisher, deliveryRepo, _ := newTestPublisher(t, server.URL)
+
+	if err := publisher.Test(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if payload.Type != "ping" {
+		t.Errorf("expected payload type %q but got %q", "ping", payload.Type)
+	}
+
+	if len(deliveryRepo.deliveries) != 1 || deliveryRepo.deliveries[0].PostID != nil {
+		t.Errorf("expected one delivery without post but got %+v", deliveryRepo.deliveries)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event_id":"event"}`)
+	now := time.Now()
+	timestamp := now.Unix()
+	signature := Sign("secret", timestamp, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		expected  error
+	}{
+		{name: "valid", secret: "secret", timestamp: timestamp, body: body},
+		{name: "wrong secret", secret: "other", timestamp: timestamp, body: body, expected: ErrInvalidSignature},
+		{name: "modified body", secret: "secret", timestamp: timestamp, body: []byte(`{}`), expected: ErrInvalidSignature},
+		{name: "replayed request", secret: "secret", timestamp: now.Add(-time.Hour).Unix(), body: body, expected: ErrInvalidTimestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, strconv.FormatInt(tt.timestamp, 10), signature, tt.body, 5*time.Minute, now)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("expected error %+v but got %+v", tt.expected, err)
+			}
+		})
+	}
+}
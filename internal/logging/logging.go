@@ -0,0 +1,113 @@
+// Package logging configures slog and carries correlation fields (update, user, event and post ids)
+// in the context, so logs of one request or event can be found together.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/kostromin59/poster/internal/configs"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Correlation fields.
+const (
+	UpdateIDKey = "update_id"
+	UserIDKey   = "user_id"
+	EventIDKey  = "event_id"
+	PostIDKey   = "post_id"
+	TraceIDKey  = "trace_id"
+)
+
+const redacted = "[REDACTED]"
+
+// botTokenRe matches Telegram bot tokens, e.g. in URLs of API errors.
+var botTokenRe = regexp.MustCompile(`\d{5,}:[A-Za-z0-9_-]{30,}`)
+
+type contextKey struct{}
+
+// New creates the logger by the config. Secrets are replaced in all string values and messages.
+func New(w io.Writer, cfg configs.Log, secrets ...string) (*slog.Logger, error) {
+	const op = "logging.New"
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr(secrets),
+	}
+
+	switch cfg.Format {
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("%s: unknown format %q", op, cfg.Format)
+	}
+}
+
+// Redact replaces secrets and bot tokens in the string.
+func Redact(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+
+	return botTokenRe.ReplaceAllString(s, redacted)
+}
+
+func redactAttr(secrets []string) func(groups []string, a slog.Attr) slog.Attr {
+	return func(_ []string, a slog.Attr) slog.Attr {
+		switch a.Value.Kind() {
+		case slog.KindString:
+			return slog.String(a.Key, Redact(a.Value.String(), secrets...))
+		case slog.KindAny:
+			if err, ok := a.Value.Any().(error); ok {
+				return slog.String(a.Key, Redact(err.Error(), secrets...))
+			}
+		}
+
+		return a
+	}
+}
+
+// With returns the context with correlation fields added to the fields of the parent context.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	parent, _ := ctx.Value(contextKey{}).([]slog.Attr)
+
+	fields := make([]slog.Attr, 0, len(parent)+len(attrs))
+	fields = append(fields, parent...)
+	fields = append(fields, attrs...)
+
+	return context.WithValue(ctx, contextKey{}, fields)
+}
+
+// FromContext returns the default logger with correlation fields of the context and the trace id.
+func FromContext(ctx context.Context) *slog.Logger {
+	fields, _ := ctx.Value(contextKey{}).([]slog.Attr)
+
+	args := make([]any, 0, len(fields)+1)
+	for _, f := range fields {
+		args = append(args, f)
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		args = append(args, slog.String(TraceIDKey, sc.TraceID().String()))
+	}
+
+	return slog.Default().With(args...)
+}
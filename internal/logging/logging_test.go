@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/kostromin59/poster/internal/configs"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("json with redacted secrets", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger, err := New(&buf, configs.Log{Level: "info", Format: FormatJSON}, "db-password")
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		token := "123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw1"
+		logger.Debug("hidden")
+		logger.Error("request failed: db-password",
+			slog.String("err", "Post https://api.telegram.org/bot"+token+"/getUpdates: timeout"),
+			slog.Any("cause", errors.New("auth db-password")),
+		)
+
+		out := buf.String()
+		if strings.Contains(out, "hidden") {
+			t.Errorf("debug record is logged on the info level: %s", out)
+		}
+
+		if strings.Contains(out, "db-password") || strings.Contains(out, token) {
+			t.Errorf("secrets are not redacted: %s", out)
+		}
+
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("expected JSON record but got %s", out)
+		}
+
+		if record["cause"] != "auth "+redacted {
+			t.Errorf("expected redacted error but got %v", record["cause"])
+		}
+	})
+
+	t.Run("unknown level", func(t *testing.T) {
+		if _, err := New(&bytes.Buffer{}, configs.Log{Level: "loud", Format: FormatText}); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if _, err := New(&bytes.Buffer{}, configs.Log{Level: "info", Format: "xml"}); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() {
+		slog.SetDefault(defaultLogger)
+	})
+
+	ctx := With(t.Context(), slog.String(EventIDKey, "event"))
+	childCtx := With(ctx, slog.String(PostIDKey, "post"))
+
+	FromContext(childCtx).Info("child")
+	FromContext(ctx).Info("parent")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records but got %q", lines)
+	}
+
+	if !strings.Contains(lines[0], "event_id=event") || !strings.Contains(lines[0], "post_id=post") {
+		t.Errorf("expected correlation fields in %q", lines[0])
+	}
+
+	if strings.Contains(lines[1], "post_id") {
+		t.Errorf("fields of the child context are in the parent record %q", lines[1])
+	}
+}
@@ -0,0 +1,93 @@
+// Package metrics defines Prometheus metrics of the app. Metrics are registered in the default registry
+// and are exposed on /metrics.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "poster"
+
+var (
+	RepositoryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "repository",
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of repository operations.",
+	}, []string{"op"})
+
+	EventsDispatched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "events",
+		Name:      "dispatched_total",
+		Help:      "Number of dispatched events.",
+	}, []string{"topic"})
+
+	EventsConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "events",
+		Name:      "consumed_total",
+		Help:      "Number of consumed events.",
+	}, []string{"topic"})
+
+	EventsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "events",
+		Name:      "failed_total",
+		Help:      "Number of events which have not been produced or handled.",
+	}, []string{"topic"})
+
+	EventHandleDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "events",
+		Name:      "handle_duration_seconds",
+		Help:      "Duration of event handling.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"topic"})
+
+	CronDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "cron",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of cron job runs.",
+	}, []string{"job"})
+
+	CronPostsFound = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cron",
+		Name:      "posts_found_total",
+		Help:      "Number of posts found by cron jobs.",
+	}, []string{"job"})
+
+	CronSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cron",
+		Name:      "skipped_total",
+		Help:      "Number of cron ticks skipped because the job lock is held by another instance.",
+	}, []string{"job"})
+
+	TelegramAPICalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "telegram",
+		Name:      "api_calls_total",
+		Help:      "Number of Telegram Bot API calls. Status is the HTTP status code or error.",
+	}, []string{"method", "status"})
+
+	TelegramAPIDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "telegram",
+		Name:      "api_call_duration_seconds",
+		Help:      "Duration of Telegram Bot API calls, long polling included.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"method"})
+)
+
+// ObserveRepository observes the duration of the repository operation, it is called with defer:
+//
+//	defer metrics.ObserveRepository(op, time.Now())
+func ObserveRepository(op string, start time.Time) {
+	RepositoryDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,7 @@
+package models
+
+import "errors"
+
+var (
+	ErrDatabaseNotEmpty = errors.New("database is not empty")
+)
@@ -0,0 +1,25 @@
+package models
+
+import "errors"
+
+var (
+	ErrCategoryNotFound      = errors.New("category not found")
+	ErrCategoryAlreadyExists = errors.New("category already exists")
+)
+
+type CategoryID ID[Category]
+
+type Category struct {
+	ID       CategoryID
+	ParentID *CategoryID
+	Slug     string
+	Title    string
+	Order    int
+}
+
+type CreateCategoryDTO struct {
+	ParentID *CategoryID
+	Slug     string
+	Title    string
+	Order    int
+}
@@ -13,3 +13,9 @@ type Media struct {
 	Filetype string
 	URI      string
 }
+
+// CreateMediaDTO is media created together with the post, see CreatePostDTO.NewMedia.
+type CreateMediaDTO struct {
+	Filetype string
+	URI      string
+}
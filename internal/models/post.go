@@ -6,19 +6,23 @@ import (
 )
 
 var (
-	ErrPostNotFound = errors.New("post not found")
+	ErrPostNotFound      = errors.New("post not found")
+	ErrPostAlreadyExists = errors.New("post already exists")
+	ErrPostPublished     = errors.New("post has publications")
 )
 
 type PostID ID[Post]
 
 type Post struct {
-	ID          PostID
-	Title       string
-	Content     string
-	PublishDate time.Time
-	Tags        []Tag
-	Sources     []Source
-	Media       []Media
+	ID               PostID
+	Title            string
+	Content          string
+	PublishDate      time.Time
+	Tags             []Tag
+	Sources          []Source
+	Media            []Media
+	Category         *Category
+	TelegramChannels []TelegramChannelID
 }
 
 type PostSearchFilters struct {
@@ -26,13 +30,35 @@ type PostSearchFilters struct {
 	Tags          []string
 	Sources       []string
 	PublishedFrom *time.Time
+	// Category is a category slug. Posts of descendant categories are included too.
+	Category *string
 }
 
 type CreatePostDTO struct {
-	Title       string
-	Content     string
-	PublishDate time.Time
-	Tags        []Tag
-	Sources     []Source
-	Media       []MediaID
+	// ID is set when the post is restored, e.g. from the export. A new id is generated otherwise.
+	ID               *PostID
+	Title            string
+	Content          string
+	PublishDate      time.Time
+	Tags             []Tag
+	Sources          []Source
+	Media            []MediaID
+	Category         *CategoryID
+	TelegramChannels []TelegramChannelID
+	// NewMedia are created in the transaction of the post and follow Media,
+	// so no media rows are left when the post is not created.
+	NewMedia []CreateMediaDTO
+}
+
+// UpdatePostDTO replaces all fields of the post.
+type UpdatePostDTO struct {
+	ID               PostID
+	Title            string
+	Content          string
+	PublishDate      time.Time
+	Tags             []Tag
+	Sources          []Source
+	Media            []MediaID
+	Category         *CategoryID
+	TelegramChannels []TelegramChannelID
 }
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrPublicationNotFound = errors.New("publication not found")
+)
+
+// Publication is a delivery of the post to the target of the source,
+// e.g. a message in the telegram channel.
+type Publication struct {
+	PostID      PostID
+	Source      Source
+	Target      string
+	ExternalID  string
+	PublishedAt time.Time
+}
@@ -1,6 +1,9 @@
 package models
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+)
 
 type Source string
 
@@ -9,6 +12,24 @@ var (
 )
 
 var (
-	SourceTG      Source = "Телеграмм"
-	SourceWebsite Source = "Вебсайт"
+	SourceTG         Source = "Телеграмм"
+	SourceWebsite    Source = "Вебсайт"
+	SourceNewsletter Source = "Рассылка"
 )
+
+// SourceType defines which publisher implementation delivers posts of the source.
+type SourceType string
+
+const (
+	SourceTypeTelegram SourceType = "telegram"
+	SourceTypeWebsite  SourceType = "website"
+	SourceTypeWebhook  SourceType = "webhook"
+	SourceTypeEmail    SourceType = "email"
+	SourceTypeMastodon SourceType = "mastodon"
+)
+
+type SourceConfig struct {
+	Source Source
+	Type   SourceType
+	Config json.RawMessage
+}
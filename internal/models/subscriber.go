@@ -0,0 +1,33 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrSubscriberNotFound      = errors.New("subscriber not found")
+	ErrSubscriberAlreadyExists = errors.New("subscriber already exists")
+)
+
+type SubscriberID ID[Subscriber]
+
+type SubscriberStatus string
+
+const (
+	// SubscriberStatusPending is a subscriber who has not confirmed the email yet.
+	SubscriberStatusPending      SubscriberStatus = "pending"
+	SubscriberStatusActive       SubscriberStatus = "active"
+	SubscriberStatusUnsubscribed SubscriberStatus = "unsubscribed"
+	// SubscriberStatusBounced is a subscriber whose mail server rejected the email permanently.
+	SubscriberStatusBounced SubscriberStatus = "bounced"
+)
+
+type Subscriber struct {
+	ID          SubscriberID
+	Email       string
+	Status      SubscriberStatus
+	Token       string
+	CreatedAt   time.Time
+	ConfirmedAt *time.Time
+}
@@ -3,7 +3,14 @@ package models
 import "errors"
 
 var (
-	ErrTagNotFound = errors.New("tag not found")
+	ErrTagNotFound      = errors.New("tag not found")
+	ErrTagAlreadyExists = errors.New("tag already exists")
+	ErrTagInUse         = errors.New("tag is in use")
 )
 
 type Tag string
+
+type TagUsage struct {
+	Tag        Tag
+	PostsCount int
+}
@@ -0,0 +1,28 @@
+package models
+
+import "errors"
+
+var (
+	ErrTelegramChannelNotFound = errors.New("telegram channel not found")
+)
+
+type TelegramChannelID ID[TelegramChannel]
+
+type TelegramChannel struct {
+	ID          TelegramChannelID
+	ChatID      int64
+	Title       string
+	Footer      string
+	Template    string
+	DefaultTags []Tag
+	Silent      bool
+}
+
+type CreateTelegramChannelDTO struct {
+	ChatID      int64
+	Title       string
+	Footer      string
+	Template    string
+	DefaultTags []Tag
+	Silent      bool
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// WebhookDelivery is a single attempt to deliver a payload to the webhook endpoint.
+type WebhookDelivery struct {
+	Source     Source
+	URL        string
+	EventID    string
+	PostID     *PostID
+	Attempt    int
+	StatusCode int
+	Error      string
+	Duration   time.Duration
+	CreatedAt  time.Time
+}
@@ -0,0 +1,58 @@
+package publishers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/kostromin59/poster/internal/events"
+	"github.com/kostromin59/poster/internal/models"
+)
+
+var (
+	ErrUnknownType = errors.New("unknown publisher type")
+)
+
+type Publisher interface {
+	Publish(ctx context.Context, post events.PublishedPost) error
+}
+
+// Factory creates a publisher of the source from its JSON config.
+type Factory func(source models.SourceConfig) (Publisher, error)
+
+type Registry struct {
+	factories map[models.SourceType]Factory
+	mu        sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[models.SourceType]Factory),
+	}
+}
+
+func (r *Registry) Register(sourceType models.SourceType, factory Factory) {
+	r.mu.Lock()
+	r.factories[sourceType] = factory
+	r.mu.Unlock()
+}
+
+func (r *Registry) New(source models.SourceConfig) (Publisher, error) {
+	const op = "publishers.Registry.New"
+
+	r.mu.RLock()
+	factory, ok := r.factories[source.Type]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%s: %q: %w", op, source.Type, ErrUnknownType)
+	}
+
+	publisher, err := factory(source)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return publisher, nil
+}
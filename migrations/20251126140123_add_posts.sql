@@ -11,5 +11,5 @@ CREATE TABLE IF NOT EXISTS posts (
 
 -- +goose Down
 -- +goose StatementBegin
-SELECT 'down SQL query';
+DROP TABLE IF EXISTS posts;
 -- +goose StatementEnd
\ No newline at end of file
@@ -10,5 +10,5 @@ CREATE TABLE IF NOT EXISTS media (
 
 -- +goose Down
 -- +goose StatementBegin
-SELECT 'down SQL query';
+DROP TABLE IF EXISTS media;
 -- +goose StatementEnd
@@ -7,5 +7,5 @@ CREATE TABLE IF NOT EXISTS tags (
 
 -- +goose Down
 -- +goose StatementBegin
-SELECT 'down SQL query';
+DROP TABLE IF EXISTS tags;
 -- +goose StatementEnd
@@ -13,5 +13,5 @@ CREATE TABLE IF NOT EXISTS posts_tags (
 
 -- +goose Down
 -- +goose StatementBegin
-SELECT 'down SQL query';
+DROP TABLE IF EXISTS posts_tags;
 -- +goose StatementEnd
@@ -13,5 +13,5 @@ CREATE TABLE IF NOT EXISTS posts_sources (
 
 -- +goose Down
 -- +goose StatementBegin
-SELECT 'down SQL query';
+DROP TABLE IF EXISTS posts_sources;
 -- +goose StatementEnd
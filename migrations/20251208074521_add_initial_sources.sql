@@ -6,5 +6,5 @@ INSERT INTO sources (source) VALUES ('Вебсайт') ON CONFLICT (source) DO N
 
 -- +goose Down
 -- +goose StatementBegin
-SELECT 'down SQL query';
+DELETE FROM sources WHERE source IN ('Телеграмм', 'Вебсайт');
 -- +goose StatementEnd
\ No newline at end of file
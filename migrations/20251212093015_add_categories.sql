@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS categories (
+  id UUID NOT NULL DEFAULT uuidv7() PRIMARY KEY,
+  parent_id UUID,
+  slug TEXT NOT NULL UNIQUE,
+  title TEXT NOT NULL,
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  FOREIGN KEY(parent_id) REFERENCES categories(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS categories;
+-- +goose StatementEnd
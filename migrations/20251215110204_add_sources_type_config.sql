@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS type TEXT NOT NULL DEFAULT '';
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS config JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+UPDATE sources SET type = 'telegram' WHERE source = 'Телеграмм';
+UPDATE sources SET type = 'website' WHERE source = 'Вебсайт';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sources DROP COLUMN IF EXISTS config;
+ALTER TABLE sources DROP COLUMN IF EXISTS type;
+-- +goose StatementEnd
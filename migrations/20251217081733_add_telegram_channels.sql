@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS telegram_channels (
+  id UUID NOT NULL DEFAULT uuidv7() PRIMARY KEY,
+  chat_id BIGINT NOT NULL UNIQUE,
+  title TEXT NOT NULL,
+  footer TEXT NOT NULL DEFAULT '',
+  template TEXT NOT NULL DEFAULT '',
+  default_tags TEXT[] NOT NULL DEFAULT '{}',
+  silent BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS telegram_channels;
+-- +goose StatementEnd
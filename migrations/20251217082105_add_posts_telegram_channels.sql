@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS posts_telegram_channels (
+  channel_id UUID NOT NULL,
+  post_id UUID NOT NULL,
+
+  PRIMARY KEY(channel_id, post_id),
+
+  FOREIGN KEY(channel_id) REFERENCES telegram_channels(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  FOREIGN KEY(post_id) REFERENCES posts(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS posts_telegram_channels;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS publications (
+  post_id UUID NOT NULL,
+  source TEXT NOT NULL,
+  target TEXT NOT NULL DEFAULT '',
+  external_id TEXT NOT NULL DEFAULT '',
+  published_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY(post_id, source, target),
+
+  FOREIGN KEY(post_id) REFERENCES posts(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  FOREIGN KEY(source) REFERENCES sources(source) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS publications;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id UUID NOT NULL DEFAULT uuidv7() PRIMARY KEY,
+  source TEXT NOT NULL,
+  url TEXT NOT NULL,
+  event_id TEXT NOT NULL,
+  post_id UUID,
+  attempt INT NOT NULL,
+  status_code INT NOT NULL DEFAULT 0,
+  error TEXT NOT NULL DEFAULT '',
+  duration_ms BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  FOREIGN KEY(source) REFERENCES sources(source) ON DELETE CASCADE ON UPDATE CASCADE,
+  FOREIGN KEY(post_id) REFERENCES posts(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_source_url_idx ON webhook_deliveries (source, url, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS webhook_deliveries;
+-- +goose StatementEnd
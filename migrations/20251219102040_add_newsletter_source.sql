@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+INSERT INTO sources (source, type) VALUES ('Рассылка', 'email') ON CONFLICT (source) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM sources WHERE source = 'Рассылка';
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+// Package migrations embeds goose migrations of the database.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// lockID is an id of the PostgreSQL advisory lock held while migrating.
+const lockID = 4707853913295521081
+
+// NewProvider returns the goose provider of the embedded migrations. Migrations are run under
+// the session advisory lock, so instances started at the same time apply them once.
+func NewProvider(db *sql.DB) (*goose.Provider, error) {
+	const op = "migrations.NewProvider"
+
+	locker, err := lock.NewPostgresSessionLocker(lock.WithLockID(lockID))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, FS, goose.WithSessionLocker(locker))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return provider, nil
+}
@@ -0,0 +1,98 @@
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/kostromin59/poster/migrations"
+	"github.com/kostromin59/poster/pkg/pgcontainer"
+)
+
+func TestMigrationsUpDownUp(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test is integration")
+	}
+
+	pgc := pgcontainer.New(t, pgcontainer.PGContainerConfig{
+		Database: "poster",
+		User:     "poster",
+		Password: "poster",
+	})
+
+	db, err := sql.Open("pgx", pgc.DSN())
+	if err != nil {
+		t.Fatalf("unable to connect to database: %q", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	provider, err := migrations.NewProvider(db)
+	if err != nil {
+		t.Fatalf("unable to create provider: %q", err)
+	}
+
+	schema := func(t *testing.T) []string {
+		t.Helper()
+
+		rows, err := db.QueryContext(t.Context(), `SELECT table_name || '.' || column_name || ':' || data_type
+			FROM information_schema.columns
+			WHERE table_schema = 'public' AND table_name <> 'goose_db_version'
+			ORDER BY table_name, column_name`)
+		if err != nil {
+			t.Fatalf("unable to query schema: %q", err)
+		}
+		defer rows.Close()
+
+		var columns []string
+		for rows.Next() {
+			var c string
+			if err := rows.Scan(&c); err != nil {
+				t.Fatalf("unable to scan column: %q", err)
+			}
+
+			columns = append(columns, c)
+		}
+
+		return columns
+	}
+
+	if _, err := provider.Up(t.Context()); err != nil {
+		t.Fatalf("unable to apply migrations: %q", err)
+	}
+
+	upSchema := schema(t)
+
+	if _, err := provider.DownTo(t.Context(), 0); err != nil {
+		t.Fatalf("unable to roll back migrations: %q", err)
+	}
+
+	if columns := schema(t); len(columns) != 0 {
+		t.Errorf("expected empty schema after rolling back but got %v", columns)
+	}
+
+	if _, err := provider.Up(t.Context()); err != nil {
+		t.Fatalf("unable to apply migrations again: %q", err)
+	}
+
+	reupSchema := schema(t)
+	if len(reupSchema) != len(upSchema) {
+		t.Fatalf("expected %d columns but got %d", len(upSchema), len(reupSchema))
+	}
+
+	for i := range upSchema {
+		if upSchema[i] != reupSchema[i] {
+			t.Errorf("expected column %q but got %q", upSchema[i], reupSchema[i])
+		}
+	}
+
+	var sources int
+	if err := db.QueryRowContext(t.Context(), `SELECT COUNT(*) FROM sources`).Scan(&sources); err != nil {
+		t.Fatalf("unable to count sources: %q", err)
+	}
+
+	if sources != 3 {
+		t.Errorf("expected 3 seeded sources but got %d", sources)
+	}
+}
@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/kostromin59/poster/pkg/fakeclock"
+	"github.com/redis/go-redis/v9"
+)
+
+type contractCache interface {
+	Set(ctx context.Context, key string, data []byte) error
+	SetWithExpiration(ctx context.Context, key string, data []byte, exp time.Duration) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// cacheFactory returns a new empty cache and a function moving its clock forward.
+type cacheFactory func(t *testing.T) (contractCache, func(time.Duration))
+
+func TestContractRedis(t *testing.T) {
+	testContract(t, func(t *testing.T) (contractCache, func(time.Duration)) {
+		server := miniredis.RunT(t)
+
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+
+		cache, err := NewRedis(client)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		return cache, server.FastForward
+	})
+}
+
+func TestContractMemory(t *testing.T) {
+	testContract(t, func(t *testing.T) (contractCache, func(time.Duration)) {
+		cache := NewMemory(0, 0, 0)
+		clock := fakeclock.New()
+		cache.now = clock.Now
+
+		return cache, clock.Advance
+	})
+}
+
+func testContract(t *testing.T, newCache cacheFactory) {
+	t.Run("missing key", func(t *testing.T) {
+		cache, _ := newCache(t)
+
+		data, err := cache.Get(t.Context(), "missing")
+		if err != nil {
+			t.Errorf("unexpected error: %q", err)
+		}
+
+		if data != nil {
+			t.Errorf("expected nil but got %q", data)
+		}
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		cache, _ := newCache(t)
+
+		if err := cache.Set(t.Context(), "key", []byte("first")); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if err := cache.Set(t.Context(), "key", []byte("second")); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		data, err := cache.Get(t.Context(), "key")
+		if err != nil {
+			t.Errorf("unexpected error: %q", err)
+		}
+
+		if string(data) != "second" {
+			t.Errorf("expected %q but got %q", "second", data)
+		}
+	})
+
+	t.Run("empty value", func(t *testing.T) {
+		cache, _ := newCache(t)
+
+		if err := cache.Set(t.Context(), "key", []byte{}); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		data, err := cache.Get(t.Context(), "key")
+		if err != nil {
+			t.Errorf("unexpected error: %q", err)
+		}
+
+		if data == nil || len(data) != 0 {
+			t.Errorf("expected empty non-nil data but got %#v", data)
+		}
+	})
+
+	t.Run("stored data is not shared", func(t *testing.T) {
+		cache, _ := newCache(t)
+
+		value := []byte("value")
+		if err := cache.Set(t.Context(), "key", value); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+		value[0] = 'V'
+
+		data, _ := cache.Get(t.Context(), "key")
+		data[1] = 'A'
+
+		data, _ = cache.Get(t.Context(), "key")
+		if string(data) != "value" {
+			t.Errorf("expected %q but got %q", "value", data)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		cache, _ := newCache(t)
+
+		if err := cache.Set(t.Context(), "key", []byte("value")); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if err := cache.Delete(t.Context(), "key"); err != nil {
+			t.Errorf("unexpected error: %q", err)
+		}
+
+		if err := cache.Delete(t.Context(), "missing"); err != nil {
+			t.Errorf("unexpected error for missing key: %q", err)
+		}
+
+		if data, _ := cache.Get(t.Context(), "key"); data != nil {
+			t.Errorf("expected nil but got %q", data)
+		}
+	})
+
+	t.Run("expiration", func(t *testing.T) {
+		cache, advance := newCache(t)
+
+		if err := cache.SetWithExpiration(t.Context(), "expiring", []byte("value"), time.Minute); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if err := cache.Set(t.Context(), "persistent", []byte("value")); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		advance(30 * time.Second)
+		if data, _ := cache.Get(t.Context(), "expiring"); string(data) != "value" {
+			t.Errorf("expected the key to live, got %q", data)
+		}
+
+		advance(time.Minute)
+		if data, _ := cache.Get(t.Context(), "expiring"); data != nil {
+			t.Errorf("expected the key to expire, got %q", data)
+		}
+
+		advance(24 * time.Hour)
+		if data, _ := cache.Get(t.Context(), "persistent"); string(data) != "value" {
+			t.Errorf("expected the key without expiration to live, got %q", data)
+		}
+	})
+
+	t.Run("set resets expiration", func(t *testing.T) {
+		cache, advance := newCache(t)
+
+		if err := cache.SetWithExpiration(t.Context(), "key", []byte("value"), time.Minute); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if err := cache.Set(t.Context(), "key", []byte("value")); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		advance(time.Hour)
+		if data, _ := cache.Get(t.Context(), "key"); string(data) != "value" {
+			t.Errorf("expected the key to live, got %q", data)
+		}
+	})
+}
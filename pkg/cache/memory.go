@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process cache with LRU eviction. It behaves like Redis: a missing or expired key
+// is returned as nil data without an error. Expired entries are removed on access and by
+// a background cleanup, which is stopped by Close.
+type Memory struct {
+	maxEntries int
+	maxBytes   int
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	// lru has the most recently used entries at the front.
+	lru   *list.List
+	bytes int
+
+	now  func() time.Time
+	stop chan struct{}
+	once sync.Once
+}
+
+type memoryEntry struct {
+	key  string
+	data []byte
+	// expiresAt is zero for entries without expiration.
+	expiresAt time.Time
+}
+
+// NewMemory creates the cache. The least recently used entries are evicted when there are more than
+// maxEntries entries or their data is larger than maxBytes, zero means no limit.
+// Expired entries are cleaned up every cleanupInterval, zero disables the cleanup.
+func NewMemory(maxEntries, maxBytes int, cleanupInterval time.Duration) *Memory {
+	m := &Memory{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		items:      make(map[string]*list.Element),
+		lru:        list.New(),
+		now:        time.Now,
+		stop:       make(chan struct{}),
+	}
+
+	if cleanupInterval > 0 {
+		go m.cleanup(cleanupInterval)
+	}
+
+	return m
+}
+
+func (m *Memory) Set(ctx context.Context, key string, data []byte) error {
+	return m.SetWithExpiration(ctx, key, data, 0)
+}
+
+func (m *Memory) SetWithExpiration(_ context.Context, key string, data []byte, exp time.Duration) error {
+	entry := &memoryEntry{
+		key:  key,
+		data: clone(data),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if exp > 0 {
+		entry.expiresAt = m.now().Add(exp)
+	}
+
+	if el, ok := m.items[key]; ok {
+		m.remove(el)
+	}
+
+	// The entry larger than the whole cache is not stored, as it would evict everything else.
+	if m.maxBytes > 0 && len(entry.data) > m.maxBytes {
+		return nil
+	}
+
+	m.items[key] = m.lru.PushFront(entry)
+	m.bytes += len(entry.data)
+
+	for (m.maxEntries > 0 && m.lru.Len() > m.maxEntries) || (m.maxBytes > 0 && m.bytes > m.maxBytes) {
+		m.remove(m.lru.Back())
+	}
+
+	return nil
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.items[key]
+	if !ok {
+		gets.WithLabelValues("memory", "miss").Inc()
+		return nil, nil
+	}
+
+	entry := el.Value.(*memoryEntry)
+	if entry.expired(m.now()) {
+		m.remove(el)
+		gets.WithLabelValues("memory", "miss").Inc()
+		return nil, nil
+	}
+
+	m.lru.MoveToFront(el)
+	gets.WithLabelValues("memory", "hit").Inc()
+
+	return clone(entry.data), nil
+}
+
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.items[key]; ok {
+		m.remove(el)
+	}
+
+	return nil
+}
+
+// Len returns the number of stored entries, expired ones which are not cleaned up yet are counted.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lru.Len()
+}
+
+// Close stops the background cleanup.
+func (m *Memory) Close() error {
+	m.once.Do(func() { close(m.stop) })
+	return nil
+}
+
+func (m *Memory) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.removeExpired()
+		}
+	}
+}
+
+func (m *Memory) removeExpired() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for el := m.lru.Back(); el != nil; {
+		prev := el.Prev()
+		if el.Value.(*memoryEntry).expired(now) {
+			m.remove(el)
+		}
+		el = prev
+	}
+}
+
+// remove must be called with the lock held.
+func (m *Memory) remove(el *list.Element) {
+	entry := m.lru.Remove(el).(*memoryEntry)
+	delete(m.items, entry.key)
+	m.bytes -= len(entry.data)
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// clone copies data, so callers can't change stored entries. Empty data stays non-nil
+// to be distinguished from a missing key, as Redis returns it.
+func clone(data []byte) []byte {
+	return append([]byte{}, data...)
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kostromin59/poster/pkg/fakeclock"
+)
+
+func TestMemoryEviction(t *testing.T) {
+	t.Run("by entries", func(t *testing.T) {
+		cache := NewMemory(2, 0, 0)
+
+		_ = cache.Set(t.Context(), "first", []byte("1"))
+		_ = cache.Set(t.Context(), "second", []byte("2"))
+
+		// Reading makes the first key recently used, so the second one is evicted.
+		if data, _ := cache.Get(t.Context(), "first"); data == nil {
+			t.Fatal("expected the first key to be stored")
+		}
+		_ = cache.Set(t.Context(), "third", []byte("3"))
+
+		for key, expected := range map[string]bool{"first": true, "second": false, "third": true} {
+			if data, _ := cache.Get(t.Context(), key); (data != nil) != expected {
+				t.Errorf("expected %q stored to be %v", key, expected)
+			}
+		}
+	})
+
+	t.Run("by bytes", func(t *testing.T) {
+		cache := NewMemory(0, 10, 0)
+
+		for i := range 4 {
+			_ = cache.Set(t.Context(), fmt.Sprint(i), []byte("abcd"))
+		}
+
+		if cache.Len() != 2 {
+			t.Errorf("expected 2 entries but got %d", cache.Len())
+		}
+
+		if data, _ := cache.Get(t.Context(), "1"); data != nil {
+			t.Errorf("expected the old key to be evicted, got %q", data)
+		}
+
+		// The value larger than the limit is not stored and doesn't evict others.
+		_ = cache.Set(t.Context(), "large", make([]byte, 11))
+		if data, _ := cache.Get(t.Context(), "large"); data != nil {
+			t.Error("expected the large value not to be stored")
+		}
+
+		if cache.Len() != 2 {
+			t.Errorf("expected 2 entries but got %d", cache.Len())
+		}
+	})
+
+	t.Run("overwrite updates size", func(t *testing.T) {
+		cache := NewMemory(0, 10, 0)
+
+		_ = cache.Set(t.Context(), "key", []byte("abcdefgh"))
+		_ = cache.Set(t.Context(), "key", []byte("ab"))
+		_ = cache.Set(t.Context(), "other", []byte("abcdefgh"))
+
+		if cache.Len() != 2 {
+			t.Errorf("expected 2 entries but got %d", cache.Len())
+		}
+	})
+}
+
+func TestMemoryCleanup(t *testing.T) {
+	cache := NewMemory(0, 0, 10*time.Millisecond)
+	defer cache.Close()
+
+	clock := fakeclock.New()
+	cache.mu.Lock()
+	cache.now = clock.Now
+	cache.mu.Unlock()
+
+	_ = cache.SetWithExpiration(t.Context(), "expiring", []byte("value"), time.Minute)
+	_ = cache.Set(t.Context(), "persistent", []byte("value"))
+
+	clock.Advance(time.Hour)
+
+	deadline := time.Now().Add(time.Second)
+	for cache.Len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected expired entries to be cleaned up, got %d entries", cache.Len())
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -5,9 +5,16 @@ import (
 	"errors"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/redis/go-redis/v9"
 )
 
+var gets = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "cache_gets_total",
+	Help: "Number of cache reads by result: hit, miss or error.",
+}, []string{"cache", "result"})
+
 type Redis struct {
 	client *redis.Client
 }
@@ -44,12 +51,15 @@ func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
 	val, err := r.client.Get(ctx, key).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
+			gets.WithLabelValues("redis", "miss").Inc()
 			return nil, nil
 		}
 
+		gets.WithLabelValues("redis", "error").Inc()
 		return nil, err
 	}
 
+	gets.WithLabelValues("redis", "hit").Inc()
 	return []byte(val), nil
 }
 
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/go-redis/redismock/v9"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestRedisSetWithExpiration(t *testing.T) {
@@ -52,6 +53,7 @@ func TestRedisGet(t *testing.T) {
 		expectedData := "some_data"
 
 		mock.ExpectGet(key).SetVal(expectedData)
+		hits := testutil.ToFloat64(gets.WithLabelValues("redis", "hit"))
 
 		data, err := cache.Get(t.Context(), key)
 		if err != nil {
@@ -61,12 +63,17 @@ func TestRedisGet(t *testing.T) {
 		if string(data) != expectedData {
 			t.Errorf("expected %#v but got %#v", expectedData, string(data))
 		}
+
+		if h := testutil.ToFloat64(gets.WithLabelValues("redis", "hit")); h != hits+1 {
+			t.Errorf("expected %v hits but got %v", hits+1, h)
+		}
 	})
 
 	t.Run("not found", func(t *testing.T) {
 		key := "some_key"
 
 		mock.ExpectGet(key).RedisNil()
+		misses := testutil.ToFloat64(gets.WithLabelValues("redis", "miss"))
 
 		data, err := cache.Get(t.Context(), key)
 		if err != nil {
@@ -76,6 +83,10 @@ func TestRedisGet(t *testing.T) {
 		if len(data) != 0 {
 			t.Errorf("expected data length %d but got %d", 0, len(data))
 		}
+
+		if m := testutil.ToFloat64(gets.WithLabelValues("redis", "miss")); m != misses+1 {
+			t.Errorf("expected %v misses but got %v", misses+1, m)
+		}
 	})
 
 	t.Run("error", func(t *testing.T) {
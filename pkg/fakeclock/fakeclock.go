@@ -0,0 +1,32 @@
+// Package fakeclock is a clock for tests of code with the injected now function.
+package fakeclock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock stands still until it is moved forward, it is safe for concurrent use.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func New() *Clock {
+	return &Clock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Advance moves the clock forward.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
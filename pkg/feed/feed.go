@@ -0,0 +1,288 @@
+// Package feed renders syndication feeds in RSS 2.0, Atom 1.0 and JSON Feed 1.1 formats.
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	ContentTypeRSS  = "application/rss+xml; charset=utf-8"
+	ContentTypeAtom = "application/atom+xml; charset=utf-8"
+	ContentTypeJSON = "application/feed+json; charset=utf-8"
+)
+
+type Feed struct {
+	Title       string
+	Description string
+	// Link is the website of the feed.
+	Link string
+	// FeedLink is the URL of the feed itself.
+	FeedLink string
+	Updated  time.Time
+	Items    []Item
+}
+
+type Item struct {
+	ID          string
+	Title       string
+	Link        string
+	ContentHTML string
+	Published   time.Time
+	Updated     time.Time
+	Tags        []string
+	Enclosures  []Enclosure
+}
+
+// Enclosure is a media file of the item. Length is optional and is 0 when unknown.
+type Enclosure struct {
+	URL    string
+	Type   string
+	Length int64
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link"`
+	GUID        rssGUID        `xml:"guid"`
+	PubDate     string         `xml:"pubDate"`
+	Description string         `xml:"description"`
+	Categories  []string       `xml:"category"`
+	Enclosures  []rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// RSS renders the feed in RSS 2.0. The RSS item supports a single enclosure, so only the first one is rendered.
+func RSS(f Feed) ([]byte, error) {
+	channel := rssChannel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Description: f.Description,
+		AtomLink:    atomLink{Href: f.FeedLink, Rel: "self", Type: "application/rss+xml"},
+		Items:       make([]rssItem, len(f.Items)),
+	}
+
+	if !f.Updated.IsZero() {
+		channel.LastBuildDate = f.Updated.Format(time.RFC1123Z)
+	}
+
+	for i, item := range f.Items {
+		channel.Items[i] = rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{IsPermaLink: item.Link == item.ID, Value: item.ID},
+			PubDate:     item.Published.Format(time.RFC1123Z),
+			Description: item.ContentHTML,
+			Categories:  item.Tags,
+		}
+
+		if len(item.Enclosures) != 0 {
+			e := item.Enclosures[0]
+			channel.Items[i].Enclosures = []rssEnclosure{{URL: e.URL, Length: e.Length, Type: e.Type}}
+		}
+	}
+
+	return marshalXML(rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: channel,
+	})
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Links      []atomLink     `xml:"link"`
+	Content    atomContent    `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// Atom renders the feed in Atom 1.0.
+func Atom(f Feed) ([]byte, error) {
+	feed := atomFeed{
+		Title:   f.Title,
+		ID:      f.FeedLink,
+		Updated: f.Updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+			{Href: f.FeedLink, Rel: "self", Type: "application/atom+xml"},
+		},
+		Entries: make([]atomEntry, len(f.Items)),
+	}
+
+	for i, item := range f.Items {
+		entry := atomEntry{
+			Title:      item.Title,
+			ID:         item.ID,
+			Updated:    updated(item).Format(time.RFC3339),
+			Published:  item.Published.Format(time.RFC3339),
+			Links:      []atomLink{{Href: item.Link, Rel: "alternate", Type: "text/html"}},
+			Content:    atomContent{Type: "html", Value: item.ContentHTML},
+			Categories: make([]atomCategory, len(item.Tags)),
+		}
+
+		for j, t := range item.Tags {
+			entry.Categories[j] = atomCategory{Term: t}
+		}
+
+		for _, e := range item.Enclosures {
+			entry.Links = append(entry.Links, atomLink{Href: e.URL, Rel: "enclosure", Type: e.Type, Length: e.Length})
+		}
+
+		feed.Entries[i] = entry
+	}
+
+	return marshalXML(feed)
+}
+
+type jsonFeed struct {
+	Version     string     `json:"version"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	HomePageURL string     `json:"home_page_url,omitempty"`
+	FeedURL     string     `json:"feed_url,omitempty"`
+	Items       []jsonItem `json:"items"`
+}
+
+type jsonItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	ContentHTML   string           `json:"content_html"`
+	DatePublished string           `json:"date_published,omitempty"`
+	DateModified  string           `json:"date_modified,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	Attachments   []jsonAttachment `json:"attachments,omitempty"`
+}
+
+type jsonAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	SizeInBytes int64  `json:"size_in_bytes,omitempty"`
+}
+
+// JSON renders the feed in JSON Feed 1.1.
+func JSON(f Feed) ([]byte, error) {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		Description: f.Description,
+		HomePageURL: f.Link,
+		FeedURL:     f.FeedLink,
+		Items:       make([]jsonItem, len(f.Items)),
+	}
+
+	for i, item := range f.Items {
+		feed.Items[i] = jsonItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentHTML:   item.ContentHTML,
+			DatePublished: item.Published.Format(time.RFC3339),
+			DateModified:  updated(item).Format(time.RFC3339),
+			Tags:          item.Tags,
+		}
+
+		for _, e := range item.Enclosures {
+			feed.Items[i].Attachments = append(feed.Items[i].Attachments, jsonAttachment{
+				URL:         e.URL,
+				MimeType:    e.Type,
+				SizeInBytes: e.Length,
+			})
+		}
+	}
+
+	return json.MarshalIndent(feed, "", "  ")
+}
+
+// MediaType returns the MIME type of the enclosure. The filetype is either a MIME type or an extension,
+// the extension of the URL is used when the filetype is unknown.
+func MediaType(filetype, url string) string {
+	if strings.Contains(filetype, "/") {
+		return filetype
+	}
+
+	if t := mime.TypeByExtension("." + strings.TrimPrefix(filetype, ".")); t != "" {
+		return t
+	}
+
+	if t := mime.TypeByExtension(path.Ext(url)); t != "" {
+		return t
+	}
+
+	return "application/octet-stream"
+}
+
+func updated(item Item) time.Time {
+	if item.Updated.IsZero() {
+		return item.Published
+	}
+
+	return item.Updated
+}
+
+func marshalXML(v any) ([]byte, error) {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), b...), nil
+}
@@ -0,0 +1,133 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+var testFeed = Feed{
+	Title:    "Poster",
+	Link:     "https://example.com",
+	FeedLink: "https://example.com/feed",
+	Updated:  time.Date(2025, 12, 20, 10, 0, 0, 0, time.UTC),
+	Items: []Item{{
+		ID:          "https://example.com/posts/1",
+		Title:       "Title & more",
+		Link:        "https://example.com/posts/1",
+		ContentHTML: "<p><b>bold</b></p>",
+		Published:   time.Date(2025, 12, 20, 10, 0, 0, 0, time.UTC),
+		Tags:        []string{"go"},
+		Enclosures: []Enclosure{
+			{URL: "https://example.com/1.png", Type: "image/png"},
+			{URL: "https://example.com/2.png", Type: "image/png"},
+		},
+	}},
+}
+
+func TestRSS(t *testing.T) {
+	b, err := RSS(testFeed)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	var got struct {
+		Channel struct {
+			Items []struct {
+				Title       string `xml:"title"`
+				GUID        string `xml:"guid"`
+				PubDate     string `xml:"pubDate"`
+				Description string `xml:"description"`
+				Category    string `xml:"category"`
+				Enclosures  []struct {
+					URL string `xml:"url,attr"`
+				} `xml:"enclosure"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+
+	if err := xml.Unmarshal(b, &got); err != nil {
+		t.Fatalf("unable to unmarshal rss: %q", err)
+	}
+
+	if len(got.Channel.Items) != 1 {
+		t.Fatalf("expected 1 item but got %d", len(got.Channel.Items))
+	}
+
+	item := got.Channel.Items[0]
+
+	if item.Title != "Title & more" || item.Description != "<p><b>bold</b></p>" || item.Category != "go" {
+		t.Errorf("unexpected item: %+v", item)
+	}
+
+	if item.PubDate != "Sat, 20 Dec 2025 10:00:00 +0000" {
+		t.Errorf("unexpected pub date %q", item.PubDate)
+	}
+
+	if len(item.Enclosures) != 1 || item.Enclosures[0].URL != "https://example.com/1.png" {
+		t.Errorf("unexpected enclosures: %+v", item.Enclosures)
+	}
+}
+
+func TestAtom(t *testing.T) {
+	b, err := Atom(testFeed)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	var got struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Updated string   `xml:"updated"`
+		Entries []struct {
+			Content string `xml:"content"`
+			Links   []struct {
+				Href string `xml:"href,attr"`
+				Rel  string `xml:"rel,attr"`
+			} `xml:"link"`
+		} `xml:"entry"`
+	}
+
+	if err := xml.Unmarshal(b, &got); err != nil {
+		t.Fatalf("unable to unmarshal atom: %q", err)
+	}
+
+	if got.Updated != "2025-12-20T10:00:00Z" {
+		t.Errorf("unexpected updated %q", got.Updated)
+	}
+
+	if len(got.Entries) != 1 || got.Entries[0].Content != "<p><b>bold</b></p>" {
+		t.Fatalf("unexpected entries: %+v", got.Entries)
+	}
+
+	var enclosures int
+	for _, l := range got.Entries[0].Links {
+		if l.Rel == "enclosure" {
+			enclosures++
+		}
+	}
+
+	if enclosures != 2 {
+		t.Errorf("expected 2 enclosures but got %d", enclosures)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	b, err := JSON(testFeed)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	var got jsonFeed
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("unable to unmarshal json feed: %q", err)
+	}
+
+	if got.Version != "https://jsonfeed.org/version/1.1" || got.FeedURL != "https://example.com/feed" {
+		t.Errorf("unexpected feed: %+v", got)
+	}
+
+	if len(got.Items) != 1 || got.Items[0].ContentHTML != "<p><b>bold</b></p>" || len(got.Items[0].Attachments) != 2 {
+		t.Errorf("unexpected items: %+v", got.Items)
+	}
+}
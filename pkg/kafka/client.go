@@ -0,0 +1,18 @@
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/IBM/sarama"
+)
+
+func NewClient(hosts []string) (sarama.Client, error) {
+	const op = "pkg.Kafka.NewClient"
+
+	client, err := sarama.NewClient(hosts, sarama.NewConfig())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return client, nil
+}
@@ -6,16 +6,30 @@ import (
 	"github.com/IBM/sarama"
 )
 
-func NewConsumer(hosts []string) (sarama.Consumer, error) {
-	const op = "pkg.Kafka.NewConsumer"
+// NewConsumerClient creates the client of consumer groups. A new group starts
+// from the oldest message, then from the offsets committed by the group.
+func NewConsumerClient(hosts []string) (sarama.Client, error) {
+	const op = "pkg.Kafka.NewConsumerClient"
 
 	saramaCfg := sarama.NewConfig()
 	saramaCfg.Consumer.Return.Errors = true
+	saramaCfg.Consumer.Offsets.Initial = sarama.OffsetOldest
 
-	consumer, err := sarama.NewConsumer(hosts, saramaCfg)
+	client, err := sarama.NewClient(hosts, saramaCfg)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return consumer, nil
+	return client, nil
+}
+
+func NewConsumerGroup(client sarama.Client, group string) (sarama.ConsumerGroup, error) {
+	const op = "pkg.Kafka.NewConsumerGroup"
+
+	consumerGroup, err := sarama.NewConsumerGroupFromClient(group, client)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return consumerGroup, nil
 }
@@ -0,0 +1,93 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Local is a lease lock of one process. It is used when instances don't share Redis,
+// e.g. with the memory cache, and behaves the same way as Redis.
+type Local struct {
+	mu     sync.Mutex
+	leases map[string]localLease
+	fences map[string]int64
+	now    func() time.Time
+}
+
+type localLease struct {
+	owner     string
+	expiresAt time.Time
+}
+
+func NewLocal() *Local {
+	return &Local{
+		leases: make(map[string]localLease),
+		fences: make(map[string]int64),
+		now:    time.Now,
+	}
+}
+
+// Acquire acquires the lock for the ttl. ErrNotAcquired is returned if it is held.
+func (l *Local) Acquire(_ context.Context, key string, ttl time.Duration) (*Lease, error) {
+	const op = "lock.Local.Acquire"
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if lease, ok := l.leases[key]; ok && now.Before(lease.expiresAt) {
+		return nil, fmt.Errorf("%s: %s: %w", op, key, ErrNotAcquired)
+	}
+
+	owner := uuid.NewString()
+	l.leases[key] = localLease{owner: owner, expiresAt: now.Add(ttl)}
+	l.fences[key]++
+
+	return &Lease{
+		backend: l,
+		key:     key,
+		owner:   owner,
+		token:   l.fences[key],
+		ttl:     ttl,
+	}, nil
+}
+
+func (l *Local) renew(_ context.Context, lease *Lease) error {
+	const op = "lock.Local.renew"
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.owns(lease) {
+		return fmt.Errorf("%s: %s: %w", op, lease.key, ErrLost)
+	}
+
+	l.leases[lease.key] = localLease{owner: lease.owner, expiresAt: l.now().Add(lease.ttl)}
+
+	return nil
+}
+
+func (l *Local) release(_ context.Context, lease *Lease) error {
+	const op = "lock.Local.release"
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.owns(lease) {
+		return fmt.Errorf("%s: %s: %w", op, lease.key, ErrLost)
+	}
+
+	delete(l.leases, lease.key)
+
+	return nil
+}
+
+// owns must be called with the lock held.
+func (l *Local) owns(lease *Lease) bool {
+	current, ok := l.leases[lease.key]
+	return ok && current.owner == lease.owner && l.now().Before(current.expiresAt)
+}
@@ -0,0 +1,79 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotAcquired is returned when the lock is held by another owner.
+	ErrNotAcquired = errors.New("lock is held by another owner")
+	// ErrLost is returned when the lease has expired or has been taken by another owner.
+	ErrLost = errors.New("lock lease has been lost")
+)
+
+type backend interface {
+	renew(ctx context.Context, l *Lease) error
+	release(ctx context.Context, l *Lease) error
+}
+
+// Lease is an acquired lock. It expires after the ttl unless it is renewed.
+type Lease struct {
+	backend backend
+	key     string
+	owner   string
+	token   int64
+	ttl     time.Duration
+}
+
+// Token returns the fencing token. Tokens of the key grow with every acquisition. Nothing checks
+// them yet, the token is only logged to tell owners apart: jobs rely on idempotent writes instead.
+func (l *Lease) Token() int64 {
+	return l.token
+}
+
+// Renew extends the lease for the ttl. ErrLost is returned if the lease has expired.
+func (l *Lease) Renew(ctx context.Context) error {
+	return l.backend.renew(ctx, l)
+}
+
+// Release releases the lock. ErrLost is returned if the lease has expired.
+func (l *Lease) Release(ctx context.Context) error {
+	return l.backend.release(ctx, l)
+}
+
+// Hold renews the lease every third of the ttl until stop is called. The returned context
+// is cancelled when the lease is lost, so the work under the lock can be stopped.
+func (l *Lease) Hold(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	wg := new(sync.WaitGroup)
+	done := make(chan struct{})
+
+	wg.Go(func() {
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := l.Renew(ctx); err != nil && !errors.Is(err, context.Canceled) {
+					cancel(err)
+					return
+				}
+			}
+		}
+	})
+
+	return ctx, func() {
+		close(done)
+		wg.Wait()
+		cancel(nil)
+	}
+}
@@ -0,0 +1,158 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/kostromin59/poster/pkg/fakeclock"
+	"github.com/redis/go-redis/v9"
+)
+
+type locker interface {
+	Acquire(ctx context.Context, key string, ttl time.Duration) (*Lease, error)
+}
+
+// lockerFactory returns a new locker and a function moving its clock forward.
+type lockerFactory func(t *testing.T) (locker, func(time.Duration))
+
+func TestRedis(t *testing.T) {
+	testLocker(t, func(t *testing.T) (locker, func(time.Duration)) {
+		server := miniredis.RunT(t)
+
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+
+		return NewRedis(client), server.FastForward
+	})
+}
+
+func TestRedisKeys(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	if _, err := NewRedis(client).Acquire(t.Context(), "job", time.Minute); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	// Both keys have the same hash tag, so they are in the same slot of Redis Cluster.
+	for _, key := range []string{"poster:lock:{job}", "poster:lock:{job}:fence"} {
+		if !server.Exists(key) {
+			t.Errorf("expected key %q, got %v", key, server.Keys())
+		}
+	}
+}
+
+func TestLocal(t *testing.T) {
+	testLocker(t, func(t *testing.T) (locker, func(time.Duration)) {
+		l := NewLocal()
+		clock := fakeclock.New()
+		l.now = clock.Now
+
+		return l, clock.Advance
+	})
+}
+
+func testLocker(t *testing.T, newLocker lockerFactory) {
+	t.Run("exclusive", func(t *testing.T) {
+		l, _ := newLocker(t)
+
+		lease, err := l.Acquire(t.Context(), "job", time.Minute)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if _, err := l.Acquire(t.Context(), "job", time.Minute); !errors.Is(err, ErrNotAcquired) {
+			t.Errorf("expected ErrNotAcquired but got %v", err)
+		}
+
+		if _, err := l.Acquire(t.Context(), "other", time.Minute); err != nil {
+			t.Errorf("unexpected error for other key: %q", err)
+		}
+
+		if err := lease.Release(t.Context()); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		next, err := l.Acquire(t.Context(), "job", time.Minute)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		if next.Token() <= lease.Token() {
+			t.Errorf("expected fencing token to grow, got %d after %d", next.Token(), lease.Token())
+		}
+	})
+
+	t.Run("expiration", func(t *testing.T) {
+		l, advance := newLocker(t)
+
+		lease, err := l.Acquire(t.Context(), "job", time.Minute)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		advance(45 * time.Second)
+		if err := lease.Renew(t.Context()); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		advance(45 * time.Second)
+		if _, err := l.Acquire(t.Context(), "job", time.Minute); !errors.Is(err, ErrNotAcquired) {
+			t.Errorf("expected the renewed lease to be held, got %v", err)
+		}
+
+		advance(time.Minute)
+		next, err := l.Acquire(t.Context(), "job", time.Minute)
+		if err != nil {
+			t.Fatalf("expected the expired lease to be free, got %q", err)
+		}
+
+		if err := lease.Renew(t.Context()); !errors.Is(err, ErrLost) {
+			t.Errorf("expected ErrLost on renew but got %v", err)
+		}
+
+		if err := lease.Release(t.Context()); !errors.Is(err, ErrLost) {
+			t.Errorf("expected ErrLost on release but got %v", err)
+		}
+
+		if _, err := l.Acquire(t.Context(), "job", time.Minute); !errors.Is(err, ErrNotAcquired) {
+			t.Errorf("expected the stale owner not to release the new lease, got %v", err)
+		}
+
+		if err := next.Release(t.Context()); err != nil {
+			t.Errorf("unexpected error: %q", err)
+		}
+	})
+
+	t.Run("hold", func(t *testing.T) {
+		l, advance := newLocker(t)
+
+		lease, err := l.Acquire(t.Context(), "job", 30*time.Millisecond)
+		if err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		ctx, stop := lease.Hold(t.Context())
+		defer stop()
+
+		// The lease is taken over while it is held, so the next renewal cancels the context.
+		advance(time.Minute)
+		if _, err := l.Acquire(t.Context(), "job", time.Minute); err != nil {
+			t.Fatalf("unexpected error: %q", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			if !errors.Is(context.Cause(ctx), ErrLost) {
+				t.Errorf("expected ErrLost cause but got %v", context.Cause(ctx))
+			}
+		case <-time.After(time.Second):
+			t.Error("expected the context to be cancelled")
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireScript sets the owner with the ttl if the key is free and increments
+// the fencing token of the key. 0 is returned when the key is held.
+var acquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0
+`)
+
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+const keyPrefix = "poster:lock:"
+
+// leaseKey returns the key of the lease. The key is a hash tag, so the lease and the fencing token
+// are in the same slot of Redis Cluster and the script doesn't fail with CROSSSLOT.
+func leaseKey(key string) string {
+	return keyPrefix + "{" + key + "}"
+}
+
+func fenceKey(key string) string {
+	return leaseKey(key) + ":fence"
+}
+
+// Redis is a lease lock shared by instances: SET NX PX with a random owner.
+// Renewal and release check the owner, so an expired lease can't affect the next owner.
+type Redis struct {
+	client redis.Scripter
+}
+
+func NewRedis(client redis.Scripter) *Redis {
+	return &Redis{
+		client: client,
+	}
+}
+
+// Acquire acquires the lock for the ttl. ErrNotAcquired is returned if it is held.
+func (r *Redis) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lease, error) {
+	const op = "lock.Redis.Acquire"
+
+	owner := uuid.NewString()
+
+	token, err := acquireScript.Run(ctx, r.client, []string{leaseKey(key), fenceKey(key)}, owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if token == 0 {
+		return nil, fmt.Errorf("%s: %s: %w", op, key, ErrNotAcquired)
+	}
+
+	return &Lease{
+		backend: r,
+		key:     key,
+		owner:   owner,
+		token:   token,
+		ttl:     ttl,
+	}, nil
+}
+
+func (r *Redis) renew(ctx context.Context, l *Lease) error {
+	const op = "lock.Redis.renew"
+
+	ok, err := renewScript.Run(ctx, r.client, []string{leaseKey(l.key)}, l.owner, l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if ok == 0 {
+		return fmt.Errorf("%s: %s: %w", op, l.key, ErrLost)
+	}
+
+	return nil
+}
+
+func (r *Redis) release(ctx context.Context, l *Lease) error {
+	const op = "lock.Redis.release"
+
+	ok, err := releaseScript.Run(ctx, r.client, []string{leaseKey(l.key)}, l.owner).Int64()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if ok == 0 {
+		return fmt.Errorf("%s: %s: %w", op, l.key, ErrLost)
+	}
+
+	return nil
+}
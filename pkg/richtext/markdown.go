@@ -0,0 +1,629 @@
+package richtext
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	htmlTagRe       = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9-]*)([^<>]*)>`)
+	hrefRe          = regexp.MustCompile(`(?i)href\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	languageRe      = regexp.MustCompile(`language-([\w+-]+)`)
+	entityRe        = regexp.MustCompile(`^&(?:#[0-9]+|#[xX][0-9a-fA-F]+|[A-Za-z][A-Za-z0-9]*);`)
+	autolinkRe      = regexp.MustCompile(`^<(https?://[^\s<>]+)>`)
+	inlineTagRe     = regexp.MustCompile(`^</?[A-Za-z][A-Za-z0-9-]*(?:\s[^<>]*)?/?>`)
+	orderedListRe   = regexp.MustCompile(`^[0-9]+[.)]`)
+	fenceRe         = regexp.MustCompile("^(`{3,}|~{3,})[ \t]*([\\w+-]*)[ \t]*$")
+	markdownEscaper = strings.NewReplacer(
+		`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `~`, `\~`, `[`, `\[`, `]`, `\]`, `<`, `\<`,
+	)
+)
+
+var emphasisDelimiters = map[string]string{
+	"b":      "**",
+	"strong": "**",
+	"i":      "*",
+	"em":     "*",
+	"s":      "~~",
+	"strike": "~~",
+	"del":    "~~",
+}
+
+// node is an element or a text of the content. Text is unescaped.
+type node struct {
+	tag      string
+	open     string
+	close    string
+	attrs    string
+	text     string
+	children []*node
+}
+
+func parseHTML(content string) []*node {
+	root := &node{}
+	stack := []*node{root}
+
+	appendText := func(text string) {
+		if text == "" {
+			return
+		}
+
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, &node{text: html.UnescapeString(text)})
+	}
+
+	last := 0
+	for _, m := range htmlTagRe.FindAllStringSubmatchIndex(content, -1) {
+		appendText(content[last:m[0]])
+		last = m[1]
+
+		tag := strings.ToLower(content[m[4]:m[5]])
+
+		if m[3] > m[2] {
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].tag == tag {
+					stack[i].close = content[m[0]:m[1]]
+					stack = stack[:i]
+					break
+				}
+			}
+
+			continue
+		}
+
+		n := &node{tag: tag, open: content[m[0]:m[1]], attrs: content[m[6]:m[7]]}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, n)
+		stack = append(stack, n)
+	}
+
+	appendText(content[last:])
+
+	return root.children
+}
+
+func plainText(nodes []*node) string {
+	b := &strings.Builder{}
+	for _, n := range nodes {
+		if n.tag == "" {
+			b.WriteString(n.text)
+			continue
+		}
+
+		b.WriteString(plainText(n.children))
+	}
+
+	return b.String()
+}
+
+type markdownWriter struct {
+	b *strings.Builder
+	// block allows fenced code and quotes, they are written only on separate lines.
+	block     bool
+	lineStart bool
+}
+
+func newMarkdownWriter(block, lineStart bool) *markdownWriter {
+	return &markdownWriter{b: &strings.Builder{}, block: block, lineStart: lineStart}
+}
+
+func (w *markdownWriter) atLineStart() bool {
+	s := w.b.String()
+	if s == "" {
+		return w.lineStart
+	}
+
+	return s[len(s)-1] == '\n'
+}
+
+func (w *markdownWriter) writeNodes(nodes []*node) {
+	for i, n := range nodes {
+		if n.tag == "" {
+			w.writeText(n.text)
+			continue
+		}
+
+		// Blocks must be followed by a new line or the end of the content.
+		ownLine := w.block && w.atLineStart() &&
+			(i == len(nodes)-1 || nodes[i+1].tag == "" && strings.HasPrefix(nodes[i+1].text, "\n"))
+
+		w.writeElement(n, ownLine)
+	}
+}
+
+func (w *markdownWriter) writeText(text string) {
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			w.b.WriteString("\n")
+		}
+
+		if line == "" {
+			continue
+		}
+
+		if w.atLineStart() {
+			if strings.ContainsRune("#>-+=|", rune(line[0])) {
+				w.b.WriteString(`\`)
+			} else if m := orderedListRe.FindString(line); m != "" {
+				w.b.WriteString(m[:len(m)-1] + `\` + m[len(m)-1:])
+				line = line[len(m):]
+			}
+		}
+
+		w.writeEscaped(line)
+	}
+}
+
+// writeEscaped escapes characters of the Markdown syntax. Ampersands are escaped only before entities.
+func (w *markdownWriter) writeEscaped(s string) {
+	s = markdownEscaper.Replace(s)
+
+	for {
+		i := strings.IndexByte(s, '&')
+		if i == -1 {
+			w.b.WriteString(s)
+			return
+		}
+
+		w.b.WriteString(s[:i])
+		if entityRe.MatchString(s[i:]) {
+			w.b.WriteString(`\`)
+		}
+
+		w.b.WriteString("&")
+		s = s[i+1:]
+	}
+}
+
+func (w *markdownWriter) writeElement(n *node, ownLine bool) {
+	if delimiter, ok := emphasisDelimiters[n.tag]; ok {
+		inner := newMarkdownWriter(false, w.atLineStart())
+		inner.writeNodes(n.children)
+
+		// Emphasis is not recognized when the delimiter is next to a whitespace.
+		text := inner.b.String()
+		core := strings.TrimSpace(text)
+		if core == "" {
+			w.b.WriteString(text)
+			return
+		}
+
+		start := strings.Index(text, core)
+		w.b.WriteString(text[:start] + delimiter + core + delimiter + text[start+len(core):])
+
+		return
+	}
+
+	switch n.tag {
+	case "a":
+		m := hrefRe.FindStringSubmatch(n.attrs)
+		if m == nil {
+			break
+		}
+
+		href := html.UnescapeString(m[1] + m[2])
+		if strings.ContainsAny(href, "() ") {
+			href = "<" + href + ">"
+		}
+
+		inner := newMarkdownWriter(false, w.atLineStart())
+		inner.writeNodes(n.children)
+
+		w.b.WriteString("[" + inner.b.String() + "](" + href + ")")
+
+		return
+	case "code":
+		w.writeCode(plainText(n.children))
+		return
+	case "pre":
+		if !ownLine {
+			break
+		}
+
+		language := ""
+		if len(n.children) == 1 && n.children[0].tag == "code" {
+			if m := languageRe.FindStringSubmatch(n.children[0].attrs); m != nil {
+				language = m[1]
+			}
+		}
+
+		text := plainText(n.children)
+		fence := strings.Repeat("`", max(3, longestRun(text, '`')+1))
+
+		w.b.WriteString(fence + language + "\n" + text + "\n" + fence)
+
+		return
+	case "blockquote":
+		if !ownLine {
+			break
+		}
+
+		inner := newMarkdownWriter(true, true)
+		inner.writeNodes(n.children)
+
+		for i, line := range strings.Split(inner.b.String(), "\n") {
+			if i > 0 {
+				w.b.WriteString("\n")
+			}
+
+			if line == "" {
+				w.b.WriteString(">")
+				continue
+			}
+
+			w.b.WriteString("> " + line)
+		}
+
+		return
+	}
+
+	// Other elements are kept as inline HTML.
+	inner := newMarkdownWriter(false, false)
+	inner.writeNodes(n.children)
+
+	w.b.WriteString(n.open + inner.b.String() + n.close)
+}
+
+func (w *markdownWriter) writeCode(text string) {
+	if text == "" {
+		return
+	}
+
+	n := 1
+	for hasRun(text, '`', n) {
+		n++
+	}
+
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") ||
+		strings.HasPrefix(text, " ") && strings.HasSuffix(text, " ") && strings.TrimSpace(text) != "" {
+		text = " " + text + " "
+	}
+
+	delimiter := strings.Repeat("`", n)
+	w.b.WriteString(delimiter + text + delimiter)
+}
+
+func longestRun(s string, c byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != c {
+			run = 0
+			continue
+		}
+
+		run++
+		longest = max(longest, run)
+	}
+
+	return longest
+}
+
+func hasRun(s string, c byte, n int) bool {
+	run := 0
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) && s[i] == c {
+			run++
+			continue
+		}
+
+		if run == n {
+			return true
+		}
+
+		run = 0
+	}
+
+	return false
+}
+
+// ToMarkdown converts the content to CommonMark. Bold, italic, strikethrough, code, links, code blocks
+// and quotes get Markdown syntax, other tags (underline, spoilers) are kept as inline HTML.
+// Newlines are kept as is, so FromMarkdown restores the same content.
+func ToMarkdown(content string) string {
+	w := newMarkdownWriter(true, true)
+	w.writeNodes(parseHTML(strings.ReplaceAll(content, "\r\n", "\n")))
+
+	return w.b.String()
+}
+
+// FromMarkdown converts Markdown written by ToMarkdown or by hand back to the content.
+// Only the syntax of the Telegram HTML subset is supported: emphasis, strikethrough, code spans,
+// links, fenced code blocks and quotes. Inline HTML is kept as is.
+func FromMarkdown(markdown string) string {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+
+	var blocks, text []string
+
+	flushText := func() {
+		if len(text) != 0 {
+			blocks = append(blocks, parseInline(strings.Join(text, "\n")))
+			text = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := fenceRe.FindStringSubmatch(line); m != nil {
+			flushText()
+
+			var code []string
+			for i++; i < len(lines); i++ {
+				closing := strings.TrimRight(lines[i], " \t")
+				if len(closing) >= len(m[1]) && strings.Trim(closing, m[1][:1]) == "" {
+					break
+				}
+
+				code = append(code, lines[i])
+			}
+
+			escaped := html.EscapeString(strings.Join(code, "\n"))
+			if m[2] != "" {
+				blocks = append(blocks, `<pre><code class="language-`+m[2]+`">`+escaped+"</code></pre>")
+				continue
+			}
+
+			blocks = append(blocks, "<pre>"+escaped+"</pre>")
+
+			continue
+		}
+
+		if strings.HasPrefix(line, ">") {
+			flushText()
+
+			var quote []string
+			for ; i < len(lines) && strings.HasPrefix(lines[i], ">"); i++ {
+				quote = append(quote, strings.TrimPrefix(strings.TrimPrefix(lines[i], ">"), " "))
+			}
+			i--
+
+			blocks = append(blocks, "<blockquote>"+FromMarkdown(strings.Join(quote, "\n"))+"</blockquote>")
+
+			continue
+		}
+
+		text = append(text, line)
+	}
+
+	flushText()
+
+	return strings.Join(blocks, "\n")
+}
+
+type emphasis struct {
+	tag       string
+	delimiter byte
+}
+
+func parseInline(s string) string {
+	var (
+		out   []byte
+		stack []emphasis
+		links []int
+	)
+
+	writeEscaped := func(c byte) {
+		switch c {
+		case '<':
+			out = append(out, "&lt;"...)
+		case '>':
+			out = append(out, "&gt;"...)
+		case '&':
+			out = append(out, "&amp;"...)
+		default:
+			out = append(out, c)
+		}
+	}
+
+	isSpace := func(i int) bool {
+		return i < 0 || i >= len(s) || unicode.IsSpace(rune(s[i]))
+	}
+
+	isWord := func(i int) bool {
+		return i >= 0 && i < len(s) && (s[i] >= 0x80 || unicode.IsLetter(rune(s[i])) || unicode.IsDigit(rune(s[i])))
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '\n' || s[i+1] < 0x80 && unicode.IsPunct(rune(s[i+1])) || s[i+1] < 0x80 && unicode.IsSymbol(rune(s[i+1]))):
+			writeEscaped(s[i+1])
+			i += 2
+		case c == '`':
+			n := runLength(s[i:], '`')
+			end := findRun(s[i+n:], '`', n)
+			if end == -1 {
+				out = append(out, s[i:i+n]...)
+				i += n
+
+				break
+			}
+
+			code := s[i+n : i+n+end]
+			if len(code) > 1 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+				code = code[1 : len(code)-1]
+			}
+
+			out = append(out, "<code>"+html.EscapeString(code)+"</code>"...)
+			i += 2*n + end
+		case c == '*' || c == '_' || c == '~' && strings.HasPrefix(s[i:], "~~"):
+			n := runLength(s[i:], c)
+			if c == '_' && isWord(i-1) && isWord(i+n) || c == '~' && n != 2 {
+				out = append(out, s[i:i+n]...)
+				i += n
+
+				break
+			}
+
+			closing := false
+			for rest := n; rest > 0; {
+				top := len(stack) - 1
+				switch {
+				case top >= 0 && stack[top].delimiter == c && stack[top].tag == "i":
+					out = append(out, "</i>"...)
+					stack = stack[:top]
+					rest--
+				case top >= 0 && stack[top].delimiter == c && rest >= 2:
+					out = append(out, "</"+stack[top].tag+">"...)
+					stack = stack[:top]
+					rest -= 2
+				case !closing && isSpace(i+n):
+					// The delimiter before a whitespace is a literal character.
+					out = append(out, s[i+n-rest:i+n]...)
+					rest = 0
+				default:
+					tag := "i"
+					if c == '~' {
+						tag = "s"
+					}
+
+					if rest >= 2 && c != '~' {
+						tag = "b"
+					}
+
+					out = append(out, "<"+tag+">"...)
+					stack = append(stack, emphasis{tag: tag, delimiter: c})
+
+					if tag == "i" {
+						rest--
+					} else {
+						rest -= 2
+					}
+				}
+
+				closing = true
+			}
+
+			i += n
+		case c == '[':
+			links = append(links, len(out))
+			i++
+		case c == ']' && len(links) != 0:
+			start := links[len(links)-1]
+			links = links[:len(links)-1]
+
+			href, n := parseLinkDestination(s[i+1:])
+			if n == 0 {
+				out = append(out[:start], append([]byte("["), out[start:]...)...)
+				out = append(out, ']')
+				i++
+
+				break
+			}
+
+			open := []byte(`<a href="` + escapeAttribute(href) + `">`)
+			out = append(out[:start], append(open, out[start:]...)...)
+			out = append(out, "</a>"...)
+			i += 1 + n
+		case c == '<':
+			if m := autolinkRe.FindStringSubmatch(s[i:]); m != nil {
+				out = append(out, `<a href="`+escapeAttribute(m[1])+`">`+html.EscapeString(m[1])+"</a>"...)
+				i += len(m[0])
+
+				break
+			}
+
+			if m := inlineTagRe.FindString(s[i:]); m != "" {
+				out = append(out, m...)
+				i += len(m)
+
+				break
+			}
+
+			writeEscaped(c)
+			i++
+		case c == '&':
+			if m := entityRe.FindString(s[i:]); m != "" {
+				out = append(out, m...)
+				i += len(m)
+
+				break
+			}
+
+			writeEscaped(c)
+			i++
+		default:
+			writeEscaped(c)
+			i++
+		}
+	}
+
+	for i := len(links) - 1; i >= 0; i-- {
+		out = append(out[:links[i]], append([]byte("["), out[links[i]:]...)...)
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		out = append(out, "</"+stack[i].tag+">"...)
+	}
+
+	return string(out)
+}
+
+// parseLinkDestination parses "(url)" or "(<url>)" after the link text and returns the url and the parsed length.
+func parseLinkDestination(s string) (string, int) {
+	if !strings.HasPrefix(s, "(") {
+		return "", 0
+	}
+
+	if strings.HasPrefix(s, "(<") {
+		end := strings.Index(s, ">)")
+		if end == -1 {
+			return "", 0
+		}
+
+		return s[2:end], end + 2
+	}
+
+	depth := 0
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case ' ', '\n':
+			return "", 0
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return s[1:i], i + 1
+			}
+
+			depth--
+		}
+	}
+
+	return "", 0
+}
+
+func escapeAttribute(s string) string {
+	return strings.NewReplacer("&", "&amp;", `"`, "&quot;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func runLength(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+
+	return n
+}
+
+// findRun returns the index of the run of exactly n characters.
+func findRun(s string, c byte, n int) int {
+	for i := 0; i < len(s); {
+		if s[i] != c {
+			i++
+			continue
+		}
+
+		run := runLength(s[i:], c)
+		if run == n {
+			return i
+		}
+
+		i += run
+	}
+
+	return -1
+}
@@ -0,0 +1,74 @@
+package richtext
+
+import "testing"
+
+func TestToMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "emphasis", content: "<b>bold</b>, <i>italic</i> and <s>strike</s>", expected: "**bold**, *italic* and ~~strike~~"},
+		{name: "spaces inside emphasis", content: "a<b> b </b>c", expected: "a **b** c"},
+		{name: "escape", content: "2 * 2 &lt; 5 &amp;amp; snake_case", expected: `2 \* 2 \< 5 \&amp; snake\_case`},
+		{name: "line start", content: "# not a header\n- not a list\n1. not a list", expected: "\\# not a header\n\\- not a list\n1\\. not a list"},
+		{name: "link", content: `<a href="https://example.com/?a=1&amp;b=2">site</a>`, expected: "[site](https://example.com/?a=1&b=2)"},
+		{name: "code", content: "<code>a `b`</code>", expected: "`` a `b` ``"},
+		{name: "code block", content: "text\n<pre><code class=\"language-go\">fmt.Println(\"&lt;\")</code></pre>\nmore", expected: "text\n```go\nfmt.Println(\"<\")\n```\nmore"},
+		{name: "inline code block", content: "text <pre>x</pre>", expected: "text <pre>x</pre>"},
+		{name: "quote", content: "<blockquote>one\n\ntwo</blockquote>", expected: "> one\n>\n> two"},
+		{name: "inline html", content: "<u>under</u> <tg-spoiler><b>spoiler</b></tg-spoiler>", expected: "<u>under</u> <tg-spoiler>**spoiler**</tg-spoiler>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToMarkdown(tt.content); got != tt.expected {
+				t.Errorf("expected %q but got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFromMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{name: "emphasis", markdown: "**bold** __bold__ *italic* _italic_ ~~strike~~", expected: "<b>bold</b> <b>bold</b> <i>italic</i> <i>italic</i> <s>strike</s>"},
+		{name: "nested emphasis", markdown: "***both***", expected: "<b><i>both</i></b>"},
+		{name: "literal", markdown: "2 * 2 < 5 & snake_case", expected: "2 * 2 &lt; 5 &amp; snake_case"},
+		{name: "links", markdown: "[**site**](https://example.com/a_(b)) <https://example.com> [not a link]", expected: `<a href="https://example.com/a_(b)"><b>site</b></a> <a href="https://example.com">https://example.com</a> [not a link]`},
+		{name: "entities", markdown: "&copy; \\&copy;", expected: "&copy; &amp;copy;"},
+		{name: "hard break", markdown: "one\\\ntwo", expected: "one\ntwo"},
+		{name: "code block", markdown: "~~~\n<b>\n~~~", expected: "<pre>&lt;b&gt;</pre>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromMarkdown(tt.markdown); got != tt.expected {
+				t.Errorf("expected %q but got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	tests := []string{
+		"<b>Заголовок</b>\n\nТекст с <i>курсивом</i>, <u>подчёркиванием</u> и <s>зачёркиванием</s>.",
+		"<b>bold <i>and italic</i></b><i>italic</i>",
+		`Ссылка: <a href="https://example.com/?q=a%20b&amp;c=(d)">пример</a>`,
+		"Код <code>x := `y`</code> и блок:\n<pre><code class=\"language-go\">if a &lt; b &amp;&amp; c {\n}</code></pre>\nконец",
+		"<blockquote>Цитата\n<b>жирная</b></blockquote>\n1) пункт\n- пункт\n#тег",
+		"Спецсимволы: * _ ~ ` [ ] \\ &lt; &gt; &amp; &amp;amp; | = +",
+		`<tg-spoiler>спойлер</tg-spoiler> <span class="tg-spoiler">ещё</span>`,
+		"<pre>без языка</pre>",
+	}
+
+	for _, content := range tests {
+		markdown := ToMarkdown(content)
+		if got := FromMarkdown(markdown); got != content {
+			t.Errorf("content %q\nmarkdown %q\nrestored %q", content, markdown, got)
+		}
+	}
+}
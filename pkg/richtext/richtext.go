@@ -0,0 +1,56 @@
+// Package richtext converts post content written in the Telegram HTML subset
+// (<b>, <i>, <a>, <code>, <pre> and plain newlines) to other formats.
+package richtext
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	tagRe       = regexp.MustCompile(`<[^>]*>`)
+	linkRe      = regexp.MustCompile(`(?is)<a\s[^>]*href\s*=\s*["']([^"']*)["'][^>]*>(.*?)</a>`)
+	manyBreakRe = regexp.MustCompile(`\n{3,}`)
+)
+
+// ToHTML converts the content to the HTML document fragment: paragraphs are separated
+// by empty lines and single newlines become <br>.
+func ToHTML(content string) string {
+	content = strings.ReplaceAll(strings.TrimSpace(content), "\r\n", "\n")
+	if content == "" {
+		return ""
+	}
+
+	paragraphs := strings.Split(manyBreakRe.ReplaceAllString(content, "\n\n"), "\n\n")
+
+	b := &strings.Builder{}
+	b.Grow(len(content) + len(paragraphs)*len("<p></p>"))
+
+	for _, p := range paragraphs {
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(p, "\n", "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// ToText strips tags and unescapes entities. Links keep their URL in parentheses.
+func ToText(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	content = linkRe.ReplaceAllStringFunc(content, func(link string) string {
+		m := linkRe.FindStringSubmatch(link)
+		href, text := m[1], tagRe.ReplaceAllString(m[2], "")
+		if href == "" || href == text {
+			return text
+		}
+
+		return text + " (" + href + ")"
+	})
+
+	content = tagRe.ReplaceAllString(content, "")
+
+	return strings.TrimSpace(html.UnescapeString(content))
+}
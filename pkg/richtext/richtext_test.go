@@ -0,0 +1,45 @@
+package richtext
+
+import "testing"
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "empty", content: " \n ", expected: ""},
+		{name: "single line", content: "<b>hello</b>", expected: "<p><b>hello</b></p>"},
+		{name: "line breaks", content: "one\ntwo", expected: "<p>one<br>\ntwo</p>"},
+		{name: "paragraphs", content: "one\n\n\n\ntwo\r\nthree", expected: "<p>one</p>\n<p>two<br>\nthree</p>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToHTML(tt.content); got != tt.expected {
+				t.Errorf("expected %q but got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestToText(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "tags", content: "<b>bold</b> and <i>italic</i>", expected: "bold and italic"},
+		{name: "entities", content: "a &lt; b &amp;&amp; c", expected: "a < b && c"},
+		{name: "link", content: `see <a href="https://example.com">site</a>`, expected: "see site (https://example.com)"},
+		{name: "bare link", content: `<a href="https://example.com">https://example.com</a>`, expected: "https://example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToText(tt.content); got != tt.expected {
+				t.Errorf("expected %q but got %q", tt.expected, got)
+			}
+		})
+	}
+}
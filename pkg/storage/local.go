@@ -0,0 +1,113 @@
+// Package storage stores media files.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var ErrInvalidName = errors.New("invalid file name")
+
+// Local stores files in the directory. Files are served by the HTTP server under the base URL.
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocal(dir, baseURL string) *Local {
+	return &Local{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Save writes the file and returns its URL. The existing file with the same name is replaced.
+func (l *Local) Save(ctx context.Context, name string, r io.Reader) (string, error) {
+	name = path.Clean("/" + name)
+	if name == "/" {
+		return "", ErrInvalidName
+	}
+
+	filename := filepath.Join(l.dir, filepath.FromSlash(name))
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(filename), ".tmp-")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(f, readerWithContext{ctx: ctx, r: r}); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+
+	if err := os.Rename(f.Name(), filename); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+
+	return l.URL(name), nil
+}
+
+// Open opens the file by its name.
+func (l *Local) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(l.dir, filepath.FromSlash(path.Clean("/"+name))))
+}
+
+// URL returns the URL of the file by its name.
+func (l *Local) URL(name string) string {
+	segments := strings.Split(strings.TrimPrefix(path.Clean("/"+name), "/"), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+
+	return l.baseURL + "/" + strings.Join(segments, "/")
+}
+
+// Name returns the name of the file by its URL. It reports false when the URL is not of the storage.
+func (l *Local) Name(uri string) (string, bool) {
+	rest, ok := strings.CutPrefix(uri, l.baseURL+"/")
+	if !ok || rest == "" {
+		return "", false
+	}
+
+	name, err := url.PathUnescape(rest)
+	if err != nil {
+		return "", false
+	}
+
+	return name, true
+}
+
+type readerWithContext struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r readerWithContext) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalSave(t *testing.T) {
+	local := NewLocal(t.TempDir(), "https://example.com/media/")
+
+	uri, err := local.Save(t.Context(), "telegram/1/фото 1.jpg", strings.NewReader("first"))
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	if want := "https://example.com/media/telegram/1/%D1%84%D0%BE%D1%82%D0%BE%201.jpg"; uri != want {
+		t.Errorf("expected uri %q but got %q", want, uri)
+	}
+
+	if _, err := local.Save(t.Context(), "../telegram/1/фото 1.jpg", strings.NewReader("second")); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+
+	f, err := local.Open("telegram/1/фото 1.jpg")
+	if err != nil {
+		t.Fatalf("unable to open file: %q", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("unable to read file: %q", err)
+	}
+
+	if string(data) != "second" {
+		t.Errorf("expected the replaced file but got %q", data)
+	}
+
+	if _, err := local.Save(t.Context(), "/", strings.NewReader("")); err == nil {
+		t.Errorf("expected error for empty name")
+	}
+}
+
+func TestLocalName(t *testing.T) {
+	local := NewLocal(t.TempDir(), "https://example.com/media")
+
+	name, ok := local.Name(local.URL("telegram/1/фото 1.jpg"))
+	if !ok || name != "telegram/1/фото 1.jpg" {
+		t.Errorf("unexpected name %q", name)
+	}
+
+	if _, ok := local.Name("https://example.org/media/1.jpg"); ok {
+		t.Errorf("expected foreign url to be rejected")
+	}
+}